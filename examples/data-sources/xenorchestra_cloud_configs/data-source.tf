@@ -0,0 +1,13 @@
+# List all cloud configs whose name starts with "web-"
+data "xenorchestra_cloud_configs" "web" {
+  name_regex = "^web-"
+}
+
+# List all cloud network configs
+data "xenorchestra_cloud_configs" "network" {
+  network_configs = true
+}
+
+output "web_cloud_config_ids" {
+  value = data.xenorchestra_cloud_configs.web.cloud_configs[*].id
+}
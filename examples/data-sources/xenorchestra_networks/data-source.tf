@@ -0,0 +1,14 @@
+data "xenorchestra_pool" "pool" {
+  name_label = "Your pool"
+}
+
+# Every network of the pool whose name starts with "vlan-"
+data "xenorchestra_networks" "vlans" {
+  pool_id    = data.xenorchestra_pool.pool.id
+  name_regex = "^vlan-"
+  sort_by    = "name_label"
+}
+
+output "vlan_network_ids" {
+  value = data.xenorchestra_networks.vlans.networks[*].id
+}
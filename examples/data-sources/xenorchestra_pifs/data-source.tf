@@ -0,0 +1,14 @@
+data "xenorchestra_pool" "pool" {
+  name_label = "Your pool"
+}
+
+# The eth1 PIF of every host in the pool
+data "xenorchestra_pifs" "eth1" {
+  pool_id = data.xenorchestra_pool.pool.id
+  device  = "eth1"
+  vlan    = -1
+}
+
+output "eth1_pif_ids" {
+  value = data.xenorchestra_pifs.eth1.pifs[*].id
+}
@@ -1,3 +1,12 @@
 data "xenorchestra_resource_set" "rs" {
   name = "my resource set"
 }
+
+# Report the share of each limit that is consumed, e.g. to alert before
+# the resource set's users hit their quota
+output "resource_set_usage" {
+  value = {
+    for usage in data.xenorchestra_resource_set.rs.usage :
+    usage.type => usage.total == 0 ? 0 : usage.used / usage.total
+  }
+}
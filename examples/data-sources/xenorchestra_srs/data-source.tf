@@ -0,0 +1,19 @@
+data "xenorchestra_pool" "pool" {
+  name_label = "Your pool"
+}
+
+# All of the shared storage repositories of the pool
+data "xenorchestra_srs" "shared" {
+  pool_id = data.xenorchestra_pool.pool.id
+  shared  = true
+  sort_by = "name_label"
+}
+
+resource "xenorchestra_vdi" "scratch" {
+  for_each = { for sr in data.xenorchestra_srs.shared.srs : sr.name_label => sr.id }
+
+  name_label = "scratch on ${each.key}"
+  sr_id      = each.value
+  filepath   = "${path.module}/scratch.iso"
+  type       = "raw"
+}
@@ -7,3 +7,27 @@ resource "xenorchestra_vm" "demo-vm" {
   template = data.xenorchestra_template.template.id
   // ...
 }
+
+# Look up the template by regular expression and tag and size the
+# VM's disks to match the template's
+data "xenorchestra_template" "golden" {
+  name_regex = "^debian-12-golden"
+  tags       = ["golden-image"]
+}
+
+resource "xenorchestra_vm" "golden-vm" {
+  // ...
+  template   = data.xenorchestra_template.golden.id
+  cpus       = data.xenorchestra_template.golden.cpus
+  memory_max = data.xenorchestra_template.golden.memory_max
+
+  dynamic "disk" {
+    for_each = data.xenorchestra_template.golden.disks
+    content {
+      sr_id      = disk.value.sr_id
+      name_label = disk.value.name_label
+      size       = disk.value.size
+    }
+  }
+  // ...
+}
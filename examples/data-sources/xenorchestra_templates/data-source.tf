@@ -0,0 +1,16 @@
+data "xenorchestra_pool" "pool" {
+  name_label = "Your pool"
+}
+
+# Every Debian template of the pool that boots with UEFI
+data "xenorchestra_templates" "debian" {
+  pool_id       = data.xenorchestra_pool.pool.id
+  name_regex    = "^Debian"
+  boot_firmware = "uefi"
+  sort_by       = "name_label"
+  sort_order    = "desc"
+}
+
+output "newest_debian_template" {
+  value = data.xenorchestra_templates.debian.templates[0].id
+}
@@ -0,0 +1,14 @@
+data "xenorchestra_sr" "iso" {
+  name_label = "ISO library"
+}
+
+# Every ISO available in the ISO library
+data "xenorchestra_vdis" "isos" {
+  sr_id      = data.xenorchestra_sr.iso.id
+  name_regex = "\\.iso$"
+  sort_by    = "name_label"
+}
+
+output "iso_names" {
+  value = data.xenorchestra_vdis.isos.vdis[*].name_label
+}
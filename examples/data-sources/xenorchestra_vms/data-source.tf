@@ -16,3 +16,19 @@ output "vms_max_memory_map" {
 output "vms_length" {
   value = length(data.xenorchestra_vms.vms.vms)
 }
+
+# Find the first 5 production web servers (sorted by name) that are
+# tagged with both `web` and `prod`
+data "xenorchestra_vms" "web" {
+  pool_id = data.xenorchestra_pool.pool.id
+  name_regex = "^web-"
+  tags = ["web", "prod"]
+  tag_match = "all"
+  xenstore = {
+    role = "frontend"
+  }
+
+  sort_by = "name_label"
+  sort_order = "asc"
+  limit = 5
+}
@@ -48,3 +48,27 @@ resource "xenorchestra_bonded_network" "network_vlan" {
     data.xenorchestra_pif.eth2_vlan.id,
   ]
 }
+
+# Create a LACP bond balancing traffic by TCP/UDP ports
+data "xenorchestra_pif" "eth3" {
+  device = "eth3"
+  vlan = -1
+  host_id = data.xenorchestra_host.host1.id
+}
+
+data "xenorchestra_pif" "eth4" {
+  device = "eth4"
+  vlan = -1
+  host_id = data.xenorchestra_host.host1.id
+}
+
+resource "xenorchestra_bonded_network" "network_lacp" {
+  name_label = "lacp network"
+  bond_mode = "lacp"
+  hashing_algorithm = "tcpudp_ports"
+  pool_id = data.xenorchestra_host.host1.pool_id
+  pif_ids = [
+    data.xenorchestra_pif.eth3.id,
+    data.xenorchestra_pif.eth4.id,
+  ]
+}
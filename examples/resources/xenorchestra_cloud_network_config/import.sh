@@ -0,0 +1 @@
+terraform import xenorchestra_cloud_network_config.static 00000000-0000-0000-0000-000000000000
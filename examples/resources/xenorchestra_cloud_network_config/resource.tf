@@ -0,0 +1,19 @@
+resource "xenorchestra_cloud_network_config" "static" {
+  name = "static network config"
+  template = <<EOF
+version: 1
+config:
+  - type: physical
+    name: eth0
+    subnets:
+      - type: static
+        address: 192.168.1.10/24
+        gateway: 192.168.1.1
+EOF
+}
+
+resource "xenorchestra_vm" "bar" {
+  // ...
+  cloud_network_config = xenorchestra_cloud_network_config.static.template
+  // ...
+}
@@ -0,0 +1,3 @@
+# The host's ID can be found from the following command:
+# $ xo-cli list-objects type=host
+$ terraform import xenorchestra_host.host1 <uuid>
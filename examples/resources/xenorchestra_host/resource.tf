@@ -0,0 +1,20 @@
+data "xenorchestra_host" "host1" {
+  name_label = "Your host"
+}
+
+resource "xenorchestra_host" "host1" {
+  host_id            = data.xenorchestra_host.host1.id
+  name_description   = "Managed by terraform"
+  syslog_destination = "syslog.example.com"
+  tags               = ["production"]
+
+  # Set to false to enable maintenance mode and evacuate the host's VMs
+  enabled = true
+
+  power_on_mode = "IPMI"
+  power_on_config = {
+    power_on_ip              = "192.168.1.100"
+    power_on_user            = "admin"
+    power_on_password_secret = "ipmi-password"
+  }
+}
@@ -0,0 +1,3 @@
+# The IP pool's ID can be found from the following command:
+# $ xo-cli ipPool.getAll
+$ terraform import xenorchestra_ip_pool.pool <id>
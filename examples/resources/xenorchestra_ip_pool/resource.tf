@@ -0,0 +1,31 @@
+data "xenorchestra_network" "net" {
+  name_label = "Pool-wide network associated with eth0"
+}
+
+resource "xenorchestra_ip_pool" "pool" {
+  name           = "tenant addresses"
+  address_ranges = ["192.168.10.10-192.168.10.99", "192.168.10.200"]
+  network_ids    = [data.xenorchestra_network.net.id]
+}
+
+# VMs allocate an address from the pool with ip_pool_id, which can be
+# written in their cloud-init network config
+resource "xenorchestra_vm" "vm" {
+  # ...
+  cloud_network_config = <<EOF
+network:
+  version: 1
+  config:
+    - type: physical
+      name: eth0
+      subnets:
+        - type: static
+          address: {network.0.ip_pool_address}/24
+          gateway: 192.168.10.1
+EOF
+
+  network {
+    network_id = data.xenorchestra_network.net.id
+    ip_pool_id = xenorchestra_ip_pool.pool.id
+  }
+}
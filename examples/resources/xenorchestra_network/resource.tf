@@ -15,3 +15,24 @@ resource "xenorchestra_network" "vlan_network" {
   source_pif_device = "eth0"
   vlan = 22
 }
+
+# Jumbo frames on a VLAN network. Setting allow_pif_replug declares that the
+# network's PIFs may be replugged so the MTU is changed without recreating
+# the network and detaching its VIFs.
+resource "xenorchestra_network" "storage_network" {
+  name_label = "storage"
+  pool_id = data.xenorchestra_host.host1.pool_id
+  source_pif_device = "eth1"
+  vlan = 30
+  mtu = 9000
+  allow_pif_replug = true
+}
+
+# Create a VLAN on top of a bond. Every host of the pool must have a PIF on
+# the bonded network.
+resource "xenorchestra_network" "vlan_on_bond" {
+  name_label = "vlan 40 on bond"
+  pool_id = data.xenorchestra_host.host1.pool_id
+  source_network_id = xenorchestra_bonded_network.network.id
+  vlan = 40
+}
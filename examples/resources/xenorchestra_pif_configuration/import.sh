@@ -0,0 +1,3 @@
+# The PIF's ID can be found from the following command:
+# $ xo-cli list-objects type=PIF
+$ terraform import xenorchestra_pif_configuration.storage 4f4a9b2c-1d6e-4f3a-9c8b-2e7d5a1b0c9f
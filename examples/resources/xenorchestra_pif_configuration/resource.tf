@@ -0,0 +1,44 @@
+data "xenorchestra_pool" "pool" {
+  name_label = "Your pool"
+}
+
+# A storage network on VLAN 100 of every host's eth1
+resource "xenorchestra_network" "storage" {
+  name_label        = "storage"
+  pool_id           = data.xenorchestra_pool.pool.id
+  source_pif_device = "eth1"
+  vlan              = 100
+}
+
+# Give each host's storage PIF a static address
+resource "xenorchestra_pif_configuration" "storage" {
+  count = length(xenorchestra_network.storage.host_pifs)
+
+  pif_id       = xenorchestra_network.storage.host_pifs[count.index].pif_id
+  mode         = "static"
+  ipv4_address = cidrhost("10.0.100.0/24", 10 + count.index)
+  ipv4_netmask = "255.255.255.0"
+  ipv6_mode    = "static"
+  ipv6_address = "fd00:100::${10 + count.index}/64"
+
+  # Keep the storage PIFs plugged, as XAPI does for storage interfaces
+  disallow_unplug = true
+}
+
+# Changing the management interface requires an explicit confirmation
+data "xenorchestra_pif" "management" {
+  device  = "eth0"
+  vlan    = -1
+  host_id = data.xenorchestra_pool.pool.master
+}
+
+resource "xenorchestra_pif_configuration" "management" {
+  pif_id       = data.xenorchestra_pif.management.id
+  mode         = "static"
+  ipv4_address = "192.168.1.10"
+  ipv4_netmask = "255.255.255.0"
+  ipv4_gateway = "192.168.1.1"
+  dns          = ["192.168.1.1"]
+
+  allow_management_reconfiguration = true
+}
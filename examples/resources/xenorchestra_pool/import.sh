@@ -0,0 +1,3 @@
+# The pool's ID can be found from the following command:
+# $ xo-cli list-objects type=pool
+$ terraform import xenorchestra_pool.pool 2c8f3a1e-6f0b-4b0e-9d6a-5a2f1c7e3b4d
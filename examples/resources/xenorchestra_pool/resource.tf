@@ -0,0 +1,25 @@
+data "xenorchestra_pool" "pool" {
+  name_label = "Your pool"
+}
+
+data "xenorchestra_sr" "shared" {
+  name_label = "Shared NFS"
+}
+
+data "xenorchestra_network" "migration" {
+  name_label = "migration"
+}
+
+resource "xenorchestra_pool" "pool" {
+  pool_id              = data.xenorchestra_pool.pool.id
+  name_description     = "Production pool, managed by terraform"
+  default_sr_id        = data.xenorchestra_sr.shared.id
+  suspend_sr_id        = data.xenorchestra_sr.shared.id
+  crash_dump_sr_id     = data.xenorchestra_sr.shared.id
+  migration_network_id = data.xenorchestra_network.migration.id
+  auto_poweron         = true
+  tags                 = ["production"]
+
+  ha_enabled          = true
+  ha_heartbeat_sr_ids = [data.xenorchestra_sr.shared.id]
+}
@@ -0,0 +1,3 @@
+# The pool's ID can be found from the following command:
+# $ xo-cli list-objects type=pool
+$ terraform import xenorchestra_pool_patches.pool <uuid>
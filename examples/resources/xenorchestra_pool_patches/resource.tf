@@ -0,0 +1,18 @@
+data "xenorchestra_pool" "pool" {
+  name_label = "Your pool"
+}
+
+# The plan shows the patches that will be installed as the new value of
+# installed_patches. Set install to false to only report the missing patches.
+resource "xenorchestra_pool_patches" "pool" {
+  pool_id = data.xenorchestra_pool.pool.id
+
+  timeouts {
+    create = "3h"
+    update = "3h"
+  }
+}
+
+output "missing_patches" {
+  value = xenorchestra_pool_patches.pool.missing_patches[*].name
+}
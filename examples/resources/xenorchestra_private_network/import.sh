@@ -0,0 +1,5 @@
+# The private network's ID is the uuid the SDN Controller stores in the
+# other_config of its networks, which can be found from the following command:
+# $ xo-cli list-objects type=network | grep xo:sdn-controller:private-network-uuid
+# The pif_ids of an imported private network are the pool masters' PIFs.
+$ terraform import xenorchestra_private_network.tenant 7b1f5c2e-3a4d-4e8f-9c6b-2d1a0e5f8c3b
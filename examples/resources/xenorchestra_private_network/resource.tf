@@ -0,0 +1,39 @@
+data "xenorchestra_pool" "pool_a" {
+  name_label = "pool-a"
+}
+
+data "xenorchestra_pool" "pool_b" {
+  name_label = "pool-b"
+}
+
+data "xenorchestra_pif" "pool_a_eth1" {
+  device  = "eth1"
+  vlan    = -1
+  host_id = data.xenorchestra_pool.pool_a.master
+}
+
+data "xenorchestra_pif" "pool_b_eth1" {
+  device  = "eth1"
+  vlan    = -1
+  host_id = data.xenorchestra_pool.pool_b.master
+}
+
+# A tenant network spanning the hosts of both pools through VXLAN tunnels
+resource "xenorchestra_private_network" "tenant" {
+  name_label    = "tenant-a"
+  encapsulation = "vxlan"
+  encrypted     = true
+  mtu           = 1450
+
+  pool_ids = [data.xenorchestra_pool.pool_a.id, data.xenorchestra_pool.pool_b.id]
+  pif_ids  = [data.xenorchestra_pif.pool_a_eth1.id, data.xenorchestra_pif.pool_b_eth1.id]
+}
+
+resource "xenorchestra_vm" "vm" {
+  # Other required options omitted
+  # [ ... ]
+
+  network {
+    network_id = xenorchestra_private_network.tenant.network_ids[data.xenorchestra_pool.pool_a.id]
+  }
+}
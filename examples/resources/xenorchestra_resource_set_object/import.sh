@@ -0,0 +1,4 @@
+# The ID is made of the resource set's ID and the object's ID separated by a colon.
+# The resource set's ID can be found from the following command:
+# $ xo-cli resourceSet.getAll
+$ terraform import xenorchestra_resource_set_object.team_sr MGSpuwnbtUE:<object uuid>
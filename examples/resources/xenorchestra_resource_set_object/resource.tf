@@ -0,0 +1,12 @@
+data "xenorchestra_resource_set" "shared" {
+  name = "shared resource set"
+}
+
+data "xenorchestra_sr" "team_sr" {
+  name_label = "Team SR"
+}
+
+resource "xenorchestra_resource_set_object" "team_sr" {
+  resource_set_id = data.xenorchestra_resource_set.shared.id
+  object_id       = data.xenorchestra_sr.team_sr.id
+}
@@ -0,0 +1,4 @@
+# The ID is made of the resource set's ID and the subject's ID separated by a colon.
+# The resource set's ID can be found from the following command:
+# $ xo-cli resourceSet.getAll
+$ terraform import xenorchestra_resource_set_subject.user MGSpuwnbtUE:<user uuid>
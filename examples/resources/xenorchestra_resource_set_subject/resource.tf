@@ -0,0 +1,12 @@
+data "xenorchestra_resource_set" "shared" {
+  name = "shared resource set"
+}
+
+data "xenorchestra_user" "user" {
+  username = "my-username"
+}
+
+resource "xenorchestra_resource_set_subject" "user" {
+  resource_set_id = data.xenorchestra_resource_set.shared.id
+  subject_id      = data.xenorchestra_user.user.id
+}
@@ -0,0 +1,3 @@
+# ID can be found from the following command:
+# $ xo-cli list-objects type=VM-template
+$ terraform import xenorchestra_template.golden 7e4ab5a0-2e50-4b7c-a1f5-2c3b8d0c6b8f
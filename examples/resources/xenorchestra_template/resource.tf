@@ -0,0 +1,33 @@
+data "xenorchestra_template" "base" {
+  name_label = "Debian Bookworm 12"
+}
+
+# A VM built and provisioned by the image pipeline
+resource "xenorchestra_vm" "golden" {
+  name_label  = "debian-12-golden-build"
+  template    = data.xenorchestra_template.base.id
+  power_state = "Halted"
+
+  # Other required options omitted
+  # [ ... ]
+}
+
+# Clone the halted VM into a template that new VMs can be created from
+resource "xenorchestra_template" "golden" {
+  source_vm_id     = xenorchestra_vm.golden.id
+  name_label       = "debian-12-golden"
+  name_description = "Debian 12 image built by the image pipeline"
+  tags             = ["golden-image"]
+
+  other_config = {
+    "install-methods" = "cdrom,nfs,http,ftp"
+  }
+}
+
+resource "xenorchestra_vm" "web" {
+  name_label = "web-1"
+  template   = xenorchestra_template.golden.id
+
+  # Other required options omitted
+  # [ ... ]
+}
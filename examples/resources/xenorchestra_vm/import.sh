@@ -0,0 +1,8 @@
+# The VM can be imported by its uuid, its name_label or `<pool>/<name_label>`,
+# where the pool is given by its name_label or uuid.
+# XO does not report the template, clone_type, installation_method,
+# destroy_cloud_config_vdi_after_boot and the cloud-init attributes of an
+# existing VM, so they are left unset by the import and the values in the
+# config are accepted without a diff. The VM's next update stores them.
+$ terraform import xenorchestra_vm.vm1 <uuid>
+$ terraform import xenorchestra_vm.vm1 "Production/web-01"
@@ -0,0 +1,18 @@
+data "xenorchestra_vms" "web" {
+  tags = ["web"]
+}
+
+# Export a VM as a compressed XVA. Bumping the date exports it again.
+resource "xenorchestra_vm_export" "web" {
+  vm_id    = data.xenorchestra_vms.web.vms[0].id
+  filepath = "${path.module}/exports/web.xva"
+  compress = true
+
+  triggers = {
+    date = "2024-01-01"
+  }
+}
+
+output "export_checksum" {
+  value = xenorchestra_vm_export.web.sha256
+}
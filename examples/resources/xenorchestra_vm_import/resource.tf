@@ -0,0 +1,42 @@
+data "xenorchestra_sr" "local_storage" {
+  name_label = "Local storage"
+}
+
+data "xenorchestra_network" "net" {
+  name_label = "Pool-wide network associated with eth0"
+}
+
+# Upload an XVA from the machine running terraform and connect its first
+# network interface to the pool-wide network
+resource "xenorchestra_vm_import" "appliance" {
+  type       = "xva"
+  filepath   = "${path.module}/appliance.xva"
+  sr_id      = data.xenorchestra_sr.local_storage.id
+  name_label = "appliance"
+
+  network_map = {
+    "0" = data.xenorchestra_network.net.id
+  }
+}
+
+# Let XO download an XVA from a web server
+resource "xenorchestra_vm_import" "from_url" {
+  type       = "xva"
+  source_url = "https://images.example.com/appliance.xva"
+  sr_id      = data.xenorchestra_sr.local_storage.id
+}
+
+# OVA appliances require every network interface to be mapped
+resource "xenorchestra_vm_import" "ova" {
+  type     = "ova"
+  filepath = "${path.module}/appliance.ova"
+  sr_id    = data.xenorchestra_sr.local_storage.id
+
+  network_map = {
+    "0" = data.xenorchestra_network.net.id
+  }
+}
+
+output "imported_vm_id" {
+  value = xenorchestra_vm_import.appliance.vm_id
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"log"
+	"os"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/plugin"
 	"github.com/vatesfr/terraform-provider-xenorchestra/xoa"
@@ -14,9 +17,18 @@ import (
 func main() {
 
 	var debugMode bool
+	var generateImport bool
 	flag.BoolVar(&debugMode, "debuggable", false, "set to true to run the provider with support for debuggers like delve")
+	flag.BoolVar(&generateImport, "generate-import", false, "print the import blocks and configuration of the existing VMs, networks, resource sets, ACLs and cloud configs of the XO instance configured through the XOA_* environment variables")
 	flag.Parse()
 
+	if generateImport {
+		if err := xoa.GenerateImportConfig(context.Background(), os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	opts := &plugin.ServeOpts{
 		Debug:        debugMode,
 		ProviderAddr: "registry.terraform.io/vatesfr/xenorchestra",
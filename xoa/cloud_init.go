@@ -0,0 +1,193 @@
+package xoa
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ipPoolAddressPlaceholder matches the `{network.<index>.ip_pool_address}`
+// placeholders that are substituted in the cloud network config at creation.
+var ipPoolAddressPlaceholder = regexp.MustCompile(`\{network\.\d+\.ip_pool_address\}`)
+
+var validCloudNetworkConfigV1Types = []string{"physical", "bond", "bridge", "vlan", "nameserver", "route"}
+
+var validOpenStackNetworkDataKeys = []string{"links", "networks", "services"}
+
+var validCloudNetworkConfigV2Keys = []string{"version", "renderer", "ethernets", "bonds", "bridges", "vlans", "wifis", "tunnels"}
+
+// cloudInitUserDataHeaders are the first lines cloud-init recognizes a
+// user-data format by, other than `#!` scripts.
+var cloudInitUserDataHeaders = []string{
+	"#cloud-config",
+	"#cloud-config-archive",
+	"#cloud-config-jsonp",
+	"#include",
+	"#include-once",
+	"#cloud-boothook",
+	"#part-handler",
+	"#upstart-job",
+	"## template: jinja",
+}
+
+// gzipMagic starts gzip compressed user-data, which cloud-init decompresses
+// before looking at its header.
+const gzipMagic = "\x1f\x8b"
+
+// validateCloudInitUserData verifies that the user-data starts with a header
+// cloud-init understands, that a `#cloud-config` document is a YAML mapping
+// and that a `#cloud-config-archive` document is a YAML list.
+func validateCloudInitUserData(userData string) error {
+	if strings.HasPrefix(userData, gzipMagic) || isMimeMultipart(userData) {
+		return nil
+	}
+
+	firstLine := strings.TrimSpace(strings.SplitN(strings.TrimLeft(userData, "\r\n"), "\n", 2)[0])
+	switch {
+	case firstLine == "#cloud-config":
+		return validateYamlMapping(userData)
+	case firstLine == "#cloud-config-archive":
+		return validateYamlList(userData)
+	case strings.HasPrefix(firstLine, "#!"), stringInSlice(firstLine, cloudInitUserDataHeaders):
+		return nil
+	}
+	return fmt.Errorf("user-data must start with `#!`, one of `%s`, be gzip compressed or be a MIME multipart document, found %q", strings.Join(cloudInitUserDataHeaders, "`, `"), firstLine)
+}
+
+// isMimeMultipart reports whether the user-data is a MIME multipart archive
+// by looking for a multipart Content-Type in its headers.
+func isMimeMultipart(userData string) bool {
+	for _, line := range strings.Split(userData, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			// End of the MIME headers
+			return false
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 && strings.EqualFold(strings.TrimSpace(parts[0]), "Content-Type") {
+			return strings.HasPrefix(strings.ToLower(strings.TrimSpace(parts[1])), "multipart/")
+		}
+	}
+	return false
+}
+
+// validateCloudInitNetworkConfig verifies that the network config is a
+// version 1 or version 2 cloud-init network configuration. The document may
+// be wrapped in a top level `network` key.
+func validateCloudInitNetworkConfig(networkConfig string) error {
+	networkConfig = ipPoolAddressPlaceholder.ReplaceAllString(networkConfig, "192.0.2.1")
+
+	var document map[string]interface{}
+	if err := yaml.Unmarshal([]byte(networkConfig), &document); err != nil {
+		return fmt.Errorf("network config is not a valid YAML mapping: %w", err)
+	}
+	if network, ok := document["network"]; ok {
+		networkMap, ok := network.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("network config `network` key must be a mapping")
+		}
+		document = networkMap
+	}
+
+	switch version := document["version"]; version {
+	case 1:
+		return validateCloudNetworkConfigV1(document)
+	case 2:
+		return validateCloudNetworkConfigV2(document)
+	default:
+		return fmt.Errorf("network config version must be 1 or 2, found %v", version)
+	}
+}
+
+func validateCloudNetworkConfigV1(document map[string]interface{}) error {
+	config, ok := document["config"].([]interface{})
+	if !ok {
+		return fmt.Errorf("version 1 network config must contain a `config` list")
+	}
+	for i, entry := range config {
+		entryMap, ok := entry.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("version 1 network config entry %d must be a mapping", i)
+		}
+		entryType, _ := entryMap["type"].(string)
+		if !stringInSlice(entryType, validCloudNetworkConfigV1Types) {
+			return fmt.Errorf("version 1 network config entry %d has an invalid type %q, must be one of %s", i, entryType, strings.Join(validCloudNetworkConfigV1Types, ", "))
+		}
+	}
+	return nil
+}
+
+func validateCloudNetworkConfigV2(document map[string]interface{}) error {
+	for key, value := range document {
+		if !stringInSlice(key, validCloudNetworkConfigV2Keys) {
+			return fmt.Errorf("version 2 network config has an unknown key %q, must be one of %s", key, strings.Join(validCloudNetworkConfigV2Keys, ", "))
+		}
+		if key == "version" || key == "renderer" {
+			continue
+		}
+		if _, ok := value.(map[string]interface{}); !ok {
+			return fmt.Errorf("version 2 network config `%s` must be a mapping", key)
+		}
+	}
+	return nil
+}
+
+// validateOpenStackNetworkData verifies that the network config of an
+// OpenStack config drive is a network_data.json document.
+func validateOpenStackNetworkData(networkData string) error {
+	networkData = ipPoolAddressPlaceholder.ReplaceAllString(networkData, "192.0.2.1")
+
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(networkData), &document); err != nil {
+		return fmt.Errorf("OpenStack network data is not a valid JSON object: %w", err)
+	}
+	for key, value := range document {
+		if !stringInSlice(key, validOpenStackNetworkDataKeys) {
+			return fmt.Errorf("OpenStack network data has an unknown key %q, must be one of %s", key, strings.Join(validOpenStackNetworkDataKeys, ", "))
+		}
+		if _, ok := value.([]interface{}); !ok {
+			return fmt.Errorf("OpenStack network data `%s` must be a list", key)
+		}
+	}
+	return nil
+}
+
+func validateYamlMapping(document string) error {
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(document), &value); err != nil {
+		return fmt.Errorf("cloud-config is not valid YAML: %w", err)
+	}
+	if value == nil {
+		return nil
+	}
+	if _, ok := value.(map[string]interface{}); !ok {
+		return fmt.Errorf("cloud-config must be a YAML mapping")
+	}
+	return nil
+}
+
+func validateYamlList(document string) error {
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(document), &value); err != nil {
+		return fmt.Errorf("cloud-config-archive is not valid YAML: %w", err)
+	}
+	if value == nil {
+		return nil
+	}
+	if _, ok := value.([]interface{}); !ok {
+		return fmt.Errorf("cloud-config-archive must be a YAML list")
+	}
+	return nil
+}
+
+func stringInSlice(s string, slice []string) bool {
+	for _, v := range slice {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
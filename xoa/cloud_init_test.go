@@ -0,0 +1,111 @@
+package xoa
+
+import (
+	"testing"
+)
+
+func Test_validateCloudInitUserData(t *testing.T) {
+	tests := []struct {
+		userData string
+		valid    bool
+	}{
+		{userData: "#cloud-config\nhostname: web\npackages:\n  - nginx\n", valid: true},
+		{userData: "#cloud-config", valid: true},
+		{userData: "\n#cloud-config\nhostname: web", valid: true},
+		{userData: "#!/bin/sh\necho hello", valid: true},
+		{userData: "Content-Type: multipart/mixed; boundary=\"==BOUNDARY==\"\nMIME-Version: 1.0\n\n--==BOUNDARY==\n", valid: true},
+		{userData: "MIME-Version: 1.0\nContent-Type: multipart/mixed; boundary=\"x\"\n\n--x\n", valid: true},
+		{userData: "#cloud-config-archive\n- type: text/cloud-config\n  content: |\n    hostname: web\n", valid: true},
+		{userData: "#cloud-config-archive\nhostname: web", valid: false},
+		{userData: "#cloud-config-jsonp\n[]", valid: true},
+		{userData: "## template: jinja\n#cloud-config\nhostname: {{ v1.local_hostname }}\n", valid: true},
+		{userData: "#include\nhttps://example.com/user-data\n", valid: true},
+		{userData: "#include-once\nhttps://example.com/user-data\n", valid: true},
+		{userData: "#cloud-boothook\necho hello", valid: true},
+		{userData: "#part-handler\ndef list_types():\n  return []\n", valid: true},
+		{userData: "#upstart-job\nstart on runlevel [2345]\n", valid: true},
+		{userData: "\x1f\x8b\x08\x00\x00\x00\x00\x00", valid: true},
+		{userData: "#cloud-configuration\nhostname: web", valid: false},
+		{userData: "#includes\nhttps://example.com/user-data", valid: false},
+		{userData: "template", valid: false},
+		{userData: "hostname: web", valid: false},
+		{userData: "Content-Type: text/plain\n\nhello", valid: false},
+		{userData: "#cloud-config\nhostname: web\n  packages: [", valid: false},
+		{userData: "#cloud-config\n- nginx", valid: false},
+	}
+
+	for _, test := range tests {
+		err := validateCloudInitUserData(test.userData)
+		if test.valid && err != nil {
+			t.Errorf("expected %q to be valid, received: %v", test.userData, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("expected %q to be invalid", test.userData)
+		}
+	}
+}
+
+func Test_validateCloudInitNetworkConfig(t *testing.T) {
+	tests := []struct {
+		networkConfig string
+		valid         bool
+	}{
+		{
+			networkConfig: `
+network:
+  version: 1
+  config:
+    - type: physical
+      name: eth0
+      subnets:
+        - type: static
+          address: {network.0.ip_pool_address}/24
+`,
+			valid: true,
+		},
+		{networkConfig: "version: 1\nconfig:\n  - type: physical\n    name: eth0\n", valid: true},
+		{networkConfig: "version: 2\nethernets:\n  eth0:\n    dhcp4: true\n", valid: true},
+		{networkConfig: "network:\n  version: 2\n  renderer: networkd\n  ethernets:\n    eth0:\n      dhcp4: true\n", valid: true},
+		{networkConfig: "version: 3\n", valid: false},
+		{networkConfig: "config:\n  - type: physical\n", valid: false},
+		{networkConfig: "version: 1\nconfig:\n  - type: ethernet\n", valid: false},
+		{networkConfig: "version: 1\nconfig: eth0\n", valid: false},
+		{networkConfig: "version: 2\ninterfaces:\n  eth0: {}\n", valid: false},
+		{networkConfig: "version: 2\nethernets: eth0\n", valid: false},
+		{networkConfig: "version: 1\n  config: [", valid: false},
+		{networkConfig: "network: eth0\n", valid: false},
+	}
+
+	for _, test := range tests {
+		err := validateCloudInitNetworkConfig(test.networkConfig)
+		if test.valid && err != nil {
+			t.Errorf("expected %q to be valid, received: %v", test.networkConfig, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("expected %q to be invalid", test.networkConfig)
+		}
+	}
+}
+
+func Test_validateOpenStackNetworkData(t *testing.T) {
+	tests := []struct {
+		networkData string
+		valid       bool
+	}{
+		{networkData: `{"links": [{"id": "eth0", "type": "phy"}], "networks": [{"id": "n0", "link": "eth0", "type": "ipv4", "ip_address": "{network.0.ip_pool_address}"}], "services": []}`, valid: true},
+		{networkData: `{}`, valid: true},
+		{networkData: "version: 2\n", valid: false},
+		{networkData: `{"interfaces": []}`, valid: false},
+		{networkData: `{"links": {}}`, valid: false},
+	}
+
+	for _, test := range tests {
+		err := validateOpenStackNetworkData(test.networkData)
+		if test.valid && err != nil {
+			t.Errorf("expected %q to be valid, received: %v", test.networkData, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("expected %q to be invalid", test.networkData)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package xoa
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+const (
+	noCloudDatasource     = "NoCloud"
+	configDriveDatasource = "ConfigDrive"
+)
+
+var validCloudInitDatasources = []string{noCloudDatasource, configDriveDatasource}
+
+// configDrive holds the cloud-init documents written to a VM's config drive.
+type configDrive struct {
+	Datasource    string
+	InstanceId    string
+	LocalHostname string
+	UserData      string
+	VendorData    string
+	NetworkConfig string
+}
+
+// configDriveInstanceId returns the default instance-id of a VM's config
+// drive. Unlike the drive XO creates along with the VM, whose instance-id is
+// the VM's UUID, it depends on the documents so that cloud-init runs again
+// when they change.
+func configDriveInstanceId(vmId string, documents ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(documents, "\x00")))
+	return fmt.Sprintf("%s-%x", vmId, sum[:4])
+}
+
+// files returns the content of the drive in the layout of its datasource.
+func (cd configDrive) files() (map[string][]byte, error) {
+	if cd.Datasource == configDriveDatasource {
+		return cd.openStackFiles()
+	}
+
+	metaData := fmt.Sprintf("instance-id: %s\n", cd.InstanceId)
+	if cd.LocalHostname != "" {
+		metaData += fmt.Sprintf("local-hostname: %s\n", cd.LocalHostname)
+	}
+	files := map[string][]byte{
+		"meta-data": []byte(metaData),
+		"user-data": []byte(cd.UserData),
+	}
+	if cd.VendorData != "" {
+		files["vendor-data"] = []byte(cd.VendorData)
+	}
+	if cd.NetworkConfig != "" {
+		files["network-config"] = []byte(cd.NetworkConfig)
+	}
+	return files, nil
+}
+
+// openStackFiles returns the content of an OpenStack config drive. Its
+// network configuration is OpenStack's network_data.json.
+func (cd configDrive) openStackFiles() (map[string][]byte, error) {
+	metaData := map[string]interface{}{
+		"uuid": cd.InstanceId,
+	}
+	if cd.LocalHostname != "" {
+		metaData["hostname"] = cd.LocalHostname
+		metaData["name"] = cd.LocalHostname
+	}
+	metaDataJson, err := json.Marshal(metaData)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{
+		"openstack/latest/meta_data.json": metaDataJson,
+		"openstack/latest/user_data":      []byte(cd.UserData),
+	}
+	if cd.VendorData != "" {
+		vendorDataJson, err := json.Marshal(map[string]string{"cloud-init": cd.VendorData})
+		if err != nil {
+			return nil, err
+		}
+		files["openstack/latest/vendor_data.json"] = vendorDataJson
+	}
+	if cd.NetworkConfig != "" {
+		files["openstack/latest/network_data.json"] = []byte(cd.NetworkConfig)
+	}
+	return files, nil
+}
+
+func (cd configDrive) image() ([]byte, error) {
+	files, err := cd.files()
+	if err != nil {
+		return nil, err
+	}
+	label := "cidata"
+	if cd.Datasource == configDriveDatasource {
+		label = "config-2"
+	}
+	return buildFatImage(label, files)
+}
+
+// vmUsesProviderConfigDrive reports whether the VM's config drive must be
+// generated by the provider, XO only supports NoCloud drives holding
+// user-data and network-config.
+func vmUsesProviderConfigDrive(d resourceDataGetter) bool {
+	return d.Get("cloud_init_datasource").(string) == configDriveDatasource ||
+		d.Get("cloud_vendor_data").(string) != "" ||
+		len(d.Get("cloud_meta_data").([]interface{})) > 0
+}
+
+// resourceDataGetter is implemented by schema.ResourceData and
+// schema.ResourceDiff.
+type resourceDataGetter interface {
+	Get(key string) interface{}
+}
+
+// vmConfigDrive returns the config drive holding the VM's cloud-init
+// documents.
+func vmConfigDrive(c client.XOClient, d *schema.ResourceData, vmId string, ipPoolAddresses map[int]string) (configDrive, error) {
+	cloudConfig, err := vmCloudConfig(c, d)
+	if err != nil {
+		return configDrive{}, err
+	}
+	cloudNetworkConfig := substituteIpPoolAddresses(d.Get("cloud_network_config").(string), ipPoolAddresses)
+	vendorData := d.Get("cloud_vendor_data").(string)
+
+	drive := configDrive{
+		Datasource:    d.Get("cloud_init_datasource").(string),
+		InstanceId:    configDriveInstanceId(vmId, cloudConfig, vendorData, cloudNetworkConfig),
+		UserData:      cloudConfig,
+		VendorData:    vendorData,
+		NetworkConfig: cloudNetworkConfig,
+	}
+	if metaData := d.Get("cloud_meta_data").([]interface{}); len(metaData) > 0 && metaData[0] != nil {
+		metaDataMap := metaData[0].(map[string]interface{})
+		if instanceId := metaDataMap["instance_id"].(string); instanceId != "" {
+			drive.InstanceId = instanceId
+		}
+		drive.LocalHostname = metaDataMap["local_hostname"].(string)
+	}
+	return drive, nil
+}
+
+// vmCloudConfig returns the user-data of the VM, rendering the stored cloud
+// config when `cloud_config_id` is used.
+func vmCloudConfig(c client.XOClient, d *schema.ResourceData) (string, error) {
+	if cloudConfigId := d.Get("cloud_config_id").(string); cloudConfigId != "" {
+		return getRenderedCloudConfig(c, cloudConfigId, d.Get("name_label").(string), d.Get("cloud_config_index").(int))
+	}
+	return d.Get("cloud_config").(string), nil
+}
+
+// vmIpPoolAddresses returns the IP pool addresses allocated to the VM's
+// network blocks, keyed by their index.
+func vmIpPoolAddresses(d *schema.ResourceData) map[int]string {
+	addresses := map[int]string{}
+	for index, allocation := range vifIpPoolAllocations(d) {
+		addresses[index] = allocation["ip_pool_address"].(string)
+	}
+	return addresses
+}
+
+// replaceConfigDrive uploads a new config drive holding the given documents,
+// detaches and destroys the VM's current config drives and attaches the new
+// one. The new drive is created on the SR of the current one, or of the
+// VM's first disk.
+func replaceConfigDrive(ctx context.Context, c client.XOClient, vm *client.Vm, drive configDrive) error {
+	disks, err := c.GetDisks(vm)
+	if err != nil {
+		return err
+	}
+
+	var srId string
+	var oldDrives []client.Disk
+	for _, disk := range disks {
+		if disk.NameLabel == defaultCloudConfigDiskName {
+			oldDrives = append(oldDrives, disk)
+			srId = disk.SrId
+		} else if srId == "" && !disk.IsCdDrive {
+			srId = disk.SrId
+		}
+	}
+	if srId == "" {
+		return fmt.Errorf("failed to find an SR for the config drive of VM %s, it has no disk", vm.Id)
+	}
+
+	image, err := drive.image()
+	if err != nil {
+		return err
+	}
+
+	var result json.RawMessage
+	params := map[string]interface{}{
+		"sr":          srId,
+		"type":        "iso",
+		"name":        defaultCloudConfigDiskName,
+		"description": fmt.Sprintf("cloud-init config drive (instance-id %s)", drive.InstanceId),
+	}
+	if err := callXoApi(c, "disk.import", params, &result); err != nil {
+		return fmt.Errorf("failed to create the config drive of VM %s: %w", vm.Id, err)
+	}
+	var sendTo struct {
+		SendTo string `json:"$sendTo"`
+	}
+	if err := json.Unmarshal(result, &sendTo); err != nil || sendTo.SendTo == "" {
+		return fmt.Errorf("unexpected disk.import response: %s", result)
+	}
+
+	tflog.Debug(ctx, "Uploading config drive", map[string]interface{}{
+		"vm":          vm.Id,
+		"sr":          srId,
+		"instance_id": drive.InstanceId,
+	})
+	body, err := xoHttpUploadReader(ctx, c, sendTo.SendTo, bytes.NewReader(image), int64(len(image)))
+	if err != nil {
+		return fmt.Errorf("failed to upload the config drive of VM %s: %w", vm.Id, err)
+	}
+	vdiId, err := vmImportResultId(body)
+	if err != nil {
+		return err
+	}
+	if vdiId == "" {
+		return fmt.Errorf("XO did not return the id of the config drive of VM %s", vm.Id)
+	}
+
+	var success bool
+	for _, oldDrive := range oldDrives {
+		if oldDrive.Attached {
+			if err := callXoApi(c, "vbd.disconnect", map[string]interface{}{"id": oldDrive.VBD.Id}, &success); err != nil {
+				return fmt.Errorf("failed to detach the previous config drive of VM %s: %w", vm.Id, err)
+			}
+		}
+		if err := callXoApi(c, "vdi.delete", map[string]interface{}{"id": oldDrive.VDIId}, &success); err != nil {
+			return fmt.Errorf("failed to delete the previous config drive of VM %s: %w", vm.Id, err)
+		}
+	}
+
+	params = map[string]interface{}{
+		"vm":       vm.Id,
+		"vdi":      vdiId,
+		"bootable": false,
+		"mode":     "RW",
+	}
+	if err := callXoApi(c, "vm.attachDisk", params, &success); err != nil {
+		return fmt.Errorf("failed to attach the config drive %s to VM %s: %w", vdiId, vm.Id, err)
+	}
+	return nil
+}
+
+// startVmWithConfigDrive attaches the provider's config drive to a VM
+// created without one, then brings it to its configured power state and
+// waits for the expected IP addresses like XO does on creation.
+func startVmWithConfigDrive(ctx context.Context, c client.XOClient, d *schema.ResourceData, vm *client.Vm, ipPoolAddresses map[int]string, waitForIps map[string]string) (*client.Vm, error) {
+	drive, err := vmConfigDrive(c, d, vm.Id, ipPoolAddresses)
+	if err != nil {
+		return nil, err
+	}
+
+	vm, err = c.GetVm(client.Vm{Id: vm.Id})
+	if err != nil {
+		return nil, err
+	}
+	if vm.PowerState != client.HaltedPowerState {
+		if err := c.HaltVm(vm.Id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := replaceConfigDrive(ctx, c, vm, drive); err != nil {
+		return nil, err
+	}
+
+	switch d.Get("power_state").(string) {
+	case client.HaltedPowerState:
+		return c.GetVm(client.Vm{Id: vm.Id})
+	case client.PausedPowerState:
+		if err := c.StartVm(vm.Id); err != nil {
+			return nil, err
+		}
+		err = c.PauseVm(vm.Id)
+	case client.SuspendedPowerState:
+		if err := c.StartVm(vm.Id); err != nil {
+			return nil, err
+		}
+		err = c.SuspendVm(vm.Id)
+	default:
+		if err := c.StartVm(vm.Id); err != nil {
+			return nil, err
+		}
+		err = waitForVmIps(ctx, c, vm.Id, waitForIps, d.Timeout(schema.TimeoutCreate))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.GetVm(client.Vm{Id: vm.Id})
+}
+
+// waitForVmIps waits until the VM reports an address within the expected
+// CIDR for each of the given network indexes.
+func waitForVmIps(ctx context.Context, c client.XOClient, vmId string, waitForIps map[string]string, timeout time.Duration) error {
+	if len(waitForIps) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		vm, err := c.GetVm(client.Vm{Id: vmId})
+		if err != nil {
+			return err
+		}
+		if vmHasExpectedIps(vm.Addresses, waitForIps) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for VM %s to report the expected IP addresses %v, found %v", vmId, waitForIps, vm.Addresses)
+		case <-ticker.C:
+		}
+	}
+}
+
+// vmHasExpectedIps reports whether addresses, keyed like XO's
+// `<device>/ipv4/<index>`, hold an address within the expected CIDR of each
+// network index.
+func vmHasExpectedIps(addresses map[string]string, waitForIps map[string]string) bool {
+	for device, cidr := range waitForIps {
+		_, expected, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return false
+		}
+		found := false
+		for key, address := range addresses {
+			if !strings.HasPrefix(key, device+"/") {
+				continue
+			}
+			if ip := net.ParseIP(address); ip != nil && expected.Contains(ip) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,135 @@
+package xoa
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_configDriveInstanceId(t *testing.T) {
+	vmId := "4f3a2a6c-0d4e-4c9f-a0c2-3b1c2d9a6e11"
+	instanceId := configDriveInstanceId(vmId, "#cloud-config\nhostname: web\n", "", "")
+
+	if !strings.HasPrefix(instanceId, vmId+"-") {
+		t.Errorf("expected the instance-id to start with the vm id, received %s", instanceId)
+	}
+	if other := configDriveInstanceId(vmId, "#cloud-config\nhostname: web\n", "", ""); other != instanceId {
+		t.Errorf("expected the instance-id to be stable, received %s and %s", instanceId, other)
+	}
+	for _, documents := range [][]string{
+		{"#cloud-config\nhostname: db\n", "", ""},
+		{"#cloud-config\nhostname: web\n", "#cloud-config\n", ""},
+		{"#cloud-config\nhostname: web\n", "", "version: 2\n"},
+	} {
+		if other := configDriveInstanceId(vmId, documents...); other == instanceId {
+			t.Errorf("expected the instance-id to change with the documents %v", documents)
+		}
+	}
+}
+
+func Test_configDriveFiles(t *testing.T) {
+	tests := []struct {
+		drive configDrive
+		label string
+		files map[string]string
+	}{
+		{
+			drive: configDrive{Datasource: noCloudDatasource, InstanceId: "vm-1", UserData: "#cloud-config\n"},
+			label: "cidata",
+			files: map[string]string{
+				"meta-data": "instance-id: vm-1\n",
+				"user-data": "#cloud-config\n",
+			},
+		},
+		{
+			drive: configDrive{
+				Datasource:    noCloudDatasource,
+				InstanceId:    "vm-1",
+				LocalHostname: "web",
+				UserData:      "#cloud-config\n",
+				VendorData:    "#cloud-config\npackages: [curl]\n",
+				NetworkConfig: "version: 2\n",
+			},
+			label: "cidata",
+			files: map[string]string{
+				"meta-data":      "instance-id: vm-1\nlocal-hostname: web\n",
+				"user-data":      "#cloud-config\n",
+				"vendor-data":    "#cloud-config\npackages: [curl]\n",
+				"network-config": "version: 2\n",
+			},
+		},
+		{
+			drive: configDrive{
+				Datasource:    configDriveDatasource,
+				InstanceId:    "vm-1",
+				LocalHostname: "web",
+				UserData:      "#cloud-config\n",
+				VendorData:    "#cloud-config\n",
+				NetworkConfig: `{"links": []}`,
+			},
+			label: "config-2",
+			files: map[string]string{
+				"openstack/latest/meta_data.json":    `{"hostname":"web","name":"web","uuid":"vm-1"}`,
+				"openstack/latest/user_data":         "#cloud-config\n",
+				"openstack/latest/vendor_data.json":  `{"cloud-init":"#cloud-config\n"}`,
+				"openstack/latest/network_data.json": `{"links": []}`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		image, err := test.drive.image()
+		if err != nil {
+			t.Fatalf("failed to build the config drive image: %v", err)
+		}
+		label, imageFiles := readFatImage(t, image)
+		if label != test.label {
+			t.Errorf("expected the drive to be labelled %s, received %q", test.label, label)
+		}
+
+		files := map[string]string{}
+		for name, content := range imageFiles {
+			files[name] = string(content)
+		}
+		// Compare the JSON documents independently of their formatting
+		for name, content := range test.files {
+			if !strings.HasSuffix(name, ".json") {
+				continue
+			}
+			var expected, received interface{}
+			if json.Unmarshal([]byte(content), &expected) == nil && json.Unmarshal([]byte(files[name]), &received) == nil && reflect.DeepEqual(expected, received) {
+				files[name] = content
+			}
+		}
+		if !reflect.DeepEqual(files, test.files) {
+			t.Errorf("expected the files %v, received %v", test.files, files)
+		}
+	}
+}
+
+func Test_vmHasExpectedIps(t *testing.T) {
+	addresses := map[string]string{
+		"0/ipv4/0": "10.0.0.12",
+		"0/ipv6/0": "fe80::1",
+		"1/ipv4/0": "192.168.1.5",
+	}
+
+	tests := []struct {
+		waitForIps map[string]string
+		expected   bool
+	}{
+		{waitForIps: map[string]string{}, expected: true},
+		{waitForIps: map[string]string{"0": "10.0.0.0/24"}, expected: true},
+		{waitForIps: map[string]string{"0": "10.0.0.0/24", "1": "192.168.1.0/24"}, expected: true},
+		{waitForIps: map[string]string{"0": "192.168.1.0/24"}, expected: false},
+		{waitForIps: map[string]string{"2": "0.0.0.0/0"}, expected: false},
+		{waitForIps: map[string]string{"0": "not a cidr"}, expected: false},
+	}
+
+	for _, test := range tests {
+		if result := vmHasExpectedIps(addresses, test.waitForIps); result != test.expected {
+			t.Errorf("expected %t for %v, received %t", test.expected, test.waitForIps, result)
+		}
+	}
+}
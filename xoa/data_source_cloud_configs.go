@@ -0,0 +1,106 @@
+package xoa
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vatesfr/terraform-provider-xenorchestra/xoa/internal"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func dataSourceXoaCloudConfigs() *schema.Resource {
+	return &schema.Resource{
+		Description: "Use this data source to list the stored cloud configs or cloud network configs, optionally filtered by name.",
+		ReadContext: dataSourceCloudConfigsReadContext,
+		Schema: map[string]*schema.Schema{
+			"name_regex": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "A regular expression the names of the cloud configs must match.",
+			},
+			"network_configs": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether to list the cloud network configs (see `xenorchestra_cloud_network_config`) instead of the cloud configs.",
+			},
+			"cloud_configs": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The matching cloud configs sorted by name.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"template": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceCloudConfigsReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	var cloudConfigs []client.CloudConfig
+	var err error
+	if d.Get("network_configs").(bool) {
+		cloudConfigs, err = getCloudNetworkConfigs(c)
+	} else {
+		cloudConfigs, err = c.GetAllCloudConfigs()
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var nameRegex *regexp.Regexp
+	if v := d.Get("name_regex").(string); v != "" {
+		nameRegex = regexp.MustCompile(v)
+	}
+
+	if err := d.Set("cloud_configs", cloudConfigsToMapList(cloudConfigs, nameRegex)); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(internal.Strings([]string{
+		d.Get("name_regex").(string),
+		fmt.Sprintf("%t", d.Get("network_configs").(bool)),
+	}))
+	return nil
+}
+
+func cloudConfigsToMapList(cloudConfigs []client.CloudConfig, nameRegex *regexp.Regexp) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(cloudConfigs))
+	for _, cloudConfig := range cloudConfigs {
+		if nameRegex != nil && !nameRegex.MatchString(cloudConfig.Name) {
+			continue
+		}
+		result = append(result, map[string]interface{}{
+			"id":       cloudConfig.Id,
+			"name":     cloudConfig.Name,
+			"template": cloudConfig.Template,
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i]["name"] == result[j]["name"] {
+			return result[i]["id"].(string) < result[j]["id"].(string)
+		}
+		return result[i]["name"].(string) < result[j]["name"].(string)
+	})
+	return result
+}
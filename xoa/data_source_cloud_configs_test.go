@@ -0,0 +1,89 @@
+package xoa
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func TestAccXenorchestraDataSource_cloudConfigs(t *testing.T) {
+	resourceName := "data.xenorchestra_cloud_configs.configs"
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckXenorchestraCloudConfigDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraDataSourceCloudConfigsConfig(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "cloud_configs.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "cloud_configs.0.name", fmt.Sprintf("%scloud-configs-a", accTestPrefix)),
+					resource.TestCheckResourceAttr(resourceName, "cloud_configs.1.name", fmt.Sprintf("%scloud-configs-b", accTestPrefix)),
+					resource.TestCheckResourceAttrPair(resourceName, "cloud_configs.0.id", "xenorchestra_cloud_config.a", "id")),
+			},
+		},
+	})
+}
+
+func testAccXenorchestraDataSourceCloudConfigsConfig() string {
+	return fmt.Sprintf(`
+resource "xenorchestra_cloud_config" "b" {
+    name = "%[1]scloud-configs-b"
+    template = "template b"
+}
+
+resource "xenorchestra_cloud_config" "a" {
+    name = "%[1]scloud-configs-a"
+    template = "template a"
+}
+
+data "xenorchestra_cloud_configs" "configs" {
+    name_regex = "^%[1]scloud-configs-"
+    depends_on = [xenorchestra_cloud_config.a, xenorchestra_cloud_config.b]
+}
+`, accTestPrefix)
+}
+
+func Test_cloudConfigsToMapList(t *testing.T) {
+	cloudConfigs := []client.CloudConfig{
+		{Id: "3", Name: "web", Template: "c"},
+		{Id: "1", Name: "db", Template: "a"},
+		{Id: "2", Name: "web", Template: "b"},
+	}
+
+	tests := []struct {
+		nameRegex *regexp.Regexp
+		expected  []map[string]interface{}
+	}{
+		{
+			nameRegex: nil,
+			expected: []map[string]interface{}{
+				{"id": "1", "name": "db", "template": "a"},
+				{"id": "2", "name": "web", "template": "b"},
+				{"id": "3", "name": "web", "template": "c"},
+			},
+		},
+		{
+			nameRegex: regexp.MustCompile("^we"),
+			expected: []map[string]interface{}{
+				{"id": "2", "name": "web", "template": "b"},
+				{"id": "3", "name": "web", "template": "c"},
+			},
+		},
+		{
+			nameRegex: regexp.MustCompile("missing"),
+			expected:  []map[string]interface{}{},
+		},
+	}
+
+	for _, test := range tests {
+		result := cloudConfigsToMapList(cloudConfigs, test.nameRegex)
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("expected %v for regex %v, received %v", test.expected, test.nameRegex, result)
+		}
+	}
+}
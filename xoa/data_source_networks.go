@@ -0,0 +1,163 @@
+package xoa
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vatesfr/terraform-provider-xenorchestra/xoa/internal"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func dataSourceXoaNetworks() *schema.Resource {
+	return &schema.Resource{
+		Description: "Use this data source to filter Xenorchestra networks by certain criteria (pool_id, tags, name_regex) for use in other resources. Unlike `xenorchestra_network` it does not fail when several networks match.",
+		ReadContext: dataSourceNetworksReadContext,
+		Schema: map[string]*schema.Schema{
+			"networks": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        dataSourceNetworksElem(),
+				Description: "The resulting networks after applying the argument filtering.",
+			},
+			"pool_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The pool id used to filter the resulting networks by.",
+			},
+			"name_regex": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "A regular expression that the networks' name_label must match.",
+			},
+			"tags": resourceTags(),
+			"sort_by": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"id", "name_label"}, false),
+				Description:  "The network field to sort the results by (id and name_label are supported). Defaults to `id`.",
+			},
+			"sort_order": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"asc", "desc"}, false),
+				Default:      "asc",
+				Description:  "Valid options are `asc` or `desc` and sort order is applied to `sort_by` argument.",
+			},
+		},
+	}
+}
+
+func dataSourceNetworksElem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"name_label": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"name_description": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"bridge": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"pool_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"mtu": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"automatic": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"pifs": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func dataSourceNetworksReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+	poolId := d.Get("pool_id").(string)
+	nameRegex := d.Get("name_regex").(string)
+	tags := tagsFromInterfaceSlice(d.Get("tags").(*schema.Set).List())
+	sortBy := d.Get("sort_by").(string)
+	sortOrder := d.Get("sort_order").(string)
+
+	filter := map[string]interface{}{"type": "network"}
+	if poolId != "" {
+		filter["$poolId"] = poolId
+	}
+	networkMap := map[string]client.Network{}
+	if err := getXoObjects(c, filter, &networkMap); err != nil {
+		return diag.FromErr(err)
+	}
+
+	taggedIds, err := getObjectIdsWithTags(c, tags)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var re *regexp.Regexp
+	if nameRegex != "" {
+		re = regexp.MustCompile(nameRegex)
+	}
+
+	networks := make([]client.Network, 0, len(networkMap))
+	for _, net := range networkMap {
+		if re != nil && !re.MatchString(net.NameLabel) {
+			continue
+		}
+		if taggedIds != nil && !taggedIds[net.Id] {
+			continue
+		}
+		networks = append(networks, net)
+	}
+	networks = internal.SortNetworks(networks, sortBy, sortOrder)
+
+	tflog.Debug(ctx, "Found networks", map[string]interface{}{
+		"count": len(networks),
+	})
+
+	if err := d.Set("networks", networksToMapList(networks)); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(internal.Strings([]string{poolId, nameRegex, strings.Join(tags, ","), sortBy, sortOrder}))
+	return nil
+}
+
+func networksToMapList(networks []client.Network) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(networks))
+	for _, net := range networks {
+		result = append(result, map[string]interface{}{
+			"id":               net.Id,
+			"name_label":       net.NameLabel,
+			"name_description": net.NameDescription,
+			"bridge":           net.Bridge,
+			"pool_id":          net.PoolId,
+			"mtu":              net.MTU,
+			"automatic":        net.Automatic,
+			"pifs":             net.PIFs,
+		})
+	}
+	return result
+}
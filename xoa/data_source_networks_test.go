@@ -0,0 +1,67 @@
+package xoa
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccXenorchestraDataSource_networks(t *testing.T) {
+	resourceName := "data.xenorchestra_networks.networks"
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraDataSourceNetworksConfig(accDefaultNetwork.NameLabel),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, "id"),
+					resource.TestCheckResourceAttr(resourceName, "networks.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "networks.0.id", accDefaultNetwork.Id),
+					resource.TestCheckResourceAttr(resourceName, "networks.0.name_label", accDefaultNetwork.NameLabel),
+					resource.TestCheckResourceAttr(resourceName, "networks.0.pool_id", accTestPool.Id),
+					resource.TestCheckResourceAttrSet(resourceName, "networks.0.bridge"),
+				),
+			},
+		},
+	},
+	)
+}
+
+func TestAccXenorchestraDataSource_networksSorted(t *testing.T) {
+	resourceName := "data.xenorchestra_networks.networks"
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraDataSourceNetworksSortedConfig(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, "networks.#"),
+					resource.TestCheckResourceAttrSet(resourceName, "networks.0.id"),
+				),
+			},
+		},
+	},
+	)
+}
+
+func testAccXenorchestraDataSourceNetworksConfig(nameLabel string) string {
+	return fmt.Sprintf(`
+data "xenorchestra_networks" "networks" {
+    pool_id = "%s"
+    name_regex = "^%s$"
+}
+`, accTestPool.Id, hclRegexpQuote(nameLabel))
+}
+
+func testAccXenorchestraDataSourceNetworksSortedConfig() string {
+	return fmt.Sprintf(`
+data "xenorchestra_networks" "networks" {
+    pool_id = "%s"
+    sort_by = "name_label"
+    sort_order = "desc"
+}
+`, accTestPool.Id)
+}
@@ -0,0 +1,217 @@
+package xoa
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vatesfr/terraform-provider-xenorchestra/xoa/internal"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func dataSourceXoaPIFs() *schema.Resource {
+	return &schema.Resource{
+		Description: "Use this data source to filter the physical network interfaces (PIFs) of Xenorchestra hosts by certain criteria (pool_id, host_id, device, vlan, network_id) for use in other resources. Unlike `xenorchestra_pif` it does not fail when several PIFs match.",
+		ReadContext: dataSourcePIFsReadContext,
+		Schema: map[string]*schema.Schema{
+			"pifs": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        dataSourcePIFsElem(),
+				Description: "The resulting PIFs after applying the argument filtering.",
+			},
+			"pool_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The pool id used to filter the resulting PIFs by.",
+			},
+			"host_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The host id used to filter the resulting PIFs by.",
+			},
+			"network_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The network id used to filter the resulting PIFs by.",
+			},
+			"device": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The name of the network device (eth0, eth1, etc) used to filter the resulting PIFs by.",
+			},
+			"vlan": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Description: "The VLAN used to filter the resulting PIFs by. PIFs that are not on a VLAN have a vlan of -1.",
+			},
+			"management": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "When set, only the PIFs whose management status matches are returned.",
+			},
+			"sort_by": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"id", "device"}, false),
+				Description:  "The PIF field to sort the results by (id and device are supported). Defaults to `id`.",
+			},
+			"sort_order": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"asc", "desc"}, false),
+				Default:      "asc",
+				Description:  "Valid options are `asc` or `desc` and sort order is applied to `sort_by` argument.",
+			},
+		},
+	}
+}
+
+func dataSourcePIFsElem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"uuid": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"device": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"host": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"network": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"pool_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"vlan": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"attached": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"management": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"is_bond_master": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"is_bond_slave": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"bond_master": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"bond_slaves": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func dataSourcePIFsReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+	poolId := d.Get("pool_id").(string)
+	hostId := d.Get("host_id").(string)
+	networkId := d.Get("network_id").(string)
+	device := d.Get("device").(string)
+	sortBy := d.Get("sort_by").(string)
+	sortOrder := d.Get("sort_order").(string)
+
+	filter := map[string]interface{}{"type": "PIF"}
+	if poolId != "" {
+		filter["$poolId"] = poolId
+	}
+	if hostId != "" {
+		filter["$host"] = hostId
+	}
+	if networkId != "" {
+		filter["$network"] = networkId
+	}
+	if device != "" {
+		filter["device"] = device
+	}
+	vlan, filterVlan := d.GetOkExists("vlan")
+	if filterVlan {
+		filter["vlan"] = vlan.(int)
+	}
+	management, filterManagement := d.GetOkExists("management")
+	if filterManagement {
+		filter["management"] = management.(bool)
+	}
+
+	pifMap := map[string]client.PIF{}
+	if err := getXoObjects(c, filter, &pifMap); err != nil {
+		return diag.FromErr(err)
+	}
+
+	pifs := make([]client.PIF, 0, len(pifMap))
+	for _, pif := range pifMap {
+		pifs = append(pifs, pif)
+	}
+	pifs = internal.SortPIFs(pifs, sortBy, sortOrder)
+
+	tflog.Debug(ctx, "Found PIFs", map[string]interface{}{
+		"count": len(pifs),
+	})
+
+	if err := d.Set("pifs", pifsToMapList(pifs)); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(internal.Strings([]string{
+		poolId,
+		hostId,
+		networkId,
+		device,
+		fmt.Sprintf("%v-%v", filterVlan, vlan),
+		fmt.Sprintf("%v-%v", filterManagement, management),
+		sortBy,
+		sortOrder,
+	}))
+	return nil
+}
+
+func pifsToMapList(pifs []client.PIF) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(pifs))
+	for _, pif := range pifs {
+		result = append(result, map[string]interface{}{
+			"id":             pif.Id,
+			"uuid":           pif.Uuid,
+			"device":         pif.Device,
+			"host":           pif.Host,
+			"network":        pif.Network,
+			"pool_id":        pif.PoolId,
+			"vlan":           pif.Vlan,
+			"attached":       pif.Attached,
+			"management":     pif.Management,
+			"is_bond_master": pif.IsBondMaster,
+			"is_bond_slave":  pif.IsBondSlave,
+			"bond_master":    pif.BondMaster,
+			"bond_slaves":    pif.BondSlaves,
+		})
+	}
+	return result
+}
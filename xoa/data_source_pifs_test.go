@@ -0,0 +1,69 @@
+package xoa
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccXenorchestraDataSource_pifs(t *testing.T) {
+	resourceName := "data.xenorchestra_pifs.pifs"
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraDataSourcePIFsConfig(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, "id"),
+					resource.TestCheckResourceAttr(resourceName, "pifs.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "pifs.0.id", accTestPIF.Id),
+					resource.TestCheckResourceAttr(resourceName, "pifs.0.device", accTestPIF.Device),
+					resource.TestCheckResourceAttr(resourceName, "pifs.0.host", accTestPIF.Host),
+					resource.TestCheckResourceAttrSet(resourceName, "pifs.0.network"),
+				),
+			},
+		},
+	},
+	)
+}
+
+func TestAccXenorchestraDataSource_pifsForPool(t *testing.T) {
+	resourceName := "data.xenorchestra_pifs.pifs"
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraDataSourcePIFsForPoolConfig(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, "pifs.#"),
+					resource.TestCheckResourceAttr(resourceName, "pifs.0.pool_id", accTestPool.Id),
+					resource.TestCheckResourceAttr(resourceName, "pifs.0.management", "true"),
+				),
+			},
+		},
+	},
+	)
+}
+
+func testAccXenorchestraDataSourcePIFsConfig() string {
+	return fmt.Sprintf(`
+data "xenorchestra_pifs" "pifs" {
+    host_id = "%s"
+    device = "%s"
+    vlan = %d
+}
+`, accTestPIF.Host, accTestPIF.Device, accTestPIF.Vlan)
+}
+
+func testAccXenorchestraDataSourcePIFsForPoolConfig() string {
+	return fmt.Sprintf(`
+data "xenorchestra_pifs" "pifs" {
+    pool_id = "%s"
+    management = true
+    sort_by = "device"
+}
+`, accTestPool.Id)
+}
@@ -133,3 +133,22 @@ func getPoolsWithTags(c client.XOClient, tags []string) ([]client.Pool, error) {
 	}
 	return pools, nil
 }
+
+// getObjectIdsWithTags returns the ids of the objects that have all of the
+// given tags. It returns nil when no tags are given so that the plural data
+// sources can skip tag filtering entirely.
+func getObjectIdsWithTags(c client.XOClient, tags []string) (map[string]bool, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	objs, err := c.GetObjectsWithTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]bool, len(objs))
+	for _, obj := range objs {
+		ids[obj.Id] = true
+	}
+	return ids, nil
+}
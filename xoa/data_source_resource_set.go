@@ -22,6 +22,50 @@ using the data source.`,
 				Required:    true,
 				Description: "The name of the resource set to look up.",
 			},
+			"subjects": &schema.Schema{
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Description: "The uuids of the user accounts that have access to the resource set.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"objects": &schema.Schema{
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Description: "The uuids of the objects that are within scope of the resource set.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"ip_pools": &schema.Schema{
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Description: "The ids of the IP pools the resource set's VMs can allocate addresses from.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"limit": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The limits applied to the resource set.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"type": &schema.Schema{
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The type of resource set limit: cpus, memory, disk or the id of an IP pool.",
+						},
+						"quantity": &schema.Schema{
+							Type:        schema.TypeInt,
+							Computed:    true,
+							Description: "The numerical limit for the given type.",
+						},
+					},
+				},
+			},
+			"usage": resourceSetUsageSchema(),
 		},
 	}
 }
@@ -41,8 +85,9 @@ func dataSourceResourceSetRead(d *schema.ResourceData, m interface{}) error {
 		return errors.New(fmt.Sprintf("found `%d` resource sets with name `%s`. Resource sets must be uniquely named to use this data source. Rename the conflicting resource set and try again.", l, name))
 	}
 
-	rs := resourceSets[0]
-	d.SetId(rs.Id)
-	d.Set("name", rs.Name)
-	return nil
+	rs, err := getResourceSetObject(c, resourceSets[0].Id)
+	if err != nil {
+		return err
+	}
+	return resourceSetToData(*rs, d)
 }
@@ -75,7 +75,13 @@ func TestAccXenorchestraDataSource_resourceSet(t *testing.T) {
 				Check: resource.ComposeAggregateTestCheckFunc(
 					testAccCheckXenorchestraDataSourceResourceSet(resourceName),
 					resource.TestCheckResourceAttrSet(resourceName, "id"),
-					resource.TestCheckResourceAttr(resourceName, "name", rsName)),
+					resource.TestCheckResourceAttr(resourceName, "name", rsName),
+					resource.TestCheckResourceAttr(resourceName, "objects.#", "0"),
+					resource.TestCheckResourceAttr(resourceName, "subjects.#", "0"),
+					resource.TestCheckResourceAttr(resourceName, "limit.#", "3"),
+					resource.TestCheckResourceAttr(resourceName, "usage.#", "3"),
+					resource.TestCheckResourceAttr(resourceName, "usage.0.type", "cpus"),
+					resource.TestCheckResourceAttrSet(resourceName, "usage.0.available")),
 			},
 		},
 	},
@@ -0,0 +1,214 @@
+package xoa
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vatesfr/terraform-provider-xenorchestra/xoa/internal"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func dataSourceXoaStorageRepositories() *schema.Resource {
+	return &schema.Resource{
+		Description: "Use this data source to filter Xenorchestra storage repositories by certain criteria (pool_id, sr_type, shared, tags, name_regex) for use in other resources. Unlike `xenorchestra_sr` it does not fail when several storage repositories match.",
+		ReadContext: dataSourceStorageRepositoriesReadContext,
+		Schema: map[string]*schema.Schema{
+			"srs": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        dataSourceStorageRepositoriesElem(),
+				Description: "The resulting storage repositories after applying the argument filtering. `size`, `physical_usage` and `usage` are in bytes.",
+			},
+			"pool_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The pool id used to filter the resulting storage repositories by.",
+			},
+			"sr_type": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The type of storage repository (lvm, udev, iso, user, etc) used to filter the results by.",
+			},
+			"shared": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Description: "When set, only the storage repositories whose shared status matches are returned.",
+			},
+			"name_regex": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "A regular expression that the storage repositories' name_label must match.",
+			},
+			"tags": resourceTags(),
+			"sort_by": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"id", "name_label"}, false),
+				Description:  "The storage repository field to sort the results by (id and name_label are supported). Defaults to `id`.",
+			},
+			"sort_order": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"asc", "desc"}, false),
+				Default:      "asc",
+				Description:  "Valid options are `asc` or `desc` and sort order is applied to `sort_by` argument.",
+			},
+		},
+	}
+}
+
+func dataSourceStorageRepositoriesElem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"uuid": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"name_label": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"pool_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"sr_type": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"shared": &schema.Schema{
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"container": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"size": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"physical_usage": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"usage": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"tags": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+// storageRepositoryObject contains the fields of XO's SR objects that
+// client.StorageRepository does not expose.
+type storageRepositoryObject struct {
+	client.StorageRepository
+	Shared bool `json:"shared"`
+}
+
+func dataSourceStorageRepositoriesReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+	poolId := d.Get("pool_id").(string)
+	srType := d.Get("sr_type").(string)
+	nameRegex := d.Get("name_regex").(string)
+	tags := tagsFromInterfaceSlice(d.Get("tags").(*schema.Set).List())
+	sortBy := d.Get("sort_by").(string)
+	sortOrder := d.Get("sort_order").(string)
+
+	filter := map[string]interface{}{"type": "SR"}
+	if poolId != "" {
+		filter["$poolId"] = poolId
+	}
+	if srType != "" {
+		filter["SR_type"] = srType
+	}
+	shared, filterShared := d.GetOkExists("shared")
+	if filterShared {
+		filter["shared"] = shared.(bool)
+	}
+	srMap := map[string]storageRepositoryObject{}
+	if err := getXoObjects(c, filter, &srMap); err != nil {
+		return diag.FromErr(err)
+	}
+
+	taggedIds, err := getObjectIdsWithTags(c, tags)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var re *regexp.Regexp
+	if nameRegex != "" {
+		re = regexp.MustCompile(nameRegex)
+	}
+
+	srs := make([]client.StorageRepository, 0, len(srMap))
+	sharedSrs := map[string]bool{}
+	for _, sr := range srMap {
+		if re != nil && !re.MatchString(sr.NameLabel) {
+			continue
+		}
+		if taggedIds != nil && !taggedIds[sr.Id] {
+			continue
+		}
+		srs = append(srs, sr.StorageRepository)
+		sharedSrs[sr.Id] = sr.Shared
+	}
+	srs = internal.SortStorageRepositories(srs, sortBy, sortOrder)
+
+	tflog.Debug(ctx, "Found storage repositories", map[string]interface{}{
+		"count": len(srs),
+	})
+
+	if err := d.Set("srs", storageRepositoriesToMapList(srs, sharedSrs)); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(internal.Strings([]string{
+		poolId,
+		srType,
+		fmt.Sprintf("%v-%v", filterShared, shared),
+		nameRegex,
+		strings.Join(tags, ","),
+		sortBy,
+		sortOrder,
+	}))
+	return nil
+}
+
+func storageRepositoriesToMapList(srs []client.StorageRepository, shared map[string]bool) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(srs))
+	for _, sr := range srs {
+		result = append(result, map[string]interface{}{
+			"id":             sr.Id,
+			"uuid":           sr.Uuid,
+			"name_label":     sr.NameLabel,
+			"pool_id":        sr.PoolId,
+			"sr_type":        sr.SRType,
+			"shared":         shared[sr.Id],
+			"container":      sr.Container,
+			"size":           sr.Size,
+			"physical_usage": sr.PhysicalUsage,
+			"usage":          sr.Usage,
+			"tags":           sr.Tags,
+		})
+	}
+	return result
+}
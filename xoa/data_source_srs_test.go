@@ -0,0 +1,68 @@
+package xoa
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccXenorchestraDataSource_storageRepositories(t *testing.T) {
+	resourceName := "data.xenorchestra_srs.srs"
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraDataSourceStorageRepositoriesConfig(accDefaultSr.NameLabel),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, "id"),
+					resource.TestCheckResourceAttr(resourceName, "srs.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "srs.0.id", accDefaultSr.Id),
+					resource.TestCheckResourceAttr(resourceName, "srs.0.name_label", accDefaultSr.NameLabel),
+					resource.TestCheckResourceAttr(resourceName, "srs.0.sr_type", accDefaultSr.SRType),
+					resource.TestCheckResourceAttrSet(resourceName, "srs.0.shared"),
+					resource.TestCheckResourceAttrSet(resourceName, "srs.0.size"),
+				),
+			},
+		},
+	},
+	)
+}
+
+func TestAccXenorchestraDataSource_storageRepositoriesByType(t *testing.T) {
+	resourceName := "data.xenorchestra_srs.srs"
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraDataSourceStorageRepositoriesByTypeConfig(accIsoSr.SRType),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, "srs.#"),
+					resource.TestCheckResourceAttr(resourceName, "srs.0.sr_type", accIsoSr.SRType),
+				),
+			},
+		},
+	},
+	)
+}
+
+func testAccXenorchestraDataSourceStorageRepositoriesConfig(nameLabel string) string {
+	return fmt.Sprintf(`
+data "xenorchestra_srs" "srs" {
+    pool_id = "%s"
+    name_regex = "^%s$"
+}
+`, accTestPool.Id, hclRegexpQuote(nameLabel))
+}
+
+func testAccXenorchestraDataSourceStorageRepositoriesByTypeConfig(srType string) string {
+	return fmt.Sprintf(`
+data "xenorchestra_srs" "srs" {
+    pool_id = "%s"
+    sr_type = "%s"
+    sort_by = "name_label"
+}
+`, accTestPool.Id, srType)
+}
@@ -0,0 +1,155 @@
+package xoa
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vatesfr/terraform-provider-xenorchestra/xoa/internal"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func dataSourceXoaTemplates() *schema.Resource {
+	return &schema.Resource{
+		Description: "Use this data source to filter Xenorchestra VM templates by certain criteria (pool_id, boot_firmware, tags, name_regex) for use in other resources. Unlike `xenorchestra_template` it does not fail when several templates match.",
+		ReadContext: dataSourceTemplatesReadContext,
+		Schema: map[string]*schema.Schema{
+			"templates": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        dataSourceTemplatesElem(),
+				Description: "The resulting templates after applying the argument filtering.",
+			},
+			"pool_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The pool id used to filter the resulting templates by.",
+			},
+			"boot_firmware": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"bios", "uefi"}, false),
+				Description:  "The boot firmware (bios or uefi) used to filter the resulting templates by.",
+			},
+			"name_regex": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "A regular expression that the templates' name_label must match.",
+			},
+			"tags": resourceTags(),
+			"sort_by": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"id", "name_label"}, false),
+				Description:  "The template field to sort the results by (id and name_label are supported). Defaults to `id`.",
+			},
+			"sort_order": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"asc", "desc"}, false),
+				Default:      "asc",
+				Description:  "Valid options are `asc` or `desc` and sort order is applied to `sort_by` argument.",
+			},
+		},
+	}
+}
+
+func dataSourceTemplatesElem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"uuid": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"name_label": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"pool_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"boot_firmware": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceTemplatesReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+	poolId := d.Get("pool_id").(string)
+	bootFirmware := d.Get("boot_firmware").(string)
+	nameRegex := d.Get("name_regex").(string)
+	tags := tagsFromInterfaceSlice(d.Get("tags").(*schema.Set).List())
+	sortBy := d.Get("sort_by").(string)
+	sortOrder := d.Get("sort_order").(string)
+
+	filter := map[string]interface{}{"type": "VM-template"}
+	if poolId != "" {
+		filter["$poolId"] = poolId
+	}
+	templateMap := map[string]client.Template{}
+	if err := getXoObjects(c, filter, &templateMap); err != nil {
+		return diag.FromErr(err)
+	}
+
+	taggedIds, err := getObjectIdsWithTags(c, tags)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var re *regexp.Regexp
+	if nameRegex != "" {
+		re = regexp.MustCompile(nameRegex)
+	}
+
+	templates := make([]client.Template, 0, len(templateMap))
+	for _, tmpl := range templateMap {
+		if bootFirmware != "" && tmpl.Boot.Firmware != bootFirmware {
+			continue
+		}
+		if re != nil && !re.MatchString(tmpl.NameLabel) {
+			continue
+		}
+		if taggedIds != nil && !taggedIds[tmpl.Id] {
+			continue
+		}
+		templates = append(templates, tmpl)
+	}
+	templates = internal.SortTemplates(templates, sortBy, sortOrder)
+
+	tflog.Debug(ctx, "Found templates", map[string]interface{}{
+		"count": len(templates),
+	})
+
+	if err := d.Set("templates", templatesToMapList(templates)); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(internal.Strings([]string{poolId, bootFirmware, nameRegex, strings.Join(tags, ","), sortBy, sortOrder}))
+	return nil
+}
+
+func templatesToMapList(templates []client.Template) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(templates))
+	for _, tmpl := range templates {
+		result = append(result, map[string]interface{}{
+			"id":            tmpl.Id,
+			"uuid":          tmpl.Uuid,
+			"name_label":    tmpl.NameLabel,
+			"pool_id":       tmpl.PoolId,
+			"boot_firmware": tmpl.Boot.Firmware,
+		})
+	}
+	return result
+}
@@ -0,0 +1,39 @@
+package xoa
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccXenorchestraDataSource_templates(t *testing.T) {
+	resourceName := "data.xenorchestra_templates.templates"
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraDataSourceTemplatesConfig(testTemplate.NameLabel),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, "id"),
+					resource.TestCheckResourceAttrSet(resourceName, "templates.#"),
+					resource.TestCheckResourceAttr(resourceName, "templates.0.name_label", testTemplate.NameLabel),
+					resource.TestCheckResourceAttr(resourceName, "templates.0.pool_id", accTestPool.Id),
+					resource.TestCheckResourceAttrSet(resourceName, "templates.0.uuid"),
+				),
+			},
+		},
+	},
+	)
+}
+
+func testAccXenorchestraDataSourceTemplatesConfig(nameLabel string) string {
+	return fmt.Sprintf(`
+data "xenorchestra_templates" "templates" {
+    pool_id = "%s"
+    name_regex = "^%s$"
+    sort_by = "id"
+}
+`, accTestPool.Id, hclRegexpQuote(nameLabel))
+}
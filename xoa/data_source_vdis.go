@@ -0,0 +1,172 @@
+package xoa
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vatesfr/terraform-provider-xenorchestra/xoa/internal"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func dataSourceXoaVDIs() *schema.Resource {
+	return &schema.Resource{
+		Description: "Use this data source to filter Xenorchestra VDIs by certain criteria (pool_id, sr_id, tags, name_regex) for use in other resources. Unlike `xenorchestra_vdi` it does not fail when several VDIs match.",
+		ReadContext: dataSourceVDIsReadContext,
+		Schema: map[string]*schema.Schema{
+			"vdis": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        dataSourceVDIsElem(),
+				Description: "The resulting VDIs after applying the argument filtering. `size` is in bytes.",
+			},
+			"pool_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The pool id used to filter the resulting VDIs by.",
+			},
+			"sr_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The storage repository id used to filter the resulting VDIs by.",
+			},
+			"name_regex": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "A regular expression that the VDIs' name_label must match.",
+			},
+			"tags": resourceTags(),
+			"sort_by": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"id", "name_label"}, false),
+				Description:  "The VDI field to sort the results by (id and name_label are supported). Defaults to `id`.",
+			},
+			"sort_order": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"asc", "desc"}, false),
+				Default:      "asc",
+				Description:  "Valid options are `asc` or `desc` and sort order is applied to `sort_by` argument.",
+			},
+		},
+	}
+}
+
+func dataSourceVDIsElem() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"name_label": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"name_description": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"pool_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"sr_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"size": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"parent": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"tags": &schema.Schema{
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func dataSourceVDIsReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+	poolId := d.Get("pool_id").(string)
+	srId := d.Get("sr_id").(string)
+	nameRegex := d.Get("name_regex").(string)
+	tags := tagsFromInterfaceSlice(d.Get("tags").(*schema.Set).List())
+	sortBy := d.Get("sort_by").(string)
+	sortOrder := d.Get("sort_order").(string)
+
+	filter := map[string]interface{}{"type": "VDI"}
+	if poolId != "" {
+		filter["$poolId"] = poolId
+	}
+	if srId != "" {
+		filter["$SR"] = srId
+	}
+	vdiMap := map[string]client.VDI{}
+	if err := getXoObjects(c, filter, &vdiMap); err != nil {
+		return diag.FromErr(err)
+	}
+
+	taggedIds, err := getObjectIdsWithTags(c, tags)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var re *regexp.Regexp
+	if nameRegex != "" {
+		re = regexp.MustCompile(nameRegex)
+	}
+
+	vdis := make([]client.VDI, 0, len(vdiMap))
+	for _, vdi := range vdiMap {
+		if re != nil && !re.MatchString(vdi.NameLabel) {
+			continue
+		}
+		if taggedIds != nil && !taggedIds[vdi.VDIId] {
+			continue
+		}
+		vdis = append(vdis, vdi)
+	}
+	vdis = internal.SortVDIs(vdis, sortBy, sortOrder)
+
+	tflog.Debug(ctx, "Found VDIs", map[string]interface{}{
+		"count": len(vdis),
+	})
+
+	if err := d.Set("vdis", vdisToMapList(vdis)); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(internal.Strings([]string{poolId, srId, nameRegex, strings.Join(tags, ","), sortBy, sortOrder}))
+	return nil
+}
+
+func vdisToMapList(vdis []client.VDI) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(vdis))
+	for _, vdi := range vdis {
+		result = append(result, map[string]interface{}{
+			"id":               vdi.VDIId,
+			"name_label":       vdi.NameLabel,
+			"name_description": vdi.NameDescription,
+			"pool_id":          vdi.PoolId,
+			"sr_id":            vdi.SrId,
+			"size":             vdi.Size,
+			"parent":           vdi.Parent,
+			"tags":             vdi.Tags,
+		})
+	}
+	return result
+}
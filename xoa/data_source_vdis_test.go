@@ -0,0 +1,38 @@
+package xoa
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccXenorchestraDataSource_vdis(t *testing.T) {
+	resourceName := "data.xenorchestra_vdis.vdis"
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraDataSourceVDIsConfig(testIso.NameLabel),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, "id"),
+					resource.TestCheckResourceAttrSet(resourceName, "vdis.#"),
+					resource.TestCheckResourceAttr(resourceName, "vdis.0.name_label", testIso.NameLabel),
+					resource.TestCheckResourceAttr(resourceName, "vdis.0.sr_id", accIsoSr.Id),
+					resource.TestCheckResourceAttrSet(resourceName, "vdis.0.size"),
+				),
+			},
+		},
+	},
+	)
+}
+
+func testAccXenorchestraDataSourceVDIsConfig(nameLabel string) string {
+	return fmt.Sprintf(`
+data "xenorchestra_vdis" "vdis" {
+    sr_id = "%s"
+    name_regex = "^%s$"
+}
+`, accIsoSr.Id, hclRegexpQuote(nameLabel))
+}
@@ -2,26 +2,32 @@ package xoa
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
 	"strings"
 
-	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/vatesfr/terraform-provider-xenorchestra/xoa/internal"
 	"github.com/vatesfr/xenorchestra-go-sdk/client"
 )
 
+var validTagMatch = []string{"all", "any"}
+
 func dataSourceXoaVms() *schema.Resource {
 
 	return &schema.Resource{
-		Description: "Use this data source to filter Xenorchestra VMs by certain criteria (pool_id, power_state or host) for use in other resources.",
+		Description: "Use this data source to filter Xenorchestra VMs by certain criteria (pool_id, power_state, host, tags, name_regex, etc) for use in other resources.",
 		ReadContext: dataSourceVmsReadContext,
 		Schema: map[string]*schema.Schema{
 			"vms": &schema.Schema{
 				Type:        schema.TypeList,
 				Computed:    true,
 				Elem:        resourceVm(),
-				Description: "A list of information for all vms found in this pool. `memory_min`, `memory_max` and `size` are in bytes. `videoram` is in MiB. `cpu_cap` is in hundredths of vCPU (e.g. 100 = 1 vCPU max, 0 means no cap). `start_delay` is in seconds.",
+				Description: "A list of information for all vms found in this pool. Each vm is populated the same way as the `xenorchestra_vm` resource, with `memory_min` and `memory_max` reflecting the dynamic memory limits. `memory_min`, `memory_max` and `size` are in bytes. `videoram` is in MiB. `cpu_cap` is in hundredths of vCPU (e.g. 100 = 1 vCPU max, 0 means no cap). `start_delay` is in seconds. `guest_memory_usage` and `disk_usage` are in bytes.",
 			},
 			"pool_id": &schema.Schema{
 				Type:        schema.TypeString,
@@ -37,8 +43,198 @@ func dataSourceXoaVms() *schema.Resource {
 				Description: "The power state of the vms. (Running, Halted)",
 				Optional:    true,
 			},
+			"tags": resourceTags(),
+			"tag_match": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "all",
+				ValidateFunc: validation.StringInSlice(validTagMatch, false),
+				Description:  "Whether the vms must have `all` of the given `tags` or `any` of them. Defaults to `all`.",
+			},
+			"name_regex": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "A regular expression that the vms' name_label must match.",
+			},
+			"resource_set": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The ID of the resource set the vms belong to.",
+			},
+			"template": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The ID of the template the vms were created from.",
+			},
+			"other_config": &schema.Schema{
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "The key value pairs that must be present in the vms' other_config.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"xenstore": &schema.Schema{
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "The key value pairs that must be present in the vms' xenstore. Keys are relative to `vm-data/`, like the `xenstore` argument of the `xenorchestra_vm` resource.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"sort_by": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"id", "name_label"}, false),
+				Description:  "The vm field to sort the results by (id and name_label are supported).",
+			},
+			"sort_order": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"asc", "desc"}, false),
+				Default:      "asc",
+				Description:  "Valid options are `asc` or `desc` and sort order is applied to `sort_by` argument.",
+			},
+			"limit": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The maximum number of vms to return once filtered and sorted. Defaults to `0` which returns every matching vm.",
+			},
+		},
+	}
+}
+
+// vmFilter contains the filtering criteria of the vms data source that
+// cannot be passed to client.GetVms.
+type vmFilter struct {
+	tags        []string
+	matchAll    bool
+	nameRegex   *regexp.Regexp
+	resourceSet string
+	template    string
+	otherConfig map[string]interface{}
+	xenstore    map[string]interface{}
+}
+
+// vmObject contains the fields of XO's VM objects that client.Vm does not
+// expose.
+type vmObject struct {
+	vmGuestMetrics
+	Other map[string]string `json:"other"`
+}
+
+func (o *vmObject) otherConfig() map[string]string {
+	if o == nil {
+		return nil
+	}
+	return o.Other
+}
+
+// vmDevices contains the VIFs and disks of a pool's vms keyed by vm id
+// along with the space used by each disk keyed by the disk's position.
+type vmDevices struct {
+	vifs      map[string][]client.VIF
+	disks     map[string][]client.Disk
+	diskUsage map[string]map[string]int
+}
+
+// vmDevice contains the fields needed to sort the objects returned by
+// getVmDevices before decoding them into their SDK type.
+type vmDevice struct {
+	Type      string `json:"type"`
+	Id        string `json:"id"`
+	VifVm     string `json:"$VM"`
+	VbdVm     string `json:"VM"`
+	IsCdDrive bool   `json:"is_cd_drive"`
+	Usage     int    `json:"usage"`
+}
+
+// getVmDevices looks up the VIFs and disks of every vm in the pool with a
+// single xo.getAllObjects call rather than querying each vm separately.
+func getVmDevices(c client.XOClient, poolId string) (*vmDevices, error) {
+	filter := map[string]interface{}{
+		"$poolId": poolId,
+		"type": map[string]interface{}{
+			"__or": []string{"VIF", "VBD", "VDI"},
 		},
 	}
+	objects := map[string]json.RawMessage{}
+	if err := getXoObjects(c, filter, &objects); err != nil {
+		return nil, err
+	}
+
+	devices := &vmDevices{
+		vifs:      map[string][]client.VIF{},
+		disks:     map[string][]client.Disk{},
+		diskUsage: map[string]map[string]int{},
+	}
+	vbds := []client.VBD{}
+	vbdVms := map[string]string{}
+	vdis := map[string]client.VDI{}
+	vdiUsage := map[string]int{}
+	for id, obj := range objects {
+		var device vmDevice
+		if err := json.Unmarshal(obj, &device); err != nil {
+			return nil, fmt.Errorf("failed to decode XO object %s: %v", id, err)
+		}
+
+		switch device.Type {
+		case "VIF":
+			var vif client.VIF
+			if err := json.Unmarshal(obj, &vif); err != nil {
+				return nil, fmt.Errorf("failed to decode VIF %s: %v", id, err)
+			}
+			devices.vifs[device.VifVm] = append(devices.vifs[device.VifVm], vif)
+		case "VBD":
+			if device.IsCdDrive {
+				continue
+			}
+			var vbd client.VBD
+			if err := json.Unmarshal(obj, &vbd); err != nil {
+				return nil, fmt.Errorf("failed to decode VBD %s: %v", id, err)
+			}
+			vbds = append(vbds, vbd)
+			vbdVms[vbd.Id] = device.VbdVm
+		case "VDI":
+			var vdi client.VDI
+			if err := json.Unmarshal(obj, &vdi); err != nil {
+				return nil, fmt.Errorf("failed to decode VDI %s: %v", id, err)
+			}
+			vdis[device.Id] = vdi
+			vdiUsage[device.Id] = device.Usage
+		}
+	}
+
+	// Keep the disks and VIFs in a stable order like the SDK's GetDisks and
+	// GetVIFs do.
+	sort.Slice(vbds, func(i, j int) bool {
+		return vbds[i].Position < vbds[j].Position
+	})
+	for _, vbd := range vbds {
+		vdi, ok := vdis[vbd.VDI]
+		if !ok {
+			continue
+		}
+		vmId := vbdVms[vbd.Id]
+		devices.disks[vmId] = append(devices.disks[vmId], client.Disk{VBD: vbd, VDI: vdi})
+
+		if vdi.NameLabel == defaultCloudConfigDiskName {
+			continue
+		}
+		if devices.diskUsage[vmId] == nil {
+			devices.diskUsage[vmId] = map[string]int{}
+		}
+		devices.diskUsage[vmId][vbd.Position] = vdiUsage[vbd.VDI]
+	}
+	for _, vifs := range devices.vifs {
+		sort.Slice(vifs, func(i, j int) bool {
+			return vifs[i].Device < vifs[j].Device
+		})
+	}
+	return devices, nil
 }
 
 func dataSourceVmsReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -49,57 +245,140 @@ func dataSourceVmsReadContext(ctx context.Context, d *schema.ResourceData, m int
 		PoolId:     d.Get("pool_id").(string),
 	}
 
+	filter := vmFilter{
+		tags:        tagsFromInterfaceSlice(d.Get("tags").(*schema.Set).List()),
+		matchAll:    d.Get("tag_match").(string) == "all",
+		resourceSet: d.Get("resource_set").(string),
+		template:    d.Get("template").(string),
+		otherConfig: d.Get("other_config").(map[string]interface{}),
+		xenstore:    d.Get("xenstore").(map[string]interface{}),
+	}
+	if nameRegex := d.Get("name_regex").(string); nameRegex != "" {
+		filter.nameRegex = regexp.MustCompile(nameRegex)
+	}
+
 	vms, err := c.GetVms(searchVm)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err = d.Set("vms", vmToMapList(ctx, vms)); err != nil {
+	// A single lookup of the pool's VM objects provides both the other_config
+	// used for filtering and the guest metrics
+	vmObjects := map[string]*vmObject{}
+	if err := getXoObjects(c, map[string]interface{}{"type": "VM", "$poolId": searchVm.PoolId}, &vmObjects); err != nil {
+		return diag.FromErr(err)
+	}
+
+	filtered := make([]client.Vm, 0, len(vms))
+	for _, vm := range vms {
+		if filter.matches(vm, vmObjects[vm.Id].otherConfig()) {
+			filtered = append(filtered, vm)
+		}
+	}
+	vms = internal.SortVms(filtered, d.Get("sort_by").(string), d.Get("sort_order").(string))
+
+	if limit := d.Get("limit").(int); limit > 0 && len(vms) > limit {
+		vms = vms[:limit]
+	}
+
+	devices, err := getVmDevices(c, searchVm.PoolId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	vmMaps, err := vmToMapList(ctx, vms, vmObjects, devices)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err = d.Set("vms", vmMaps); err != nil {
 		return diag.FromErr(err)
 	}
-	d.SetId(internal.Strings([]string{searchVm.PowerState, searchVm.PoolId, searchVm.Host}))
+	d.SetId(internal.Strings([]string{
+		searchVm.PowerState,
+		searchVm.PoolId,
+		searchVm.Host,
+		strings.Join(filter.tags, ","),
+		d.Get("tag_match").(string),
+		d.Get("name_regex").(string),
+		filter.resourceSet,
+		filter.template,
+		fmt.Sprintf("%v", filter.otherConfig),
+		fmt.Sprintf("%v", filter.xenstore),
+		d.Get("sort_by").(string),
+		d.Get("sort_order").(string),
+		fmt.Sprintf("%d", d.Get("limit").(int)),
+	}))
 	return nil
 
 }
 
-func vmToMapList(ctx context.Context, vms []client.Vm) []map[string]interface{} {
-	result := make([]map[string]interface{}, 0, len(vms))
-	for _, vm := range vms {
-		tflog.Debug(ctx, "Found IPs", map[string]interface{}{"addresses": vm.Addresses})
-		var ipv4 []string
-		var ipv6 []string
-		for key, address := range vm.Addresses {
-			if strings.Contains(key, "ipv4") {
-				ipv4 = append(ipv4, address)
-			} else if strings.Contains(key, "ipv6") {
-				ipv6 = append(ipv6, address)
+func (f vmFilter) matches(vm client.Vm, otherConfig map[string]string) bool {
+	if f.nameRegex != nil && !f.nameRegex.MatchString(vm.NameLabel) {
+		return false
+	}
+
+	if f.resourceSet != "" && (vm.ResourceSet == nil || vm.ResourceSet.Id != f.resourceSet) {
+		return false
+	}
+
+	if f.template != "" && vm.Template != f.template {
+		return false
+	}
+
+	if len(f.tags) > 0 {
+		found := 0
+		for _, tag := range f.tags {
+			for _, vmTag := range vm.Tags {
+				if tag == vmTag {
+					found++
+					break
+				}
 			}
 		}
-		tflog.Debug(ctx, "VBD info", map[string]interface{}{"vbds": vm.VBDs, "nameLabel": vm.NameLabel, "id": vm.Id})
-		hostMap := map[string]interface{}{
-			"id":                   vm.Id,
-			"name_label":           vm.NameLabel,
-			"cpus":                 vm.CPUs.Number,
-			"cloud_config":         vm.CloudConfig,
-			"cloud_network_config": vm.CloudNetworkConfig,
-			"tags":                 vm.Tags,
-			"memory_max":           vm.Memory.Static[1],
-			"memory_min":           vm.Memory.Static[0],
-			"affinity_host":        vm.AffinityHost,
-			"template":             vm.Template,
-			"high_availability":    vm.HA,
-			"ipv4_addresses":       ipv4,
-			"ipv6_addresses":       ipv6,
-			"power_state":          vm.PowerState,
-			"host":                 vm.Host,
-			"auto_poweron":         vm.AutoPoweron,
-			"name_description":     vm.NameDescription,
+
+		if f.matchAll && found != len(f.tags) {
+			return false
+		}
+		if !f.matchAll && found == 0 {
+			return false
 		}
-		if vm.ResourceSet != nil {
-			hostMap["resource_set"] = vm.ResourceSet.Id
+	}
+
+	for k, v := range f.otherConfig {
+		if value, ok := otherConfig[k]; !ok || value != v.(string) {
+			return false
+		}
+	}
+
+	xenstore := filterXenstoreDataToVmData(vm.XenstoreData)
+	for k, v := range f.xenstore {
+		if value, ok := xenstore[k]; !ok || fmt.Sprintf("%v", value) != v.(string) {
+			return false
+		}
+	}
+	return true
+}
+
+func vmToMapList(ctx context.Context, vms []client.Vm, vmObjects map[string]*vmObject, devices *vmDevices) ([]map[string]interface{}, error) {
+	result := make([]map[string]interface{}, 0, len(vms))
+	for _, vm := range vms {
+		var guestMetrics *vmGuestMetrics
+		if obj, ok := vmObjects[vm.Id]; ok {
+			guestMetrics = &obj.vmGuestMetrics
+			guestMetrics.DiskUsage = devices.diskUsage[vm.Id]
+		}
+
+		vmMap, err := vmToMap(ctx, vm, devices.vifs[vm.Id], devices.disks[vm.Id], guestMetrics, map[string]string{})
+		if err != nil {
+			return nil, err
 		}
-		result = append(result, hostMap)
+		vmMap["id"] = vm.Id
+		vmMap["host"] = vm.Host
+		vmMap["cloud_config"] = vm.CloudConfig
+		vmMap["cloud_network_config"] = vm.CloudNetworkConfig
+		result = append(result, vmMap)
 	}
 
-	return result
+	return result, nil
 }
@@ -1,13 +1,16 @@
 package xoa
 
 import (
+	"context"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 	"log"
+	"reflect"
 	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
 )
 
 func TestAccXenorchestraDataSource_vms(t *testing.T) {
@@ -29,6 +32,120 @@ func TestAccXenorchestraDataSource_vms(t *testing.T) {
 	)
 }
 
+func TestAccXenorchestraDataSource_vmsFilteredAndSorted(t *testing.T) {
+	resourceName := "data.xenorchestra_vms.vms"
+	vmName := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckXenorchestraVmDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraDataSourceVmsFilteredConfig(vmName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckXenorchestraDataSourceVms(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "vms.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "vms.0.name_label", vmName),
+					resource.TestMatchResourceAttr(resourceName, "vms.0.disk.#", regexp.MustCompile("^[1-9][0-9]*$")),
+					resource.TestMatchResourceAttr(resourceName, "vms.0.network.#", regexp.MustCompile("^[1-9][0-9]*$")),
+					resource.TestCheckResourceAttrPair(resourceName, "vms.0.hvm_boot_firmware", "xenorchestra_vm.bar", "hvm_boot_firmware"),
+					resource.TestCheckResourceAttrPair(resourceName, "vms.0.sockets", "xenorchestra_vm.bar", "sockets"),
+					resource.TestCheckResourceAttrPair(resourceName, "vms.0.vga", "xenorchestra_vm.bar", "vga"),
+					resource.TestCheckResourceAttrPair(resourceName, "vms.0.memory_max", "xenorchestra_vm.bar", "memory_max"),
+					resource.TestCheckResourceAttrPair(resourceName, "vms.0.disk.0.name_label", "xenorchestra_vm.bar", "disk.0.name_label"),
+				),
+			},
+		},
+	},
+	)
+}
+
+func Test_vmFilterMatches(t *testing.T) {
+	vm := client.Vm{
+		Id:        "vm-id",
+		NameLabel: "web-1",
+		Host:      "host-id",
+		Template:  "template-id",
+		Tags:      []string{"web", "prod"},
+		ResourceSet: &client.FlatResourceSet{
+			Id: "rs-id",
+		},
+		XenstoreData: map[string]interface{}{
+			"vm-data/role": "frontend",
+		},
+	}
+	otherConfig := map[string]string{
+		"owner": "team-a",
+	}
+
+	tests := []struct {
+		name     string
+		filter   vmFilter
+		expected bool
+	}{
+		{
+			name:     "emptyFilter",
+			filter:   vmFilter{},
+			expected: true,
+		},
+		{
+			name:     "allTagsMatch",
+			filter:   vmFilter{tags: []string{"web", "prod"}, matchAll: true},
+			expected: true,
+		},
+		{
+			name:     "allTagsMissingOne",
+			filter:   vmFilter{tags: []string{"web", "staging"}, matchAll: true},
+			expected: false,
+		},
+		{
+			name:     "anyTagMatch",
+			filter:   vmFilter{tags: []string{"web", "staging"}},
+			expected: true,
+		},
+		{
+			name:     "nameRegex",
+			filter:   vmFilter{nameRegex: regexp.MustCompile(`^db-`)},
+			expected: false,
+		},
+		{
+			name:     "resourceSet",
+			filter:   vmFilter{resourceSet: "rs-id", template: "template-id"},
+			expected: true,
+		},
+		{
+			name:     "otherConfig",
+			filter:   vmFilter{otherConfig: map[string]interface{}{"owner": "team-b"}},
+			expected: false,
+		},
+		{
+			name:     "xenstore",
+			filter:   vmFilter{xenstore: map[string]interface{}{"role": "frontend"}},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := tt.filter.matches(vm, otherConfig); actual != tt.expected {
+				t.Errorf("expected filter %+v to return %t but received %t", tt.filter, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func testAccXenorchestraDataSourceVmsFilteredConfig(vmName string) string {
+	return testAccVmConfig(vmName) + fmt.Sprintf(`
+data "xenorchestra_vms" "vms" {
+    pool_id = "%s"
+    name_regex = "^${xenorchestra_vm.bar.name_label}$"
+    sort_by = "name_label"
+    sort_order = "desc"
+    limit = 1
+}
+`, accTestPool.Id)
+}
+
 func testAccXenorchestraDataSourceVmsConfig() string {
 	return fmt.Sprintf(`
 data "xenorchestra_vms" "vms" {
@@ -51,3 +168,50 @@ func testAccCheckXenorchestraDataSourceVms(n string) resource.TestCheckFunc {
 		return nil
 	}
 }
+
+func Test_vmToMap(t *testing.T) {
+	coresPerSocket := 2
+	vm := client.Vm{
+		Id:             "vm-id",
+		NameLabel:      "web-1",
+		CPUs:           client.CPUs{Number: 4},
+		CoresPerSocket: &coresPerSocket,
+		Memory: client.MemoryObject{
+			Dynamic: []int{1073741824, 2147483648},
+		},
+		XenstoreData: map[string]interface{}{
+			"vm-data/role": "frontend",
+			"other/key":    "ignored",
+		},
+	}
+
+	vmMap, err := vmToMap(context.Background(), vm, []client.VIF{}, []client.Disk{}, nil, map[string]string{})
+	if err != nil {
+		t.Fatalf("expected vmToMap to succeed, received: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"name_label":       "web-1",
+		"cpus":             4,
+		"cores_per_socket": 2,
+		"sockets":          2,
+		"memory_min":       1073741824,
+		"memory_max":       2147483648,
+		"affinity_host":    "",
+		"resource_set":     "",
+	}
+	for k, v := range expected {
+		if !reflect.DeepEqual(vmMap[k], v) {
+			t.Errorf("expected %s to be %v, received %v", k, v, vmMap[k])
+		}
+	}
+
+	if _, ok := vmMap["template"]; ok {
+		t.Errorf("expected template to be omitted when the vm has none")
+	}
+
+	xenstore := vmMap["xenstore"].(map[string]interface{})
+	if len(xenstore) != 1 || xenstore["role"] != "frontend" {
+		t.Errorf("expected xenstore to only contain the vm-data keys, received %v", xenstore)
+	}
+}
@@ -3,8 +3,10 @@ package xoa
 import (
 	"errors"
 	"fmt"
+	"regexp"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
 	"github.com/vatesfr/xenorchestra-go-sdk/client"
 )
 
@@ -14,12 +16,20 @@ func dataSourceXoaTemplate() *schema.Resource {
 		Description: `Provides information about a VM template that can be used for creating new VMs.
 
 **Note:** If there are multiple templates that match terraform will fail.
-Ensure that your name_label and pool_id identify a unique template.`,
+Ensure that your name_label, name_regex, tags and pool_id identify a unique template.`,
 		Schema: map[string]*schema.Schema{
 			"name_label": &schema.Schema{
-				Type:        schema.TypeString,
-				Description: "The name of the template to look up.",
-				Required:    true,
+				Type:         schema.TypeString,
+				Description:  "The name of the template to look up.",
+				Optional:     true,
+				Computed:     true,
+				AtLeastOneOf: []string{"name_label", "name_regex", "tags"},
+			},
+			"name_regex": &schema.Schema{
+				Type:         schema.TypeString,
+				Description:  "A regular expression that the template's name_label must match.",
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
 			},
 			"uuid": &schema.Schema{
 				Type:        schema.TypeString,
@@ -36,16 +46,145 @@ Ensure that your name_label and pool_id identify a unique template.`,
 				Description: "The id of the pool that the template belongs to.",
 				Optional:    true,
 			},
+			"tags": resourceTags(),
+			"template_tags": &schema.Schema{
+				Type:        schema.TypeSet,
+				Description: "All of the template's tags, `tags` only holds the ones used to look it up.",
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"cpus": &schema.Schema{
+				Type:        schema.TypeInt,
+				Description: "The number of CPUs VMs created from the template start with.",
+				Computed:    true,
+			},
+			"cpus_max": &schema.Schema{
+				Type:        schema.TypeInt,
+				Description: "The maximum number of CPUs of the template.",
+				Computed:    true,
+			},
+			"memory_min": &schema.Schema{
+				Type:        schema.TypeInt,
+				Description: "The template's minimum dynamic memory in bytes.",
+				Computed:    true,
+			},
+			"memory_max": &schema.Schema{
+				Type:        schema.TypeInt,
+				Description: "The template's maximum dynamic memory in bytes.",
+				Computed:    true,
+			},
+			"memory_static_max": &schema.Schema{
+				Type:        schema.TypeInt,
+				Description: "The template's maximum static memory in bytes.",
+				Computed:    true,
+			},
+			"secure_boot": &schema.Schema{
+				Type:        schema.TypeBool,
+				Description: "Whether secure boot is enabled on the template.",
+				Computed:    true,
+			},
+			"other_config": &schema.Schema{
+				Type:        schema.TypeMap,
+				Description: "The template's other_config key value pairs.",
+				Computed:    true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"disks": &schema.Schema{
+				Type:        schema.TypeList,
+				Description: "The disks attached to the template. `size` is in bytes. These can be used to size the `disk` blocks of a `xenorchestra_vm`.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"vbd_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"vdi_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name_label": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name_description": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"size": &schema.Schema{
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"sr_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"position": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"attached": &schema.Schema{
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"networks": &schema.Schema{
+				Type:        schema.TypeList,
+				Description: "The network interfaces (VIFs) of the template.",
+				Computed:    true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"device": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"mac_address": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"network_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
 		},
 	}
 }
 
+// templateObject contains the fields of XO's VM-template objects that
+// client.Template does not expose.
+type templateObject struct {
+	client.Template
+	NameDescription string `json:"name_description"`
+	CPUs            struct {
+		Max    int `json:"max"`
+		Number int `json:"number"`
+	} `json:"CPUs"`
+	Memory struct {
+		Dynamic []int `json:"dynamic"`
+		Static  []int `json:"static"`
+	} `json:"memory"`
+	SecureBoot bool              `json:"secureBoot"`
+	Tags       []string          `json:"tags"`
+	Other      map[string]string `json:"other"`
+}
+
 func dataSourceTemplateRead(d *schema.ResourceData, m interface{}) error {
 	c := m.(client.XOClient)
 
 	nameLabel := d.Get("name_label").(string)
+	nameRegex := d.Get("name_regex").(string)
 	poolId := d.Get("pool_id").(string)
 	bootFirmware := d.Get("boot_firmware").(string)
+	tags := tagsFromInterfaceSlice(d.Get("tags").(*schema.Set).List())
 
 	templateReq := client.Template{
 		NameLabel: nameLabel,
@@ -54,22 +193,116 @@ func dataSourceTemplateRead(d *schema.ResourceData, m interface{}) error {
 			Firmware: bootFirmware,
 		},
 	}
-	templates, err := c.GetTemplate(templateReq)
+	templates, err := getTemplates(c, templateReq, nameRegex, tags)
 
 	if err != nil {
 		return err
 	}
 
 	l := len(templates)
+	if l == 0 {
+		return client.NotFound{Query: templateReq}
+	}
 	if l != 1 {
 		return errors.New(fmt.Sprintf("found `%d` templates with query %+v. Templates must be uniquely named to use this data source", l, templateReq))
 	}
 
 	tmpl := templates[0]
 
+	vm := client.Vm{Id: tmpl.Id}
+	disks, err := c.GetDisks(&vm)
+	if err != nil {
+		return err
+	}
+	vifs, err := c.GetVIFs(&vm)
+	if err != nil {
+		return err
+	}
+
 	d.SetId(tmpl.Id)
 	d.Set("uuid", tmpl.Uuid)
 	d.Set("name_label", tmpl.NameLabel)
 	d.Set("boot_firmware", tmpl.Boot.Firmware)
-	return nil
+	d.Set("pool_id", tmpl.PoolId)
+	d.Set("template_tags", tmpl.Tags)
+	d.Set("cpus", tmpl.CPUs.Number)
+	d.Set("cpus_max", tmpl.CPUs.Max)
+	if len(tmpl.Memory.Dynamic) == 2 {
+		d.Set("memory_min", tmpl.Memory.Dynamic[0])
+		d.Set("memory_max", tmpl.Memory.Dynamic[1])
+	}
+	if len(tmpl.Memory.Static) == 2 {
+		d.Set("memory_static_max", tmpl.Memory.Static[1])
+	}
+	d.Set("secure_boot", tmpl.SecureBoot)
+	d.Set("other_config", tmpl.Other)
+	if err := d.Set("disks", disksToMapList(disks)); err != nil {
+		return err
+	}
+	return d.Set("networks", templateVifsToMapList(vifs))
+}
+
+// getTemplates returns the templates matching templateReq whose name_label
+// matches nameRegex (when provided) and that have all of the given tags.
+func getTemplates(c client.XOClient, templateReq client.Template, nameRegex string, tags []string) ([]templateObject, error) {
+	filter := map[string]interface{}{"type": "VM-template"}
+	if templateReq.NameLabel != "" {
+		filter["name_label"] = templateReq.NameLabel
+	}
+	if templateReq.PoolId != "" {
+		filter["$poolId"] = templateReq.PoolId
+	}
+
+	templateMap := map[string]templateObject{}
+	if err := getXoObjects(c, filter, &templateMap); err != nil {
+		return nil, err
+	}
+
+	var re *regexp.Regexp
+	if nameRegex != "" {
+		re = regexp.MustCompile(nameRegex)
+	}
+
+	templates := make([]templateObject, 0, len(templateMap))
+	for _, tmpl := range templateMap {
+		if templateReq.Boot.Firmware != "" && tmpl.Boot.Firmware != templateReq.Boot.Firmware {
+			continue
+		}
+		if re != nil && !re.MatchString(tmpl.NameLabel) {
+			continue
+		}
+		if !hasAllTags(tmpl.Tags, tags) {
+			continue
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates, nil
+}
+
+func hasAllTags(objTags, tags []string) bool {
+	for _, tag := range tags {
+		found := false
+		for _, objTag := range objTags {
+			if tag == objTag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func templateVifsToMapList(vifs []client.VIF) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(vifs))
+	for _, vif := range vifs {
+		result = append(result, map[string]interface{}{
+			"device":      vif.Device,
+			"mac_address": vif.MacAddress,
+			"network_id":  vif.Network,
+		})
+	}
+	return sortNetworkMapByDevice(result)
 }
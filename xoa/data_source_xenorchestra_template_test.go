@@ -21,6 +21,33 @@ func TestAccXenorchestraDataSource_template(t *testing.T) {
 					testAccCheckXenorchestraDataSourceTemplate(resourceName),
 					resource.TestCheckResourceAttrSet(resourceName, "id"),
 					resource.TestCheckResourceAttrSet(resourceName, "uuid"),
+					resource.TestCheckResourceAttrSet(resourceName, "cpus"),
+					resource.TestCheckResourceAttrSet(resourceName, "memory_max"),
+					resource.TestCheckResourceAttrSet(resourceName, "secure_boot"),
+					resource.TestCheckResourceAttr(resourceName, "pool_id", accTestPool.Id),
+					resource.TestCheckResourceAttrSet(resourceName, "disks.#"),
+					resource.TestCheckResourceAttrSet(resourceName, "networks.#"),
+					resource.TestCheckResourceAttrSet(resourceName, "template_tags.#"),
+					resource.TestCheckNoResourceAttr(resourceName, "tags.0"),
+				),
+			},
+		},
+	},
+	)
+}
+
+func TestAccXenorchestraDataSource_templateByNameRegex(t *testing.T) {
+	resourceName := "data.xenorchestra_template.template"
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraDataSourceTemplateNameRegexConfig(accTestPool.Id),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCheckXenorchestraDataSourceTemplate(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "id", testTemplate.Id),
+					resource.TestCheckResourceAttr(resourceName, "name_label", testTemplate.NameLabel),
 				),
 			},
 		},
@@ -70,3 +97,31 @@ data "xenorchestra_template" "template" {
 }
 `, testTemplate.NameLabel, poolId)
 }
+
+func testAccXenorchestraDataSourceTemplateNameRegexConfig(poolId string) string {
+	return fmt.Sprintf(`
+data "xenorchestra_template" "template" {
+    name_regex = "^%s$"
+    pool_id = "%s"
+}
+`, hclRegexpQuote(testTemplate.NameLabel), poolId)
+}
+
+func Test_hasAllTags(t *testing.T) {
+	objTags := []string{"web", "prod"}
+	tests := []struct {
+		tags     []string
+		expected bool
+	}{
+		{tags: nil, expected: true},
+		{tags: []string{"web"}, expected: true},
+		{tags: []string{"web", "prod"}, expected: true},
+		{tags: []string{"web", "staging"}, expected: false},
+	}
+
+	for _, test := range tests {
+		if actual := hasAllTags(objTags, test.tags); actual != test.expected {
+			t.Errorf("expected hasAllTags(%v, %v) to be %t, received %t", objTags, test.tags, test.expected, actual)
+		}
+	}
+}
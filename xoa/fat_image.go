@@ -0,0 +1,381 @@
+package xoa
+
+import (
+	"encoding/binary"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// The config drives are small FAT16 file systems, like the ones XO creates
+// when a VM is created with a cloud config.
+const (
+	fatSectorSize        = 512
+	fatSectorsPerCluster = 4
+	fatClusterSize       = fatSectorSize * fatSectorsPerCluster
+	fatTotalSectors      = 20480 // 10 MiB
+	fatReservedSectors   = 1
+	fatCount             = 2
+	fatRootEntries       = 512
+	fatSectorsPerFat     = 20
+	fatRootSectors       = fatRootEntries * fatDirEntrySize / fatSectorSize
+	fatDataStart         = fatReservedSectors + fatCount*fatSectorsPerFat + fatRootSectors
+	fatClusterCount      = (fatTotalSectors - fatDataStart) / fatSectorsPerCluster
+	fatDirEntrySize      = 32
+	fatEndOfChain        = 0xFFFF
+
+	fatAttrVolumeId  = 0x08
+	fatAttrDirectory = 0x10
+	fatAttrArchive   = 0x20
+	fatAttrLongName  = 0x0F
+
+	// 1980-01-01, the FAT epoch, so that images are reproducible
+	fatDate = 0x0021
+)
+
+type fatNode struct {
+	name      string
+	shortName [11]byte
+	data      []byte
+	dir       bool
+	children  []*fatNode
+	cluster   uint16
+}
+
+// buildFatImage returns a FAT16 file system image with the given volume
+// label holding files, keyed by their slash separated path. Directories are
+// created as needed and long file names are used for names that do not fit
+// the 8.3 format.
+func buildFatImage(label string, files map[string][]byte) ([]byte, error) {
+	if len(label) > 11 {
+		return nil, fmt.Errorf("FAT volume label %q is longer than 11 characters", label)
+	}
+
+	root := &fatNode{dir: true}
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if err := root.add(strings.Split(path.Clean(p), "/"), files[p]); err != nil {
+			return nil, err
+		}
+	}
+
+	image := make([]byte, fatTotalSectors*fatSectorSize)
+	writeFatBootSector(image, label)
+
+	fat := make([]uint16, fatClusterCount+2)
+	fat[0] = 0xFFF8
+	fat[1] = fatEndOfChain
+	next := uint16(2)
+	allocate := func(size int) (uint16, error) {
+		clusters := (size + fatClusterSize - 1) / fatClusterSize
+		if clusters == 0 {
+			return 0, nil
+		}
+		if int(next)+clusters > len(fat) {
+			return 0, fmt.Errorf("config drive content does not fit the %d bytes FAT image", len(image))
+		}
+		first := next
+		for i := 0; i < clusters; i++ {
+			fat[next] = next + 1
+			next++
+		}
+		fat[next-1] = fatEndOfChain
+		return first, nil
+	}
+
+	var write func(dir *fatNode, parent uint16) error
+	write = func(dir *fatNode, parent uint16) error {
+		for _, child := range dir.children {
+			if !child.dir {
+				cluster, err := allocate(len(child.data))
+				if err != nil {
+					return err
+				}
+				child.cluster = cluster
+				copy(image[fatClusterOffset(cluster):], child.data)
+				continue
+			}
+			cluster, err := allocate(child.entryCount() * fatDirEntrySize)
+			if err != nil {
+				return err
+			}
+			child.cluster = cluster
+		}
+
+		var entries []byte
+		if dir == root {
+			entries = append(entries, fatDirEntry(fatPaddedName(label), fatAttrVolumeId, 0, 0)...)
+		} else {
+			entries = append(entries, fatDirEntry(fatPaddedName("."), fatAttrDirectory, dir.cluster, 0)...)
+			entries = append(entries, fatDirEntry(fatPaddedName(".."), fatAttrDirectory, parent, 0)...)
+		}
+		for _, child := range dir.children {
+			entries = append(entries, child.entries()...)
+		}
+
+		if dir == root {
+			if len(entries) > fatRootEntries*fatDirEntrySize {
+				return fmt.Errorf("config drive has too many files in its root directory")
+			}
+			copy(image[(fatReservedSectors+fatCount*fatSectorsPerFat)*fatSectorSize:], entries)
+		} else {
+			copy(image[fatClusterOffset(dir.cluster):], entries)
+		}
+
+		for _, child := range dir.children {
+			if !child.dir {
+				continue
+			}
+			parentCluster := dir.cluster
+			if dir == root {
+				parentCluster = 0
+			}
+			if err := write(child, parentCluster); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := write(root, 0); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < fatCount; i++ {
+		offset := (fatReservedSectors + i*fatSectorsPerFat) * fatSectorSize
+		for cluster, value := range fat {
+			binary.LittleEndian.PutUint16(image[offset+cluster*2:], value)
+		}
+	}
+	return image, nil
+}
+
+func (n *fatNode) add(parts []string, data []byte) error {
+	for _, child := range n.children {
+		if !strings.EqualFold(child.name, parts[0]) {
+			continue
+		}
+		if len(parts) == 1 || !child.dir {
+			return fmt.Errorf("duplicate config drive path %q", strings.Join(parts, "/"))
+		}
+		return child.add(parts[1:], data)
+	}
+
+	child := &fatNode{name: parts[0], dir: len(parts) > 1}
+	if err := child.setShortName(n.children); err != nil {
+		return err
+	}
+	n.children = append(n.children, child)
+	if child.dir {
+		return child.add(parts[1:], data)
+	}
+	child.data = data
+	return nil
+}
+
+// setShortName picks the 8.3 name of the node. Names that are not valid
+// upper case 8.3 names get a `BASIS~N` short name next to their long name.
+func (n *fatNode) setShortName(siblings []*fatNode) error {
+	if n.name == "" || n.name == "." || n.name == ".." || len(n.name) > 255 {
+		return fmt.Errorf("invalid config drive file name %q", n.name)
+	}
+	if !n.needsLongName() {
+		n.shortName = fatShortName(n.name)
+		return nil
+	}
+
+	base, ext := n.name, ""
+	if i := strings.LastIndex(n.name, "."); i > 0 {
+		base, ext = n.name[:i], n.name[i+1:]
+	}
+	base, ext = fatShortNameChars(base), fatShortNameChars(ext)
+	if len(base) > 6 {
+		base = base[:6]
+	}
+	if len(ext) > 3 {
+		ext = ext[:3]
+	}
+
+	for i := 1; i < 10; i++ {
+		candidate := fmt.Sprintf("%s~%d", base, i)
+		if ext != "" {
+			candidate += "." + ext
+		}
+		shortName := fatShortName(candidate)
+		taken := false
+		for _, sibling := range siblings {
+			if sibling.shortName == shortName {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			n.shortName = shortName
+			return nil
+		}
+	}
+	return fmt.Errorf("too many config drive files with names similar to %q", n.name)
+}
+
+func (n *fatNode) needsLongName() bool {
+	base, ext := n.name, ""
+	if i := strings.LastIndex(n.name, "."); i > 0 {
+		base, ext = n.name[:i], n.name[i+1:]
+	}
+	if len(base) > 8 || len(ext) > 3 || strings.Count(n.name, ".") > 1 {
+		return true
+	}
+	return fatShortNameChars(base) != base || fatShortNameChars(ext) != ext
+}
+
+// entryCount returns the number of directory entries of a directory,
+// including the `.` and `..` entries.
+func (n *fatNode) entryCount() int {
+	count := 2
+	for _, child := range n.children {
+		count += len(child.entries()) / fatDirEntrySize
+	}
+	return count
+}
+
+// entries returns the long name entries of the node, if needed, followed by
+// its short name entry.
+func (n *fatNode) entries() []byte {
+	attr := byte(fatAttrArchive)
+	if n.dir {
+		attr = fatAttrDirectory
+	}
+	size := uint32(len(n.data))
+	if n.dir {
+		size = 0
+	}
+	entry := fatDirEntry(n.shortName, attr, n.cluster, size)
+	if !n.needsLongName() {
+		return entry
+	}
+
+	checksum := fatShortNameChecksum(n.shortName)
+	name := utf16.Encode([]rune(n.name))
+	count := (len(name) + 12) / 13
+	var result []byte
+	for seq := count; seq >= 1; seq-- {
+		chars := make([]uint16, 13)
+		for i := range chars {
+			pos := (seq-1)*13 + i
+			switch {
+			case pos < len(name):
+				chars[i] = name[pos]
+			case pos == len(name):
+				chars[i] = 0
+			default:
+				chars[i] = 0xFFFF
+			}
+		}
+
+		lfn := make([]byte, fatDirEntrySize)
+		lfn[0] = byte(seq)
+		if seq == count {
+			lfn[0] |= 0x40
+		}
+		for i := 0; i < 5; i++ {
+			binary.LittleEndian.PutUint16(lfn[1+i*2:], chars[i])
+		}
+		lfn[11] = fatAttrLongName
+		lfn[13] = checksum
+		for i := 0; i < 6; i++ {
+			binary.LittleEndian.PutUint16(lfn[14+i*2:], chars[5+i])
+		}
+		for i := 0; i < 2; i++ {
+			binary.LittleEndian.PutUint16(lfn[28+i*2:], chars[11+i])
+		}
+		result = append(result, lfn...)
+	}
+	return append(result, entry...)
+}
+
+func writeFatBootSector(image []byte, label string) {
+	copy(image[0:], []byte{0xEB, 0x3C, 0x90})
+	copy(image[3:], "MSWIN4.1")
+	binary.LittleEndian.PutUint16(image[11:], fatSectorSize)
+	image[13] = fatSectorsPerCluster
+	binary.LittleEndian.PutUint16(image[14:], fatReservedSectors)
+	image[16] = fatCount
+	binary.LittleEndian.PutUint16(image[17:], fatRootEntries)
+	binary.LittleEndian.PutUint16(image[19:], fatTotalSectors)
+	image[21] = 0xF8
+	binary.LittleEndian.PutUint16(image[22:], fatSectorsPerFat)
+	binary.LittleEndian.PutUint16(image[24:], 32)
+	binary.LittleEndian.PutUint16(image[26:], 64)
+	image[36] = 0x80
+	image[38] = 0x29
+	binary.LittleEndian.PutUint32(image[39:], 0x20200101)
+	volumeLabel := fatPaddedName(label)
+	copy(image[43:], volumeLabel[:])
+	copy(image[54:], "FAT16   ")
+	image[510] = 0x55
+	image[511] = 0xAA
+}
+
+func fatDirEntry(name [11]byte, attr byte, cluster uint16, size uint32) []byte {
+	entry := make([]byte, fatDirEntrySize)
+	copy(entry, name[:])
+	entry[11] = attr
+	binary.LittleEndian.PutUint16(entry[16:], fatDate)
+	binary.LittleEndian.PutUint16(entry[18:], fatDate)
+	binary.LittleEndian.PutUint16(entry[24:], fatDate)
+	binary.LittleEndian.PutUint16(entry[26:], cluster)
+	binary.LittleEndian.PutUint32(entry[28:], size)
+	return entry
+}
+
+func fatClusterOffset(cluster uint16) int {
+	return (fatDataStart + (int(cluster)-2)*fatSectorsPerCluster) * fatSectorSize
+}
+
+// fatShortName returns the space padded directory entry name of a valid 8.3
+// name.
+func fatShortName(name string) [11]byte {
+	base, ext := name, ""
+	if i := strings.LastIndex(name, "."); i > 0 {
+		base, ext = name[:i], name[i+1:]
+	}
+	result := fatPaddedName(base)
+	copy(result[8:], ext)
+	return result
+}
+
+// fatPaddedName returns s padded with spaces, as used for the volume label
+// and the `.` and `..` entries.
+func fatPaddedName(s string) [11]byte {
+	var result [11]byte
+	for i := range result {
+		result[i] = ' '
+	}
+	copy(result[:], s)
+	return result
+}
+
+// fatShortNameChars upper cases s and drops the characters that are not
+// allowed in short names.
+func fatShortNameChars(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || strings.ContainsRune("!#$%&'()-@^_`{}~", r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func fatShortNameChecksum(name [11]byte) byte {
+	var sum byte
+	for _, c := range name {
+		sum = ((sum & 1) << 7) + (sum >> 1) + c
+	}
+	return sum
+}
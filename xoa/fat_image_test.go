@@ -0,0 +1,170 @@
+package xoa
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// readFatImage lists the files of a FAT16 image built by buildFatImage,
+// keyed by their long names.
+func readFatImage(t *testing.T, image []byte) (string, map[string][]byte) {
+	bytesPerSector := int(binary.LittleEndian.Uint16(image[11:]))
+	sectorsPerCluster := int(image[13])
+	reserved := int(binary.LittleEndian.Uint16(image[14:]))
+	fats := int(image[16])
+	rootEntries := int(binary.LittleEndian.Uint16(image[17:]))
+	sectorsPerFat := int(binary.LittleEndian.Uint16(image[22:]))
+	if image[510] != 0x55 || image[511] != 0xAA {
+		t.Fatalf("missing boot sector signature")
+	}
+
+	fatOffset := reserved * bytesPerSector
+	rootOffset := (reserved + fats*sectorsPerFat) * bytesPerSector
+	dataOffset := rootOffset + rootEntries*32
+	clusterSize := sectorsPerCluster * bytesPerSector
+
+	for i := 1; i < fats; i++ {
+		other := fatOffset + i*sectorsPerFat*bytesPerSector
+		if string(image[fatOffset:fatOffset+sectorsPerFat*bytesPerSector]) != string(image[other:other+sectorsPerFat*bytesPerSector]) {
+			t.Fatalf("FAT copies differ")
+		}
+	}
+
+	chain := func(cluster uint16) []byte {
+		var data []byte
+		for cluster >= 2 && cluster < 0xFFF8 {
+			offset := dataOffset + (int(cluster)-2)*clusterSize
+			data = append(data, image[offset:offset+clusterSize]...)
+			cluster = binary.LittleEndian.Uint16(image[fatOffset+int(cluster)*2:])
+		}
+		return data
+	}
+
+	label := ""
+	files := map[string][]byte{}
+	var readDir func(prefix string, entries []byte)
+	readDir = func(prefix string, entries []byte) {
+		var longName []uint16
+		for i := 0; i+32 <= len(entries); i += 32 {
+			entry := entries[i : i+32]
+			if entry[0] == 0 {
+				return
+			}
+			attr := entry[11]
+			if attr == fatAttrLongName {
+				var chars []uint16
+				for _, r := range [][2]int{{1, 5}, {14, 6}, {28, 2}} {
+					for j := 0; j < r[1]; j++ {
+						chars = append(chars, binary.LittleEndian.Uint16(entry[r[0]+j*2:]))
+					}
+				}
+				if entry[13] != fatShortNameChecksum(*(*[11]byte)(entries[i+32*int(entry[0]&0x1F) : i+32*int(entry[0]&0x1F)+11])) {
+					t.Errorf("invalid long name checksum in %q", prefix)
+				}
+				longName = append(chars, longName...)
+				continue
+			}
+
+			name := strings.TrimRight(string(entry[0:8]), " ")
+			if ext := strings.TrimRight(string(entry[8:11]), " "); ext != "" {
+				name += "." + ext
+			}
+			if longName != nil {
+				for j, c := range longName {
+					if c == 0 {
+						longName = longName[:j]
+						break
+					}
+				}
+				name = string(utf16.Decode(longName))
+				longName = nil
+			}
+
+			cluster := binary.LittleEndian.Uint16(entry[26:])
+			switch {
+			case attr == fatAttrVolumeId:
+				label = strings.TrimRight(string(entry[0:11]), " ")
+			case name == "." || name == "..":
+			case attr&fatAttrDirectory != 0:
+				readDir(prefix+name+"/", chain(cluster))
+			default:
+				size := binary.LittleEndian.Uint32(entry[28:])
+				files[prefix+name] = chain(cluster)[:size]
+			}
+		}
+	}
+	readDir("", image[rootOffset:dataOffset])
+	return label, files
+}
+
+func Test_buildFatImage(t *testing.T) {
+	files := map[string][]byte{
+		"meta-data":                       []byte("instance-id: vm\n"),
+		"user-data":                       []byte("#cloud-config\n"),
+		"network-config":                  []byte(strings.Repeat("a", 5000)),
+		"README":                          []byte("plain 8.3 name"),
+		"empty":                           []byte{},
+		"openstack/latest/meta_data.json": []byte(`{"uuid": "vm"}`),
+		"openstack/latest/user_data":      []byte("#cloud-config\n"),
+		"openstack/latest/a-very-long-file-name-that-needs-several-entries.json": []byte("{}"),
+	}
+
+	image, err := buildFatImage("cidata", files)
+	if err != nil {
+		t.Fatalf("failed to build image: %v", err)
+	}
+	if len(image) != fatTotalSectors*fatSectorSize {
+		t.Errorf("expected an image of %d bytes, received %d", fatTotalSectors*fatSectorSize, len(image))
+	}
+	if fsType := string(image[54:62]); fsType != "FAT16   " {
+		t.Errorf("expected a FAT16 file system, received %q", fsType)
+	}
+
+	label, result := readFatImage(t, image)
+	if label != "cidata" {
+		t.Errorf("expected label cidata, received %q", label)
+	}
+	if len(result) != len(files) {
+		t.Errorf("expected %d files, received %d", len(files), len(result))
+	}
+	for name, content := range files {
+		if received, ok := result[name]; !ok || string(received) != string(content) {
+			t.Errorf("expected %s to contain %q, received %q", name, content, received)
+		}
+	}
+}
+
+func Test_buildFatImageErrors(t *testing.T) {
+	tests := []struct {
+		label string
+		files map[string][]byte
+	}{
+		{label: "a-label-too-long", files: map[string][]byte{}},
+		{label: "cidata", files: map[string][]byte{"user-data": make([]byte, fatTotalSectors*fatSectorSize)}},
+		{label: "cidata", files: map[string][]byte{"a/b": {}, "a": {}}},
+	}
+
+	for _, test := range tests {
+		if _, err := buildFatImage(test.label, test.files); err == nil {
+			t.Errorf("expected an error for label %q and files %v", test.label, test.files)
+		}
+	}
+}
+
+func Test_fatShortNames(t *testing.T) {
+	root := &fatNode{dir: true}
+	for _, name := range []string{"README", "user-data", "user-date", "meta_data.json"} {
+		if err := root.add([]string{name}, nil); err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+	}
+
+	expected := []string{"README     ", "USER-D~1   ", "USER-D~2   ", "META_D~1JSO"}
+	for i, child := range root.children {
+		if string(child.shortName[:]) != expected[i] {
+			t.Errorf("expected short name %q for %s, received %q", expected[i], child.name, child.shortName)
+		}
+	}
+}
@@ -0,0 +1,476 @@
+package xoa
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+	"github.com/zclconf/go-cty/cty"
+)
+
+var hclNameInvalidChars = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// importObject contains the fields of the XO objects that the generated
+// config references or imports.
+type importObject struct {
+	Id          string            `json:"id"`
+	Type        string            `json:"type"`
+	NameLabel   string            `json:"name_label"`
+	PoolId      string            `json:"$poolId"`
+	PIFs        []string          `json:"PIFs"`
+	Vlan        int               `json:"vlan"`
+	OtherConfig map[string]string `json:"other_config"`
+}
+
+// importDataSource describes the data source used to reference the XO
+// objects of a given type.
+type importDataSource struct {
+	dataSource string
+	// The objects are looked up by name_label within their pool
+	poolScoped bool
+}
+
+var importDataSources = map[string]importDataSource{
+	"pool":        {dataSource: "xenorchestra_pool"},
+	"host":        {dataSource: "xenorchestra_host"},
+	"SR":          {dataSource: "xenorchestra_sr", poolScoped: true},
+	"network":     {dataSource: "xenorchestra_network", poolScoped: true},
+	"VM-template": {dataSource: "xenorchestra_template", poolScoped: true},
+}
+
+// importGenerator writes the import blocks and resource configuration of
+// existing XO objects. The ids found in the configuration are replaced by
+// references to the generated resources or to data sources.
+type importGenerator struct {
+	ctx      context.Context
+	provider *schema.Provider
+	meta     interface{}
+
+	objects    map[string]importObject
+	nameCounts map[string]int
+	users      map[string]string
+
+	refs        map[string]hcl.Traversal
+	names       map[string]bool
+	dataSources *hclwrite.File
+	resources   *hclwrite.File
+}
+
+// GenerateImportConfig writes the import blocks and configuration of the
+// VMs, networks, resource sets, ACLs and cloud configs of the XO instance
+// configured through the provider's environment variables, such as
+// XOA_URL and XOA_TOKEN.
+func GenerateImportConfig(ctx context.Context, w io.Writer) error {
+	p := Provider()
+	if diags := p.Configure(ctx, terraform.NewResourceConfigRaw(map[string]interface{}{})); diags.HasError() {
+		return diagnosticsError(diags)
+	}
+
+	g := &importGenerator{
+		ctx:         ctx,
+		provider:    p,
+		meta:        p.Meta(),
+		refs:        map[string]hcl.Traversal{},
+		names:       map[string]bool{},
+		dataSources: hclwrite.NewEmptyFile(),
+		resources:   hclwrite.NewEmptyFile(),
+	}
+	if err := g.generate(); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(hclwrite.Format(g.dataSources.Bytes())); err != nil {
+		return err
+	}
+	_, err := w.Write(hclwrite.Format(g.resources.Bytes()))
+	return err
+}
+
+func diagnosticsError(diags diag.Diagnostics) error {
+	for _, d := range diags {
+		if d.Severity == diag.Error {
+			return fmt.Errorf("%s: %s", d.Summary, d.Detail)
+		}
+	}
+	return nil
+}
+
+func (g *importGenerator) generate() error {
+	c := g.meta.(client.XOClient)
+
+	filter := map[string]interface{}{
+		"type": map[string]interface{}{
+			"__or": []string{"pool", "host", "SR", "network", "PIF", "VM-template", "VM"},
+		},
+	}
+	g.objects = map[string]importObject{}
+	if err := getXoObjects(c, filter, &g.objects); err != nil {
+		return err
+	}
+	g.nameCounts = map[string]int{}
+	for _, obj := range g.objects {
+		g.nameCounts[g.nameKey(obj)]++
+	}
+
+	users, err := c.GetAllUsers()
+	if err != nil {
+		return err
+	}
+	g.users = map[string]string{}
+	for _, user := range users {
+		g.users[user.Id] = user.Email
+	}
+
+	resourceSets := []resourceSetObject{}
+	if err := callXoApi(c, "resourceSet.getAll", map[string]interface{}{}, &resourceSets); err != nil {
+		return err
+	}
+	acls := []client.Acl{}
+	if err := callXoApi(c, "acl.get", map[string]interface{}{}, &acls); err != nil {
+		return err
+	}
+	cloudConfigs, err := c.GetAllCloudConfigs()
+	if err != nil {
+		return err
+	}
+
+	type importedResource struct {
+		resourceType string
+		id           string
+		name         string
+	}
+	resources := []importedResource{}
+	add := func(resourceType, id, label string) {
+		name := g.uniqueName("resource."+resourceType, label)
+		g.refs[id] = hcl.Traversal{
+			hcl.TraverseRoot{Name: resourceType},
+			hcl.TraverseAttr{Name: name},
+			hcl.TraverseAttr{Name: "id"},
+		}
+		resources = append(resources, importedResource{resourceType, id, name})
+	}
+
+	for _, obj := range sortedImportObjects(g.objects, "network") {
+		if isManagedNetwork(obj, g.objects) {
+			add("xenorchestra_network", obj.Id, obj.NameLabel)
+		}
+	}
+	for _, obj := range sortedImportObjects(g.objects, "VM") {
+		add("xenorchestra_vm", obj.Id, obj.NameLabel)
+	}
+	sort.Slice(resourceSets, func(i, j int) bool {
+		return resourceSets[i].Name+resourceSets[i].Id < resourceSets[j].Name+resourceSets[j].Id
+	})
+	for _, rs := range resourceSets {
+		add("xenorchestra_resource_set", rs.Id, rs.Name)
+	}
+	sort.Slice(cloudConfigs, func(i, j int) bool {
+		return cloudConfigs[i].Name+cloudConfigs[i].Id < cloudConfigs[j].Name+cloudConfigs[j].Id
+	})
+	for _, cloudConfig := range cloudConfigs {
+		add("xenorchestra_cloud_config", cloudConfig.Id, cloudConfig.Name)
+	}
+	sort.Slice(acls, func(i, j int) bool { return acls[i].Id < acls[j].Id })
+	for _, acl := range acls {
+		label := fmt.Sprintf("%s_%s_%s", g.users[acl.Subject], g.objects[acl.Object].NameLabel, acl.Action)
+		add("xenorchestra_acl", acl.Id, label)
+	}
+
+	for _, r := range resources {
+		d, err := g.readResource(r.resourceType, r.id)
+		if err != nil {
+			return fmt.Errorf("failed to read %s %s: %w", r.resourceType, r.id, err)
+		}
+		g.writeResource(r.resourceType, r.name, d)
+	}
+	return nil
+}
+
+// isManagedNetwork reports whether the network can be managed by the
+// xenorchestra_network resource: internal networks without PIFs and VLAN
+// networks. Physical, bonded and private networks, as well as the host
+// internal management network, are referenced through data sources.
+func isManagedNetwork(network importObject, objects map[string]importObject) bool {
+	if network.OtherConfig["is_host_internal_management_network"] == "true" {
+		return false
+	}
+	for _, pifId := range network.PIFs {
+		if pif, ok := objects[pifId]; !ok || pif.Vlan < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedImportObjects(objects map[string]importObject, objectType string) []importObject {
+	result := []importObject{}
+	for _, obj := range objects {
+		if obj.Type == objectType {
+			result = append(result, obj)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].NameLabel != result[j].NameLabel {
+			return result[i].NameLabel < result[j].NameLabel
+		}
+		return result[i].Id < result[j].Id
+	})
+	return result
+}
+
+// readResource imports and refreshes the resource like `terraform import`
+// does.
+func (g *importGenerator) readResource(resourceType, id string) (*schema.ResourceData, error) {
+	r := g.provider.ResourcesMap[resourceType]
+	d := r.Data(nil)
+	d.SetId(id)
+
+	var imported []*schema.ResourceData
+	var err error
+	if r.Importer.StateContext != nil {
+		imported, err = r.Importer.StateContext(g.ctx, d, g.meta)
+	} else {
+		imported, err = r.Importer.State(d, g.meta)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state, diags := r.RefreshWithoutUpgrade(g.ctx, imported[0].State(), g.meta)
+	if diags.HasError() {
+		return nil, diagnosticsError(diags)
+	}
+	if state == nil || state.ID == "" {
+		return nil, fmt.Errorf("the object no longer exists")
+	}
+	return r.Data(state), nil
+}
+
+func (g *importGenerator) writeResource(resourceType, name string, d *schema.ResourceData) {
+	body := g.resources.Body()
+
+	importBody := body.AppendNewBlock("import", nil).Body()
+	importBody.SetAttributeTraversal("to", hcl.Traversal{
+		hcl.TraverseRoot{Name: resourceType},
+		hcl.TraverseAttr{Name: name},
+	})
+	importBody.SetAttributeValue("id", cty.StringVal(d.Id()))
+	body.AppendNewline()
+
+	resourceSchema := g.provider.ResourcesMap[resourceType].Schema
+	values := map[string]interface{}{}
+	for k := range resourceSchema {
+		values[k] = d.Get(k)
+	}
+	g.writeAttributes(body.AppendNewBlock("resource", []string{resourceType, name}).Body(), resourceSchema, values)
+	body.AppendNewline()
+}
+
+// writeAttributes writes the configurable attributes that differ from
+// their default, followed by the nested blocks.
+func (g *importGenerator) writeAttributes(body *hclwrite.Body, schemaMap map[string]*schema.Schema, values map[string]interface{}) {
+	keys := make([]string, 0, len(schemaMap))
+	for k := range schemaMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	blocks := []string{}
+	for _, k := range keys {
+		s := schemaMap[k]
+		if (s.Computed && !s.Optional) || s.Deprecated != "" {
+			continue
+		}
+		if _, ok := s.Elem.(*schema.Resource); ok {
+			blocks = append(blocks, k)
+			continue
+		}
+
+		value := values[k]
+		if set, ok := value.(*schema.Set); ok {
+			value = set.List()
+		}
+		if !s.Required && isDefaultValue(s, value) {
+			continue
+		}
+		if s.Required && value == "" {
+			body.AppendUnstructuredTokens(hclwrite.Tokens{{
+				Type:  hclsyntax.TokenComment,
+				Bytes: []byte(fmt.Sprintf("# %s is not reported by Xen Orchestra, it must be set to the value the object was created with\n", k)),
+			}})
+		}
+		body.SetAttributeRaw(k, g.valueTokens(value))
+	}
+
+	for _, k := range blocks {
+		value := values[k]
+		if set, ok := value.(*schema.Set); ok {
+			value = set.List()
+		}
+		elems, _ := value.([]interface{})
+		for _, elem := range elems {
+			elemValues, _ := elem.(map[string]interface{})
+			g.writeAttributes(body.AppendNewBlock(k, nil).Body(), schemaMap[k].Elem.(*schema.Resource).Schema, elemValues)
+		}
+	}
+}
+
+func isDefaultValue(s *schema.Schema, value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+
+	def := s.Default
+	if def == nil && s.DefaultFunc != nil {
+		def, _ = s.DefaultFunc()
+	}
+	if def == nil {
+		switch s.Type {
+		case schema.TypeString:
+			def = ""
+		case schema.TypeInt:
+			def = 0
+		case schema.TypeFloat:
+			def = 0.0
+		case schema.TypeBool:
+			def = false
+		}
+	}
+	return fmt.Sprint(def) == fmt.Sprint(value)
+}
+
+func (g *importGenerator) valueTokens(value interface{}) hclwrite.Tokens {
+	switch v := value.(type) {
+	case string:
+		if traversal, ok := g.reference(v); ok {
+			return hclwrite.TokensForTraversal(traversal)
+		}
+		return hclwrite.TokensForValue(cty.StringVal(v))
+	case int:
+		return hclwrite.TokensForValue(cty.NumberIntVal(int64(v)))
+	case float64:
+		return hclwrite.TokensForValue(cty.NumberFloatVal(v))
+	case bool:
+		return hclwrite.TokensForValue(cty.BoolVal(v))
+	case []interface{}:
+		elems := make([]hclwrite.Tokens, 0, len(v))
+		for _, elem := range v {
+			elems = append(elems, g.valueTokens(elem))
+		}
+		return hclwrite.TokensForTuple(elems)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		attrs := make([]hclwrite.ObjectAttrTokens, 0, len(v))
+		for _, k := range keys {
+			attrs = append(attrs, hclwrite.ObjectAttrTokens{
+				Name:  hclwrite.TokensForValue(cty.StringVal(k)),
+				Value: g.valueTokens(v[k]),
+			})
+		}
+		return hclwrite.TokensForObject(attrs)
+	}
+	return hclwrite.TokensForValue(cty.StringVal(fmt.Sprint(value)))
+}
+
+// reference returns the expression referencing the object with the given
+// id, adding the data source that looks it up if needed. Objects whose name
+// is not unique are not referenced since a data source could not find them.
+func (g *importGenerator) reference(id string) (hcl.Traversal, bool) {
+	if traversal, ok := g.refs[id]; ok {
+		return traversal, true
+	}
+
+	var dataSource string
+	attrs := map[string]hclwrite.Tokens{}
+	var label string
+	if email, ok := g.users[id]; ok {
+		dataSource, label = "xenorchestra_user", email
+		attrs["username"] = hclwrite.TokensForValue(cty.StringVal(email))
+	} else {
+		obj, ok := g.objects[id]
+		if !ok {
+			return nil, false
+		}
+		ds, ok := importDataSources[obj.Type]
+		if !ok || obj.NameLabel == "" || g.nameCounts[g.nameKey(obj)] != 1 {
+			return nil, false
+		}
+		dataSource, label = ds.dataSource, obj.NameLabel
+		attrs["name_label"] = hclwrite.TokensForValue(cty.StringVal(obj.NameLabel))
+		if ds.poolScoped {
+			attrs["pool_id"] = g.valueTokens(obj.PoolId)
+		}
+	}
+
+	name := g.uniqueName("data."+dataSource, label)
+	body := g.dataSources.Body().AppendNewBlock("data", []string{dataSource, name}).Body()
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		body.SetAttributeRaw(k, attrs[k])
+	}
+	g.dataSources.Body().AppendNewline()
+
+	g.refs[id] = hcl.Traversal{
+		hcl.TraverseRoot{Name: "data"},
+		hcl.TraverseAttr{Name: dataSource},
+		hcl.TraverseAttr{Name: name},
+		hcl.TraverseAttr{Name: "id"},
+	}
+	return g.refs[id], true
+}
+
+// nameKey identifies the objects that a data source cannot tell apart.
+func (g *importGenerator) nameKey(obj importObject) string {
+	key := obj.Type + "/" + obj.NameLabel
+	if importDataSources[obj.Type].poolScoped {
+		key = obj.PoolId + "/" + key
+	}
+	return key
+}
+
+// uniqueName returns a Terraform identifier derived from label that is not
+// used yet by the blocks of the given kind.
+func (g *importGenerator) uniqueName(kind, label string) string {
+	base := hclName(label)
+	name := base
+	for i := 2; g.names[kind+"."+name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	g.names[kind+"."+name] = true
+	return name
+}
+
+// hclName converts label to a valid Terraform identifier.
+func hclName(label string) string {
+	name := strings.Trim(hclNameInvalidChars.ReplaceAllString(strings.ToLower(label), "_"), "_")
+	if name == "" {
+		return "unnamed"
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		return "_" + name
+	}
+	return name
+}
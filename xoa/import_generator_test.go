@@ -0,0 +1,199 @@
+package xoa
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccGenerateImportConfig(t *testing.T) {
+	resourceName := "xenorchestra_network.network"
+	nameLabel := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraNetworkConfig(nameLabel),
+				Check: func(s *terraform.State) error {
+					rs, ok := s.RootModule().Resources[resourceName]
+					if !ok {
+						return fmt.Errorf("can't find %s", resourceName)
+					}
+
+					var config bytes.Buffer
+					if err := GenerateImportConfig(context.Background(), &config); err != nil {
+						return err
+					}
+
+					name := hclName(nameLabel)
+					expected := []string{
+						fmt.Sprintf("import {\n  to = xenorchestra_network.%s\n  id = %q\n}", name, rs.Primary.ID),
+						fmt.Sprintf("resource \"xenorchestra_network\" \"%s\" {", name),
+						fmt.Sprintf("= %q", nameLabel),
+					}
+					for _, e := range expected {
+						if !strings.Contains(config.String(), e) {
+							return fmt.Errorf("expected the generated config to contain:\n%s\nreceived:\n%s", e, config.String())
+						}
+					}
+					return nil
+				},
+			},
+		},
+	},
+	)
+}
+
+func Test_hclName(t *testing.T) {
+	tests := map[string]string{
+		"web-01":          "web_01",
+		"Ubuntu 22.04 VM": "ubuntu_22_04_vm",
+		"  --  ":          "unnamed",
+		"1st vm":          "_1st_vm",
+		"already_valid":   "already_valid",
+	}
+
+	for label, expected := range tests {
+		if name := hclName(label); name != expected {
+			t.Errorf("expected %q to be converted to %q, received %q", label, expected, name)
+		}
+	}
+}
+
+func Test_isManagedNetwork(t *testing.T) {
+	objects := map[string]importObject{
+		"pif-physical": {Id: "pif-physical", Type: "PIF", Vlan: -1},
+		"pif-vlan":     {Id: "pif-vlan", Type: "PIF", Vlan: 100},
+	}
+
+	tests := []struct {
+		network importObject
+		managed bool
+	}{
+		{network: importObject{NameLabel: "internal"}, managed: true},
+		{network: importObject{NameLabel: "vlan", PIFs: []string{"pif-vlan"}}, managed: true},
+		{network: importObject{NameLabel: "eth0", PIFs: []string{"pif-physical"}}, managed: false},
+		{network: importObject{NameLabel: "unknown pif", PIFs: []string{"pif-missing"}}, managed: false},
+		{network: importObject{NameLabel: "management", OtherConfig: map[string]string{"is_host_internal_management_network": "true"}}, managed: false},
+	}
+
+	for _, test := range tests {
+		if managed := isManagedNetwork(test.network, objects); managed != test.managed {
+			t.Errorf("expected network %q managed to be %t, received %t", test.network.NameLabel, test.managed, managed)
+		}
+	}
+}
+
+func Test_importGeneratorWriteAttributes(t *testing.T) {
+	g := &importGenerator{
+		objects: map[string]importObject{
+			"pool-1": {Id: "pool-1", Type: "pool", NameLabel: "Production", PoolId: "pool-1"},
+			"sr-1":   {Id: "sr-1", Type: "SR", NameLabel: "Local storage", PoolId: "pool-1"},
+			"sr-2":   {Id: "sr-2", Type: "SR", NameLabel: "Shared", PoolId: "pool-1"},
+			"sr-3":   {Id: "sr-3", Type: "SR", NameLabel: "Shared", PoolId: "pool-1"},
+		},
+		users: map[string]string{"user-1": "admin@admin.net"},
+		refs: map[string]hcl.Traversal{
+			"network-1": {
+				hcl.TraverseRoot{Name: "xenorchestra_network"},
+				hcl.TraverseAttr{Name: "internal"},
+				hcl.TraverseAttr{Name: "id"},
+			},
+		},
+		names:       map[string]bool{},
+		dataSources: hclwrite.NewEmptyFile(),
+		resources:   hclwrite.NewEmptyFile(),
+	}
+	g.nameCounts = map[string]int{}
+	for _, obj := range g.objects {
+		g.nameCounts[g.nameKey(obj)]++
+	}
+
+	schemaMap := map[string]*schema.Schema{
+		"name_label": {Type: schema.TypeString, Required: true},
+		"template":   {Type: schema.TypeString, Required: true},
+		"cpus":       {Type: schema.TypeInt, Optional: true, Default: 1},
+		"power":      {Type: schema.TypeString, Optional: true, Default: "Running"},
+		"owner":      {Type: schema.TypeString, Optional: true},
+		"usage":      {Type: schema.TypeInt, Computed: true},
+		"tags":       {Type: schema.TypeSet, Optional: true, Elem: &schema.Schema{Type: schema.TypeString}},
+		"disk": {
+			Type:     schema.TypeList,
+			Required: true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"sr_id":    {Type: schema.TypeString, Required: true},
+					"position": {Type: schema.TypeString, Computed: true},
+				},
+			},
+		},
+		"network": {
+			Type:     schema.TypeList,
+			Optional: true,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"network_id": {Type: schema.TypeString, Required: true},
+				},
+			},
+		},
+	}
+	values := map[string]interface{}{
+		"name_label": "web",
+		"template":   "",
+		"cpus":       1,
+		"power":      "Halted",
+		"owner":      "user-1",
+		"usage":      3,
+		"tags":       []interface{}{},
+		"disk": []interface{}{
+			map[string]interface{}{"sr_id": "sr-1", "position": "0"},
+			map[string]interface{}{"sr_id": "sr-2", "position": "1"},
+		},
+		"network": []interface{}{
+			map[string]interface{}{"network_id": "network-1"},
+		},
+	}
+	g.writeAttributes(g.resources.Body().AppendNewBlock("resource", []string{"xenorchestra_vm", "web"}).Body(), schemaMap, values)
+
+	expectedResource := `resource "xenorchestra_vm" "web" {
+  name_label = "web"
+  owner      = data.xenorchestra_user.admin_admin_net.id
+  power      = "Halted"
+  # template is not reported by Xen Orchestra, it must be set to the value the object was created with
+  template = ""
+  disk {
+    sr_id = data.xenorchestra_sr.local_storage.id
+  }
+  disk {
+    sr_id = "sr-2"
+  }
+  network {
+    network_id = xenorchestra_network.internal.id
+  }
+}
+`
+	if config := string(hclwrite.Format(g.resources.Bytes())); config != expectedResource {
+		t.Errorf("expected resource:\n%s\nreceived:\n%s", expectedResource, config)
+	}
+
+	expectedDataSources := []string{
+		"data \"xenorchestra_user\" \"admin_admin_net\" {\n  username = \"admin@admin.net\"\n}",
+		"data \"xenorchestra_pool\" \"production\" {\n  name_label = \"Production\"\n}",
+		"data \"xenorchestra_sr\" \"local_storage\" {\n  name_label = \"Local storage\"\n  pool_id    = data.xenorchestra_pool.production.id\n}",
+	}
+	dataSources := string(hclwrite.Format(g.dataSources.Bytes()))
+	for _, expected := range expectedDataSources {
+		if !strings.Contains(dataSources, expected) {
+			t.Errorf("expected data sources to contain:\n%s\nreceived:\n%s", expected, dataSources)
+		}
+	}
+}
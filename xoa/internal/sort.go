@@ -30,6 +30,149 @@ func SortPools(pools []client.Pool, sortBy, sortOrder string) []client.Pool {
 	return pools
 }
 
+// SortVms sorts a list of VMs based on the specified field and order
+func SortVms(vms []client.Vm, sortBy, sortOrder string) []client.Vm {
+	if len(vms) == 0 {
+		return vms
+	}
+
+	switch sortBy {
+	case "id":
+		sort.Slice(vms, func(i, j int) bool {
+			return compareString(vms[i].Id, vms[j].Id, sortOrder)
+		})
+	case "name_label":
+		sort.Slice(vms, func(i, j int) bool {
+			return compareString(vms[i].NameLabel, vms[j].NameLabel, sortOrder)
+		})
+	default:
+		// No sorting if sort_by is not recognized
+		return vms
+	}
+
+	return vms
+}
+
+// SortNetworks sorts a list of networks based on the specified field and order,
+// by id when sortBy is empty so that the order is stable across reads
+func SortNetworks(networks []client.Network, sortBy, sortOrder string) []client.Network {
+	if len(networks) == 0 {
+		return networks
+	}
+
+	switch sortBy {
+	case "", "id":
+		sort.Slice(networks, func(i, j int) bool {
+			return compareString(networks[i].Id, networks[j].Id, sortOrder)
+		})
+	case "name_label":
+		sort.Slice(networks, func(i, j int) bool {
+			return compareString(networks[i].NameLabel, networks[j].NameLabel, sortOrder)
+		})
+	default:
+		// No sorting if sort_by is not recognized
+		return networks
+	}
+
+	return networks
+}
+
+// SortStorageRepositories sorts a list of SRs based on the specified field and order,
+// by id when sortBy is empty so that the order is stable across reads
+func SortStorageRepositories(srs []client.StorageRepository, sortBy, sortOrder string) []client.StorageRepository {
+	if len(srs) == 0 {
+		return srs
+	}
+
+	switch sortBy {
+	case "", "id":
+		sort.Slice(srs, func(i, j int) bool {
+			return compareString(srs[i].Id, srs[j].Id, sortOrder)
+		})
+	case "name_label":
+		sort.Slice(srs, func(i, j int) bool {
+			return compareString(srs[i].NameLabel, srs[j].NameLabel, sortOrder)
+		})
+	default:
+		// No sorting if sort_by is not recognized
+		return srs
+	}
+
+	return srs
+}
+
+// SortTemplates sorts a list of templates based on the specified field and order,
+// by id when sortBy is empty so that the order is stable across reads
+func SortTemplates(templates []client.Template, sortBy, sortOrder string) []client.Template {
+	if len(templates) == 0 {
+		return templates
+	}
+
+	switch sortBy {
+	case "", "id":
+		sort.Slice(templates, func(i, j int) bool {
+			return compareString(templates[i].Id, templates[j].Id, sortOrder)
+		})
+	case "name_label":
+		sort.Slice(templates, func(i, j int) bool {
+			return compareString(templates[i].NameLabel, templates[j].NameLabel, sortOrder)
+		})
+	default:
+		// No sorting if sort_by is not recognized
+		return templates
+	}
+
+	return templates
+}
+
+// SortVDIs sorts a list of VDIs based on the specified field and order,
+// by id when sortBy is empty so that the order is stable across reads
+func SortVDIs(vdis []client.VDI, sortBy, sortOrder string) []client.VDI {
+	if len(vdis) == 0 {
+		return vdis
+	}
+
+	switch sortBy {
+	case "", "id":
+		sort.Slice(vdis, func(i, j int) bool {
+			return compareString(vdis[i].VDIId, vdis[j].VDIId, sortOrder)
+		})
+	case "name_label":
+		sort.Slice(vdis, func(i, j int) bool {
+			return compareString(vdis[i].NameLabel, vdis[j].NameLabel, sortOrder)
+		})
+	default:
+		// No sorting if sort_by is not recognized
+		return vdis
+	}
+
+	return vdis
+}
+
+// SortPIFs sorts a list of PIFs based on the specified field and order,
+// by id when sortBy is empty so that the order is stable across reads
+func SortPIFs(pifs []client.PIF, sortBy, sortOrder string) []client.PIF {
+	if len(pifs) == 0 {
+		return pifs
+	}
+
+	switch sortBy {
+	case "", "id":
+		sort.Slice(pifs, func(i, j int) bool {
+			return compareString(pifs[i].Id, pifs[j].Id, sortOrder)
+		})
+	case "device":
+		sort.Slice(pifs, func(i, j int) bool {
+			return compareString(pifs[i].Device, pifs[j].Device, sortOrder)
+		})
+	default:
+		// No sorting if sort_by is not recognized
+		return pifs
+	}
+
+	return pifs
+}
+
 // compareString compares two strings based on the sort order
 // Returns true if a should come before b
 func compareString(a, b string, sortOrder string) bool {
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func TestSortPIFs(t *testing.T) {
+	pifs := func() []client.PIF {
+		return []client.PIF{
+			{Id: "b", Device: "eth1"},
+			{Id: "c", Device: "eth0"},
+			{Id: "a", Device: "eth2"},
+		}
+	}
+
+	tests := []struct {
+		sortBy    string
+		sortOrder string
+		expected  []string
+	}{
+		{sortBy: "id", sortOrder: "asc", expected: []string{"a", "b", "c"}},
+		{sortBy: "id", sortOrder: "desc", expected: []string{"c", "b", "a"}},
+		{sortBy: "device", sortOrder: "asc", expected: []string{"c", "b", "a"}},
+		{sortBy: "", sortOrder: "asc", expected: []string{"a", "b", "c"}},
+		{sortBy: "unknown", sortOrder: "asc", expected: []string{"b", "c", "a"}},
+	}
+
+	for _, test := range tests {
+		sorted := SortPIFs(pifs(), test.sortBy, test.sortOrder)
+		ids := make([]string, 0, len(sorted))
+		for _, pif := range sorted {
+			ids = append(ids, pif.Id)
+		}
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("expected sort_by=%q sort_order=%q to return %v, received %v", test.sortBy, test.sortOrder, test.expected, ids)
+		}
+	}
+}
+
+func TestSortStorageRepositoriesIsCaseInsensitive(t *testing.T) {
+	srs := []client.StorageRepository{
+		{Id: "1", NameLabel: "b"},
+		{Id: "2", NameLabel: "A"},
+	}
+
+	sorted := SortStorageRepositories(srs, "name_label", "asc")
+	if sorted[0].Id != "2" {
+		t.Errorf("expected `A` to sort before `b`, received %v", sorted)
+	}
+}
@@ -77,28 +77,46 @@ func Provider() *schema.Provider {
 			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"xenorchestra_acl":            resourceAcl(),
-			"xenorchestra_bonded_network": resourceXoaBondedNetwork(),
-			"xenorchestra_cloud_config":   resourceCloudConfigRecord(),
-			"xenorchestra_network":        resourceXoaNetwork(),
-			"xenorchestra_vm":             resourceRecord(),
-			"xenorchestra_resource_set":   resourceResourceSet(),
-			"xenorchestra_vdi":            resourceVDIRecord(),
+			"xenorchestra_acl":                  resourceAcl(),
+			"xenorchestra_bonded_network":       resourceXoaBondedNetwork(),
+			"xenorchestra_cloud_config":         resourceCloudConfigRecord(),
+			"xenorchestra_cloud_network_config": resourceCloudNetworkConfigRecord(),
+			"xenorchestra_host":                 resourceHostRecord(),
+			"xenorchestra_ip_pool":              resourceIpPool(),
+			"xenorchestra_network":              resourceXoaNetwork(),
+			"xenorchestra_pif_configuration":    resourcePifConfiguration(),
+			"xenorchestra_pool":                 resourcePoolRecord(),
+			"xenorchestra_pool_patches":         resourcePoolPatchesRecord(),
+			"xenorchestra_private_network":      resourceXoaPrivateNetwork(),
+			"xenorchestra_vm":                   resourceRecord(),
+			"xenorchestra_resource_set":         resourceResourceSet(),
+			"xenorchestra_resource_set_object":  resourceResourceSetObject(),
+			"xenorchestra_resource_set_subject": resourceResourceSetSubject(),
+			"xenorchestra_template":             resourceTemplateRecord(),
+			"xenorchestra_vdi":                  resourceVDIRecord(),
+			"xenorchestra_vm_export":            resourceVmExportRecord(),
+			"xenorchestra_vm_import":            resourceVmImportRecord(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"xenorchestra_cloud_config": dataSourceXoaCloudConfig(),
-			"xenorchestra_network":      dataSourceXoaNetwork(),
-			"xenorchestra_pif":          dataSourceXoaPIF(),
-			"xenorchestra_pool":         dataSourceXoaPool(),
-			"xenorchestra_pools":        dataSourceXoaPools(),
-			"xenorchestra_host":         dataSourceXoaHost(),
-			"xenorchestra_hosts":        dataSourceXoaHosts(),
-			"xenorchestra_template":     dataSourceXoaTemplate(),
-			"xenorchestra_resource_set": dataSourceXoaResourceSet(),
-			"xenorchestra_sr":           dataSourceXoaStorageRepository(),
-			"xenorchestra_user":         dataSourceXoaUser(),
-			"xenorchestra_vms":          dataSourceXoaVms(),
-			"xenorchestra_vdi":          dataSourceXoaVDI(),
+			"xenorchestra_cloud_config":  dataSourceXoaCloudConfig(),
+			"xenorchestra_cloud_configs": dataSourceXoaCloudConfigs(),
+			"xenorchestra_network":       dataSourceXoaNetwork(),
+			"xenorchestra_networks":      dataSourceXoaNetworks(),
+			"xenorchestra_pif":           dataSourceXoaPIF(),
+			"xenorchestra_pifs":          dataSourceXoaPIFs(),
+			"xenorchestra_pool":          dataSourceXoaPool(),
+			"xenorchestra_pools":         dataSourceXoaPools(),
+			"xenorchestra_host":          dataSourceXoaHost(),
+			"xenorchestra_hosts":         dataSourceXoaHosts(),
+			"xenorchestra_template":      dataSourceXoaTemplate(),
+			"xenorchestra_templates":     dataSourceXoaTemplates(),
+			"xenorchestra_resource_set":  dataSourceXoaResourceSet(),
+			"xenorchestra_sr":            dataSourceXoaStorageRepository(),
+			"xenorchestra_srs":           dataSourceXoaStorageRepositories(),
+			"xenorchestra_user":          dataSourceXoaUser(),
+			"xenorchestra_vms":           dataSourceXoaVms(),
+			"xenorchestra_vdi":           dataSourceXoaVDI(),
+			"xenorchestra_vdis":          dataSourceXoaVDIs(),
 		},
 		ConfigureContextFunc: xoaConfigure,
 	}
@@ -140,5 +158,8 @@ func xoaConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, dia
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
+	if sdkClient, ok := c.(*client.Client); ok {
+		return &xoaClient{Client: sdkClient, config: config}, nil
+	}
 	return c, nil
 }
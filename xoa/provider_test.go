@@ -2,6 +2,8 @@ package xoa
 
 import (
 	"os"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/vatesfr/terraform-provider-xenorchestra/xoa/internal"
@@ -96,3 +98,9 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatal("The XOA_RETRY_MODE environment variable must be set")
 	}
 }
+
+// hclRegexpQuote escapes s so that it can be used as a literal within a
+// regular expression in an HCL string.
+func hclRegexpQuote(s string) string {
+	return strings.ReplaceAll(regexp.QuoteMeta(s), `\`, `\\`)
+}
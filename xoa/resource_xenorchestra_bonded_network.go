@@ -3,6 +3,7 @@ package xoa
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -12,6 +13,19 @@ import (
 
 var validBondModes []string = []string{"balance-slb", "active-backup", "lacp"}
 
+var validBondHashingAlgorithms []string = []string{"src_mac", "tcpudp_ports"}
+
+// bondHashingAlgorithmKey is the bond property that sets how the traffic of
+// a lacp bond is balanced between its members.
+const bondHashingAlgorithmKey = "hashing_algorithm"
+
+// bondObject is a bond along with its properties, which the SDK's type does
+// not expose.
+type bondObject struct {
+	client.Bond
+	Properties map[string]string `json:"properties"`
+}
+
 func resourceXoaBondedNetwork() *schema.Resource {
 	return &schema.Resource{
 		Description:   "A resource for managing Bonded Xen Orchestra networks. See the XCP-ng [networking docs](https://xcp-ng.org/docs/networking.html) for more details.",
@@ -19,6 +33,7 @@ func resourceXoaBondedNetwork() *schema.Resource {
 		DeleteContext: resourceBondedNetworkDeleteContext,
 		ReadContext:   resourceBondedNetworkReadContext,
 		UpdateContext: resourceBondedNetworkUpdateContext,
+		CustomizeDiff: bondedNetworkCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			State: resourceBondedNetworkImport,
 		},
@@ -52,16 +67,57 @@ func resourceXoaBondedNetwork() *schema.Resource {
 				Optional:    true,
 				ForceNew:    true,
 				Computed:    true,
-				Description: "The PIFs (uuid) that should be used for this network.",
+				Description: "The PIFs (uuid) that should be used for this network. XAPI cannot add or remove the members of an existing bond and Xen Orchestra cannot recreate the bond of an existing network, so changing them recreates the network.",
+			},
+			"members": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The member PIFs of every host's bond along with their link state, sorted by host id.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"host_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"bond_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"pif_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"device": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"attached": &schema.Schema{
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "If the member PIF is plugged.",
+						},
+						"carrier": &schema.Schema{
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether the member PIF's link is up.",
+						},
+					},
+				},
 			},
 			"bond_mode": &schema.Schema{
 				Type:         schema.TypeString,
 				Optional:     true,
-				ForceNew:     true,
 				Computed:     true,
-				Description:  "The bond mode that should be used for this network.",
+				Description:  "The bond mode that should be used for this network. Changing it switches the mode of every host's bond in place.",
 				ValidateFunc: validation.StringInSlice(validBondModes, false),
 			},
+			"hashing_algorithm": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				Description:  "How the traffic of a `lacp` bond is balanced between its members, either `src_mac` or `tcpudp_ports`. It can only be set when `bond_mode` is `lacp` and is changed in place.",
+				ValidateFunc: validation.StringInSlice(validBondHashingAlgorithms, false),
+			},
 			"pool_id": &schema.Schema{
 				Type:        schema.TypeString,
 				Required:    true,
@@ -102,7 +158,26 @@ func resourceBondedNetworkCreateContext(ctx context.Context, d *schema.ResourceD
 	if len(network.PIFs) < 1 {
 		return diag.FromErr(fmt.Errorf("network should contain more than one PIF after creation"))
 	}
-	return diag.FromErr(bondedNetworkToData(network, d))
+	if err := bondedNetworkToData(network, d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if isConfigured(d, "hashing_algorithm") {
+		if err := setBondedNetworkHashingAlgorithm(c, network.Id, d.Get("hashing_algorithm").(string)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return resourceBondedNetworkReadContext(ctx, d, m)
+}
+
+func bondedNetworkCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, v interface{}) error {
+	if diff.GetRawConfig().GetAttr("hashing_algorithm").IsNull() || !diff.NewValueKnown("bond_mode") {
+		return nil
+	}
+	if mode := diff.Get("bond_mode").(string); mode != "lacp" {
+		return fmt.Errorf("hashing_algorithm can only be set when bond_mode is `lacp`, not `%s`", mode)
+	}
+	return nil
 }
 
 func resourceBondedNetworkReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -122,6 +197,36 @@ func resourceBondedNetworkReadContext(ctx context.Context, d *schema.ResourceDat
 	if len(network.PIFs) < 1 {
 		return diag.FromErr(fmt.Errorf("network should contain more than one PIF"))
 	}
+
+	bonds, members, err := getBondedNetworkMembers(c, network)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if len(bonds) > 0 {
+		// Report a bond whose mode differs from the configured one as drift
+		mode := bonds[0].Mode
+		for _, bond := range bonds {
+			if bond.Mode != d.Get("bond_mode").(string) {
+				mode = bond.Mode
+			}
+		}
+		if err := d.Set("bond_mode", mode); err != nil {
+			return diag.FromErr(err)
+		}
+
+		algorithm, reported, err := getBondedNetworkHashingAlgorithm(c, bonds, d.Get("hashing_algorithm").(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if reported {
+			if err := d.Set("hashing_algorithm", algorithm); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
+	if err := d.Set("members", members); err != nil {
+		return diag.FromErr(err)
+	}
 	return diag.FromErr(bondedNetworkToData(network, d))
 }
 
@@ -148,9 +253,170 @@ func resourceBondedNetworkUpdateContext(ctx context.Context, d *schema.ResourceD
 	if err != nil {
 		return diag.FromErr(err)
 	}
+
+	if d.HasChange("bond_mode") {
+		if err := setBondedNetworkMode(c, d.Id(), d.Get("bond_mode").(string)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	// Switching a bond to lacp may reset its hashing algorithm so it is set
+	// again after the mode changes.
+	if isConfigured(d, "hashing_algorithm") && d.HasChanges("hashing_algorithm", "bond_mode") {
+		if err := setBondedNetworkHashingAlgorithm(c, d.Id(), d.Get("hashing_algorithm").(string)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	return resourceBondedNetworkReadContext(ctx, d, m)
 }
 
+// setBondedNetworkMode switches the bond of every host of the network to
+// the given mode.
+func setBondedNetworkMode(c client.XOClient, networkId, mode string) error {
+	network, err := c.GetNetwork(client.Network{Id: networkId})
+	if err != nil {
+		return err
+	}
+
+	bonds, _, err := getBondedNetworkMembers(c, network)
+	if err != nil {
+		return err
+	}
+	for _, bond := range bonds {
+		if bond.Mode == mode {
+			continue
+		}
+
+		var success bool
+		params := map[string]interface{}{
+			"bond":     bond.Id,
+			"bondMode": mode,
+		}
+		if err := callXoApi(c, "bond.setMode", params, &success); err != nil {
+			return fmt.Errorf("failed to set the mode of bond %s to %s: %w", bond.Id, mode, err)
+		}
+	}
+	return nil
+}
+
+// setBondedNetworkHashingAlgorithm sets the hashing algorithm property of
+// the bond of every host of the network.
+func setBondedNetworkHashingAlgorithm(c client.XOClient, networkId, algorithm string) error {
+	network, err := c.GetNetwork(client.Network{Id: networkId})
+	if err != nil {
+		return err
+	}
+
+	bonds, _, err := getBondedNetworkMembers(c, network)
+	if err != nil {
+		return err
+	}
+	for _, bond := range bonds {
+		var success bool
+		params := map[string]interface{}{
+			"bond":  bond.Id,
+			"name":  bondHashingAlgorithmKey,
+			"value": algorithm,
+		}
+		if err := callXoApi(c, "bond.setProperty", params, &success); err != nil {
+			return fmt.Errorf("failed to set the hashing algorithm of bond %s to %s: %w", bond.Id, algorithm, err)
+		}
+	}
+	return nil
+}
+
+// getBondedNetworkHashingAlgorithm returns the hashing algorithm of the
+// network's lacp bonds, reporting one that differs from the configured one
+// as drift in the same way as the bond mode. The second value is false when
+// none of the lacp bonds reports the property.
+func getBondedNetworkHashingAlgorithm(c client.XOClient, bonds []client.Bond, configured string) (string, bool, error) {
+	bondObjects := map[string]bondObject{}
+	if err := getXoObjects(c, map[string]interface{}{"type": "bond"}, &bondObjects); err != nil {
+		return "", false, err
+	}
+
+	properties := make([]map[string]string, 0, len(bonds))
+	for _, bond := range bonds {
+		if bond.Mode != "lacp" {
+			continue
+		}
+		properties = append(properties, bondObjects[bond.Id].Properties)
+	}
+	// The property only applies to lacp bonds
+	if len(properties) == 0 {
+		return "", true, nil
+	}
+	algorithm, reported := bondHashingAlgorithm(properties, configured)
+	return algorithm, reported, nil
+}
+
+// bondHashingAlgorithm returns the hashing algorithm among the properties of
+// a network's lacp bonds, preferring one that differs from configured.
+func bondHashingAlgorithm(properties []map[string]string, configured string) (string, bool) {
+	algorithm, reported := "", false
+	for _, props := range properties {
+		value, ok := props[bondHashingAlgorithmKey]
+		if !ok {
+			continue
+		}
+		if !reported || value != configured {
+			algorithm = value
+		}
+		reported = true
+	}
+	return algorithm, reported
+}
+
+// getBondedNetworkMembers returns the bond of every host of the network and
+// the link state of their member PIFs, both sorted by host id.
+func getBondedNetworkMembers(c client.XOClient, network *client.Network) ([]client.Bond, []map[string]interface{}, error) {
+	pifs := map[string]pifObject{}
+	filter := map[string]interface{}{
+		"type":    "PIF",
+		"$poolId": network.PoolId,
+	}
+	if err := getXoObjects(c, filter, &pifs); err != nil {
+		return nil, nil, err
+	}
+
+	masters := []pifObject{}
+	for _, pifId := range network.PIFs {
+		if pif, ok := pifs[pifId]; ok && pif.IsBondMaster {
+			masters = append(masters, pif)
+		}
+	}
+	sort.Slice(masters, func(i, j int) bool {
+		return masters[i].Host < masters[j].Host
+	})
+
+	bonds := make([]client.Bond, 0, len(masters))
+	members := []map[string]interface{}{}
+	for _, master := range masters {
+		bond, err := c.GetBond(client.Bond{Master: master.Id})
+		if err != nil {
+			return nil, nil, err
+		}
+		bonds = append(bonds, *bond)
+
+		slaves := append([]string{}, master.BondSlaves...)
+		sort.Strings(slaves)
+		for _, slaveId := range slaves {
+			slave, ok := pifs[slaveId]
+			if !ok {
+				return nil, nil, fmt.Errorf("failed to find member PIF %s of bond %s", slaveId, bond.Id)
+			}
+			members = append(members, map[string]interface{}{
+				"host_id":  master.Host,
+				"bond_id":  bond.Id,
+				"pif_id":   slave.Id,
+				"device":   slave.Device,
+				"attached": slave.Attached,
+				"carrier":  slave.Carrier,
+			})
+		}
+	}
+	return bonds, members, nil
+}
+
 func resourceBondedNetworkDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(client.XOClient)
 
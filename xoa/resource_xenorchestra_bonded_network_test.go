@@ -2,6 +2,7 @@ package xoa
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -125,6 +126,103 @@ func TestAccXOBondedNetwork_updateForceNew(t *testing.T) {
 	)
 }
 
+func TestAccXOBondedNetwork_updateBondModeInPlace(t *testing.T) {
+	if accTestPIF.Id == "" {
+		t.Skip()
+	}
+	resourceName := "xenorchestra_bonded_network.network"
+	nameLabel := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	var networkId string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraBondedNetworkConfigWithMode(nameLabel, "active-backup"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckXenorchestraNetworkId(resourceName, &networkId, false),
+					resource.TestCheckResourceAttr(resourceName, "bond_mode", "active-backup"),
+					resource.TestCheckResourceAttr(resourceName, "members.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "members.0.host_id", accTestPIF.Host),
+					resource.TestCheckResourceAttrSet(resourceName, "members.0.bond_id"),
+					resource.TestCheckResourceAttrSet(resourceName, "members.0.pif_id"),
+					resource.TestCheckResourceAttrSet(resourceName, "members.0.carrier")),
+			},
+			{
+				Config: testAccXenorchestraBondedNetworkConfigWithMode(nameLabel, "balance-slb"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckXenorchestraNetworkId(resourceName, &networkId, true),
+					resource.TestCheckResourceAttr(resourceName, "bond_mode", "balance-slb")),
+			},
+			{
+				Config: testAccXenorchestraBondedNetworkConfigWithHashingAlgorithm(nameLabel, "tcpudp_ports"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckXenorchestraNetworkId(resourceName, &networkId, true),
+					resource.TestCheckResourceAttr(resourceName, "bond_mode", "lacp"),
+					resource.TestCheckResourceAttr(resourceName, "hashing_algorithm", "tcpudp_ports")),
+			},
+			{
+				Config: testAccXenorchestraBondedNetworkConfigWithHashingAlgorithm(nameLabel, "src_mac"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckXenorchestraNetworkId(resourceName, &networkId, true),
+					resource.TestCheckResourceAttr(resourceName, "hashing_algorithm", "src_mac")),
+			},
+		},
+	},
+	)
+}
+
+func TestAccXOBondedNetwork_hashingAlgorithmRequiresLacp(t *testing.T) {
+	if accTestPIF.Id == "" {
+		t.Skip()
+	}
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraBondedNetworkPIFs() + fmt.Sprintf(`
+resource "xenorchestra_bonded_network" "network" {
+    name_label = "%s"
+    pool_id = "%s"
+    pif_ids = [
+      data.xenorchestra_pif.eth1.id,
+      data.xenorchestra_pif.eth2.id,
+    ]
+    bond_mode = "active-backup"
+    hashing_algorithm = "src_mac"
+}
+`, accTestPrefix, accTestPIF.PoolId),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("hashing_algorithm can only be set when bond_mode is `lacp`"),
+			},
+		},
+	},
+	)
+}
+
+func Test_bondHashingAlgorithm(t *testing.T) {
+	tests := []struct {
+		properties []map[string]string
+		configured string
+		algorithm  string
+		reported   bool
+	}{
+		{properties: []map[string]string{{"hashing_algorithm": "src_mac"}, {"hashing_algorithm": "src_mac"}}, configured: "src_mac", algorithm: "src_mac", reported: true},
+		{properties: []map[string]string{{"hashing_algorithm": "src_mac"}, {"hashing_algorithm": "tcpudp_ports"}}, configured: "src_mac", algorithm: "tcpudp_ports", reported: true},
+		{properties: []map[string]string{{"hashing_algorithm": "tcpudp_ports"}, {"hashing_algorithm": "src_mac"}}, configured: "src_mac", algorithm: "tcpudp_ports", reported: true},
+		{properties: []map[string]string{{}, nil}, configured: "src_mac", algorithm: "", reported: false},
+	}
+
+	for _, test := range tests {
+		algorithm, reported := bondHashingAlgorithm(test.properties, test.configured)
+		if algorithm != test.algorithm || reported != test.reported {
+			t.Errorf("expected %v with %q configured to report (%q, %t), received (%q, %t)", test.properties, test.configured, test.algorithm, test.reported, algorithm, reported)
+		}
+	}
+}
+
 var testAccXenorchestraBondedNetworkPIFs = func() string {
 	return fmt.Sprintf(`
 data "xenorchestra_pif" "eth1" {
@@ -200,3 +298,32 @@ resource "xenorchestra_bonded_network" "network" {
 }
 `, name, desc, accTestPIF.PoolId, mtu)
 }
+
+var testAccXenorchestraBondedNetworkConfigWithMode = func(name, bondMode string) string {
+	return testAccXenorchestraBondedNetworkPIFs() + fmt.Sprintf(`
+resource "xenorchestra_bonded_network" "network" {
+    name_label = "%s"
+    pool_id = "%s"
+    pif_ids = [
+      data.xenorchestra_pif.eth1.id,
+      data.xenorchestra_pif.eth2.id,
+    ]
+    bond_mode = "%s"
+}
+`, name, accTestPIF.PoolId, bondMode)
+}
+
+var testAccXenorchestraBondedNetworkConfigWithHashingAlgorithm = func(name, algorithm string) string {
+	return testAccXenorchestraBondedNetworkPIFs() + fmt.Sprintf(`
+resource "xenorchestra_bonded_network" "network" {
+    name_label = "%s"
+    pool_id = "%s"
+    pif_ids = [
+      data.xenorchestra_pif.eth1.id,
+      data.xenorchestra_pif.eth2.id,
+    ]
+    bond_mode = "lacp"
+    hashing_algorithm = "%s"
+}
+`, name, accTestPIF.PoolId, algorithm)
+}
@@ -2,6 +2,9 @@ package xoa
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
@@ -13,6 +16,7 @@ func resourceCloudConfigRecord() *schema.Resource {
 		Description:   "Creates a Xen Orchestra cloud config resource.",
 		CreateContext: resourceCloudConfigCreateContext,
 		ReadContext:   resourceCloudConfigReadContext,
+		UpdateContext: resourceCloudConfigUpdateContext,
 		DeleteContext: resourceCloudConfigDeleteContext,
 		Importer: &schema.ResourceImporter{
 			State: CloudConfigImport,
@@ -22,13 +26,11 @@ func resourceCloudConfigRecord() *schema.Resource {
 			"template": &schema.Schema{
 				Type:        schema.TypeString,
 				Required:    true,
-				ForceNew:    true,
 				Description: "The cloud init config. See the cloud init docs for more [information](https://cloudinit.readthedocs.io/en/latest/topics/examples.html).",
 			},
 			"name": &schema.Schema{
 				Type:        schema.TypeString,
 				Required:    true,
-				ForceNew:    true,
 				Description: "The name of the cloud config.",
 			},
 		},
@@ -64,6 +66,39 @@ func resourceCloudConfigReadContext(ctx context.Context, d *schema.ResourceData,
 	return nil
 }
 
+func resourceCloudConfigUpdateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	if err := updateStoredCloudConfig(c, d); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceCloudConfigReadContext(ctx, d, m)
+}
+
+// updateStoredCloudConfig updates the name and template of a stored cloud
+// config in place. Cloud configs and cloud network configs share the same
+// XO method.
+func updateStoredCloudConfig(c client.XOClient, d *schema.ResourceData) error {
+	params := map[string]interface{}{
+		"id": d.Id(),
+	}
+	if d.HasChange("name") {
+		params["name"] = d.Get("name").(string)
+	}
+	if d.HasChange("template") {
+		params["template"] = d.Get("template").(string)
+	}
+	if len(params) == 1 {
+		return nil
+	}
+
+	var success bool
+	if err := callXoApi(c, "cloudConfig.update", params, &success); err != nil {
+		return fmt.Errorf("failed to update cloud config %s: %w", d.Id(), err)
+	}
+	return nil
+}
+
 func resourceCloudConfigDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(client.XOClient)
 
@@ -89,3 +124,22 @@ func CloudConfigImport(d *schema.ResourceData, m interface{}) ([]*schema.Resourc
 	d.Set("template", cloud_config.Template)
 	return []*schema.ResourceData{d}, nil
 }
+
+// renderCloudConfigTemplate performs the substitution Xen Orchestra applies
+// to cloud config templates when creating VMs: `{name}` is replaced with the
+// VM's name and every `%` with the VM's index.
+func renderCloudConfigTemplate(template, name string, index int) string {
+	rendered := strings.ReplaceAll(template, "{name}", name)
+	return strings.ReplaceAll(rendered, "%", strconv.Itoa(index))
+}
+
+func getRenderedCloudConfig(c client.XOClient, id, name string, index int) (string, error) {
+	cloudConfig, err := c.GetCloudConfig(id)
+	if err != nil {
+		return "", err
+	}
+	if cloudConfig == nil {
+		return "", fmt.Errorf("could not find cloud config with id `%s`", id)
+	}
+	return renderCloudConfigTemplate(cloudConfig.Template, name, index), nil
+}
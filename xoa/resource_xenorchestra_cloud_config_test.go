@@ -70,6 +70,7 @@ func TestAccXenorchestraCloudConfig_updateName(t *testing.T) {
 	templateText := "template body"
 
 	updatedName := "updated"
+	var cloudConfigId string
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
 		Providers:    testAccProviders,
@@ -77,11 +78,13 @@ func TestAccXenorchestraCloudConfig_updateName(t *testing.T) {
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCloudConfigConfig(templateName, templateText),
+				Check:  testAccCheckXenorchestraCloudConfigId(resourceName, &cloudConfigId, false),
 			},
 			{
 				Config: testAccCloudConfigConfig(updatedName, templateText),
 				Check: resource.ComposeAggregateTestCheckFunc(
 					testAccCloudConfigExists(resourceName),
+					testAccCheckXenorchestraCloudConfigId(resourceName, &cloudConfigId, true),
 					resource.TestCheckResourceAttr(resourceName, "name", fmt.Sprintf("%s%s", accTestPrefix, updatedName))),
 			},
 		},
@@ -94,6 +97,7 @@ func TestAccXenorchestraCloudConfig_updateTemplate(t *testing.T) {
 	templateText := "template body"
 
 	updatedTemplate := "new template"
+	var cloudConfigId string
 	resource.Test(t, resource.TestCase{
 		PreCheck:     func() { testAccPreCheck(t) },
 		Providers:    testAccProviders,
@@ -101,11 +105,13 @@ func TestAccXenorchestraCloudConfig_updateTemplate(t *testing.T) {
 		Steps: []resource.TestStep{
 			{
 				Config: testAccCloudConfigConfig(templateName, templateText),
+				Check:  testAccCheckXenorchestraCloudConfigId(resourceName, &cloudConfigId, false),
 			},
 			{
 				Config: testAccCloudConfigConfig(templateName, updatedTemplate),
 				Check: resource.ComposeAggregateTestCheckFunc(
 					testAccCloudConfigExists(resourceName),
+					testAccCheckXenorchestraCloudConfigId(resourceName, &cloudConfigId, true),
 					resource.TestCheckResourceAttr(resourceName, "template", updatedTemplate)),
 			},
 		},
@@ -150,6 +156,10 @@ func TestAccXenorchestraCloudConfig_import(t *testing.T) {
 	})
 }
 
+// testAccVmCloudConfig is the cloud config template used by the VM tests,
+// it must pass the validate_cloud_init checks of the VMs using it.
+const testAccVmCloudConfig = "#cloud-config"
+
 func testAccCloudConfigConfig(name, template string) string {
 	return fmt.Sprintf(`
 resource "xenorchestra_cloud_config" "bar" {
@@ -184,6 +194,23 @@ func testAccCloudConfigExists(resourceName string) resource.TestCheckFunc {
 	}
 }
 
+// testAccCheckXenorchestraCloudConfigId records the cloud config's id or, when
+// unchanged is set, verifies that the cloud config was not recreated.
+func testAccCheckXenorchestraCloudConfigId(resourceName string, id *string, unchanged bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", resourceName)
+		}
+
+		if unchanged && rs.Primary.ID != *id {
+			return fmt.Errorf("expected cloud config %s to be updated in place, it was recreated as %s", *id, rs.Primary.ID)
+		}
+		*id = rs.Primary.ID
+		return nil
+	}
+}
+
 func testAccCheckXenorchestraCloudConfigDestroyNow(resourceName string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[resourceName]
@@ -232,3 +259,12 @@ func testAccCheckXenorchestraCloudConfigDestroy(s *terraform.State) error {
 	}
 	return nil
 }
+
+func Test_renderCloudConfigTemplate(t *testing.T) {
+	template := "#cloud-config\nhostname: {name}-%\nfqdn: {name}.example.com"
+
+	expected := "#cloud-config\nhostname: web-2\nfqdn: web.example.com"
+	if rendered := renderCloudConfigTemplate(template, "web", 2); rendered != expected {
+		t.Errorf("expected %q, received %q", expected, rendered)
+	}
+}
@@ -0,0 +1,113 @@
+package xoa
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+// cloudNetworkConfigNotFound is returned when a stored cloud network config
+// does not exist. The SDK does not know about network configs.
+type cloudNetworkConfigNotFound struct {
+	id string
+}
+
+func (e cloudNetworkConfigNotFound) Error() string {
+	return fmt.Sprintf("could not find cloud network config with id `%s`", e.id)
+}
+
+func resourceCloudNetworkConfigRecord() *schema.Resource {
+	return &schema.Resource{
+		Description:   "Creates a Xen Orchestra cloud network config resource. These are the cloud-init network configuration templates that can be selected when creating a VM in the Xen Orchestra UI, the content of the template is used with the `cloud_network_config` argument of `xenorchestra_vm`.",
+		CreateContext: resourceCloudNetworkConfigCreateContext,
+		ReadContext:   resourceCloudNetworkConfigReadContext,
+		UpdateContext: resourceCloudNetworkConfigUpdateContext,
+		DeleteContext: resourceCloudConfigDeleteContext,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"template": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The cloud init network config. See the cloud init docs for more [information](https://cloudinit.readthedocs.io/en/latest/topics/network-config-format-v1.html).",
+			},
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The name of the cloud network config.",
+			},
+		},
+	}
+}
+
+func resourceCloudNetworkConfigCreateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	params := map[string]interface{}{
+		"name":     d.Get("name").(string),
+		"template": d.Get("template").(string),
+	}
+	var id string
+	if err := callXoApi(c, "cloudConfig.createNetworkConfig", params, &id); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to create cloud network config: %w", err))
+	}
+	d.SetId(id)
+	return resourceCloudNetworkConfigReadContext(ctx, d, m)
+}
+
+func resourceCloudNetworkConfigReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	networkConfig, err := getCloudNetworkConfig(c, d.Id())
+	if _, ok := err.(cloudNetworkConfigNotFound); ok {
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set("name", networkConfig.Name); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("template", networkConfig.Template); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func resourceCloudNetworkConfigUpdateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	if err := updateStoredCloudConfig(c, d); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceCloudNetworkConfigReadContext(ctx, d, m)
+}
+
+func getCloudNetworkConfigs(c client.XOClient) ([]client.CloudConfig, error) {
+	var networkConfigs []client.CloudConfig
+	if err := callXoApi(c, "cloudConfig.getAllNetworkConfigs", map[string]interface{}{}, &networkConfigs); err != nil {
+		return nil, err
+	}
+	return networkConfigs, nil
+}
+
+func getCloudNetworkConfig(c client.XOClient, id string) (*client.CloudConfig, error) {
+	networkConfigs, err := getCloudNetworkConfigs(c)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, networkConfig := range networkConfigs {
+		if networkConfig.Id == id {
+			return &networkConfig, nil
+		}
+	}
+	return nil, cloudNetworkConfigNotFound{id: id}
+}
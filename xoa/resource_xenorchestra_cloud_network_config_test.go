@@ -0,0 +1,75 @@
+package xoa
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func TestAccXenorchestraCloudNetworkConfig_createAndUpdate(t *testing.T) {
+	resourceName := "xenorchestra_cloud_network_config.bar"
+	name := "network-config"
+	template := "version: 1"
+	updatedTemplate := "version: 2"
+	var networkConfigId string
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckXenorchestraCloudNetworkConfigDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccCloudNetworkConfigConfig(name, template),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckXenorchestraCloudConfigId(resourceName, &networkConfigId, false),
+					resource.TestCheckResourceAttrSet(resourceName, "id"),
+					resource.TestCheckResourceAttr(resourceName, "name", fmt.Sprintf("%s%s", accTestPrefix, name)),
+					resource.TestCheckResourceAttr(resourceName, "template", template)),
+			},
+			{
+				Config: testAccCloudNetworkConfigConfig(name, updatedTemplate),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckXenorchestraCloudConfigId(resourceName, &networkConfigId, true),
+					resource.TestCheckResourceAttr(resourceName, "template", updatedTemplate)),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccCloudNetworkConfigConfig(name, template string) string {
+	return fmt.Sprintf(`
+resource "xenorchestra_cloud_network_config" "bar" {
+    name = "%s%s"
+    template = "%s"
+}
+`, accTestPrefix, name, template)
+}
+
+func testAccCheckXenorchestraCloudNetworkConfigDestroy(s *terraform.State) error {
+	c, err := client.NewClient(client.GetConfigFromEnv())
+	if err != nil {
+		return err
+	}
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "xenorchestra_cloud_network_config" {
+			continue
+		}
+
+		_, err := getCloudNetworkConfig(c, rs.Primary.ID)
+		if _, ok := err.(cloudNetworkConfigNotFound); ok {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("Cloud network config (%s) still exists", rs.Primary.ID)
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package xoa
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+// The host logging key XAPI reads the remote syslog destination from
+const hostSyslogDestinationKey = "syslog_destination"
+
+var validHostPowerOnModes = []string{"", "wake-on-lan", "IPMI", "iLO", "DRAC"}
+
+// How often the resident VMs of a host are checked while it is evacuated
+var hostEvacuationPollInterval = 10 * time.Second
+
+// hostObject is a host along with the settings that the SDK's type does
+// not expose.
+type hostObject struct {
+	client.Host
+	NameDescription string            `json:"name_description"`
+	Enabled         bool              `json:"enabled"`
+	PowerState      string            `json:"power_state"`
+	PowerOnMode     string            `json:"powerOnMode"`
+	Address         string            `json:"address"`
+	Logging         map[string]string `json:"logging"`
+	Tags            []string          `json:"tags"`
+}
+
+func resourceHostRecord() *schema.Resource {
+	return &schema.Resource{
+		Description: `Manages the settings of an existing host. Hosts cannot be created by terraform, so this resource adopts the host given by ` + "`host_id`" + `.
+
+Setting ` + "`enabled`" + ` to ` + "`false`" + ` puts the host in maintenance mode: its VMs are migrated to the other hosts of the pool and terraform waits until the host runs no VM.
+
+**Note:** Destroying this resource only removes it from the terraform state, the host keeps its settings. Settings that are not specified are left as they are.`,
+		CreateContext: resourceHostCreateContext,
+		ReadContext:   resourceHostReadContext,
+		UpdateContext: resourceHostUpdateContext,
+		DeleteContext: resourceHostDeleteContext,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceHostImportContext,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Update: schema.DefaultTimeout(30 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"host_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The id of the host to manage.",
+			},
+			"name_label": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The name of the host.",
+			},
+			"name_description": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The description of the host.",
+			},
+			"enabled": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: "Whether the host accepts VMs. Setting it to `false` enables maintenance mode, which evacuates the host's VMs to the rest of the pool.",
+			},
+			"syslog_destination": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The remote syslog server the host sends its logs to.",
+			},
+			"power_on_mode": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice(validHostPowerOnModes, false),
+				Description:  "How the host is powered on remotely, one of `wake-on-lan`, `IPMI`, `iLO` or `DRAC`. An empty string disables remote power on.",
+			},
+			"power_on_config": &schema.Schema{
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Sensitive:   true,
+				Description: "The configuration of the `power_on_mode`, e.g. `power_on_ip`, `power_on_user` and `power_on_password_secret` for IPMI. XAPI does not return the configuration so changes made outside of terraform are not detected.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"tags": adoptedTagsSchema("host"),
+			"pool_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The id of the pool the host belongs to.",
+			},
+			"address": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The management address of the host.",
+			},
+			"power_state": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The power state of the host.",
+			},
+		},
+	}
+}
+
+func resourceHostCreateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	hostId := d.Get("host_id").(string)
+	host, err := getHostObject(c, hostId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(hostId)
+
+	if err := updateHostSettings(ctx, c, host, d); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceHostReadContext(ctx, d, m)
+}
+
+func resourceHostReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	host, err := getHostObject(c, d.Id())
+	if _, ok := err.(client.NotFound); ok {
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	for key, value := range hostObjectToMap(host) {
+		if err := d.Set(key, value); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return nil
+}
+
+func resourceHostUpdateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+
+	host, err := getHostObject(c, d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := updateHostSettings(ctx, c, host, d); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourceHostReadContext(ctx, d, m)
+}
+
+func resourceHostDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
+
+func resourceHostImportContext(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	d.Set("host_id", d.Id())
+	return []*schema.ResourceData{d}, nil
+}
+
+// updateHostSettings applies the configured settings that differ from the
+// host's current ones, in the same way as updatePoolSettings.
+func updateHostSettings(ctx context.Context, c client.XOClient, host *hostObject, d *schema.ResourceData) error {
+	current := hostObjectToMap(host)
+	changed := func(key string) bool {
+		return isConfigured(d, key) && d.Get(key) != current[key]
+	}
+
+	params := map[string]interface{}{"id": host.Id}
+	for _, key := range []string{"name_label", "name_description"} {
+		if changed(key) {
+			params[key] = d.Get(key)
+		}
+	}
+	var success bool
+	if len(params) > 1 {
+		if err := callXoApi(c, "host.set", params, &success); err != nil {
+			return fmt.Errorf("failed to update host %s: %w", host.Id, err)
+		}
+	}
+
+	if changed("syslog_destination") {
+		params := map[string]interface{}{
+			"id":                host.Id,
+			"syslogDestination": d.Get("syslog_destination").(string),
+		}
+		if err := callXoApi(c, "host.setRemoteSyslogHost", params, &success); err != nil {
+			return fmt.Errorf("failed to set the syslog destination of host %s: %w", host.Id, err)
+		}
+	}
+
+	if changed("power_on_mode") || (isConfigured(d, "power_on_config") && d.HasChange("power_on_config")) {
+		params := map[string]interface{}{
+			"id":            host.Id,
+			"powerOnMode":   d.Get("power_on_mode").(string),
+			"powerOnConfig": d.Get("power_on_config").(map[string]interface{}),
+		}
+		if err := callXoApi(c, "host.set", params, &success); err != nil {
+			return fmt.Errorf("failed to set the power on mode of host %s: %w", host.Id, err)
+		}
+	}
+
+	if err := updateAdoptedTags(c, host.Id, host.Tags, d); err != nil {
+		return err
+	}
+
+	if changed("enabled") {
+		enabled := d.Get("enabled").(bool)
+		tflog.Debug(ctx, "Setting host maintenance mode", map[string]interface{}{
+			"host_id":     host.Id,
+			"maintenance": !enabled,
+		})
+		params := map[string]interface{}{
+			"id":          host.Id,
+			"maintenance": !enabled,
+		}
+		if err := callXoApi(c, "host.setMaintenanceMode", params, &success); err != nil {
+			return fmt.Errorf("failed to set the maintenance mode of host %s: %w", host.Id, err)
+		}
+		if !enabled {
+			return waitForHostEvacuation(ctx, c, host.Id)
+		}
+	}
+	return nil
+}
+
+// waitForHostEvacuation waits until no VM is running on the host.
+func waitForHostEvacuation(ctx context.Context, c client.XOClient, hostId string) error {
+	ticker := time.NewTicker(hostEvacuationPollInterval)
+	defer ticker.Stop()
+
+	for {
+		vms := map[string]client.Vm{}
+		filter := map[string]interface{}{
+			"type":        "VM",
+			"$container":  hostId,
+			"power_state": "Running",
+		}
+		if err := getXoObjects(c, filter, &vms); err != nil {
+			return err
+		}
+		if len(vms) == 0 {
+			return nil
+		}
+
+		tflog.Debug(ctx, "Waiting for host evacuation", map[string]interface{}{
+			"host_id":      hostId,
+			"resident_vms": len(vms),
+		})
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for host %s to be evacuated, %d VMs are still running on it", hostId, len(vms))
+		case <-ticker.C:
+		}
+	}
+}
+
+func getHostObject(c client.XOClient, id string) (*hostObject, error) {
+	hosts := map[string]hostObject{}
+	if err := getXoObjects(c, map[string]interface{}{"type": "host", "id": id}, &hosts); err != nil {
+		return nil, err
+	}
+
+	host, ok := hosts[id]
+	if !ok {
+		return nil, client.NotFound{Query: client.Host{Id: id}}
+	}
+	return &host, nil
+}
+
+func hostObjectToMap(host *hostObject) map[string]interface{} {
+	tags := host.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return map[string]interface{}{
+		"host_id":            host.Id,
+		"name_label":         host.NameLabel,
+		"name_description":   host.NameDescription,
+		"enabled":            host.Enabled,
+		"syslog_destination": host.Logging[hostSyslogDestinationKey],
+		"power_on_mode":      host.PowerOnMode,
+		"tags":               tags,
+		"pool_id":            host.Pool,
+		"address":            host.Address,
+		"power_state":        host.PowerState,
+	}
+}
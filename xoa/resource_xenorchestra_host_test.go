@@ -0,0 +1,92 @@
+package xoa
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func TestAccXenorchestraHost_adoptAndUpdate(t *testing.T) {
+	resourceName := "xenorchestra_host.host"
+	description := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	tag := fmt.Sprintf("%s-host-tag", accTestPrefix)
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraHostConfig(description, tag),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "id", accTestHost.Id),
+					resource.TestCheckResourceAttr(resourceName, "name_label", accTestHost.NameLabel),
+					resource.TestCheckResourceAttr(resourceName, "name_description", description),
+					resource.TestCheckResourceAttr(resourceName, "pool_id", accTestPool.Id),
+					resource.TestCheckResourceAttr(resourceName, "enabled", "true"),
+					resource.TestCheckResourceAttr(resourceName, "tags.#", "1"),
+					resource.TestCheckResourceAttrSet(resourceName, "address"),
+				),
+			},
+			{
+				Config: testAccXenorchestraHostConfig(description+" updated", tag),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name_description", description+" updated"),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccXenorchestraHostConfig(description, tag string) string {
+	return fmt.Sprintf(`
+resource "xenorchestra_host" "host" {
+    host_id = "%s"
+    name_label = "%s"
+    name_description = "%s"
+    enabled = true
+    tags = ["%s"]
+}
+`, accTestHost.Id, accTestHost.NameLabel, description, tag)
+}
+
+func Test_hostObjectToMap(t *testing.T) {
+	host := &hostObject{
+		Host: client.Host{
+			Id:        "host id",
+			NameLabel: "host",
+			Pool:      "pool id",
+		},
+		NameDescription: "description",
+		Enabled:         true,
+		PowerState:      "Running",
+		PowerOnMode:     "IPMI",
+		Address:         "192.168.1.10",
+		Logging: map[string]string{
+			hostSyslogDestinationKey: "syslog.example.com",
+		},
+		Tags: []string{"tag"},
+	}
+
+	expected := map[string]interface{}{
+		"host_id":            "host id",
+		"name_label":         "host",
+		"name_description":   "description",
+		"enabled":            true,
+		"syslog_destination": "syslog.example.com",
+		"power_on_mode":      "IPMI",
+		"tags":               []string{"tag"},
+		"pool_id":            "pool id",
+		"address":            "192.168.1.10",
+		"power_state":        "Running",
+	}
+	if data := hostObjectToMap(host); !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected %+v, received %+v", expected, data)
+	}
+}
@@ -0,0 +1,607 @@
+package xoa
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+// The largest address range an ip pool accepts, XO stores every address of
+// the pool individually
+const maxIpPoolRangeSize = 65536
+
+// ipPoolAddress is the state of an address of an ip pool. The address is
+// allocated when it is assigned to VIFs.
+type ipPoolAddress struct {
+	Vifs []string `json:"vifs,omitempty"`
+}
+
+// ipPoolReservations holds the addresses picked for the VMs being created,
+// keyed by ip pool id. XO only allocates an address once it is set on a VIF
+// after the VM is created, so concurrent creations would otherwise pick the
+// same free address.
+var ipPoolReservations = struct {
+	sync.Mutex
+	addresses map[string]map[string]bool
+}{addresses: map[string]map[string]bool{}}
+
+// ipPoolNotFound is returned when an ip pool does not exist. The SDK has no
+// type for ip pools to build a client.NotFound from.
+type ipPoolNotFound struct {
+	id string
+}
+
+func (e ipPoolNotFound) Error() string {
+	return fmt.Sprintf("could not find ip pool with id `%s`", e.id)
+}
+
+type ipPoolObject struct {
+	Id        string                   `json:"id"`
+	Name      string                   `json:"name"`
+	Addresses map[string]ipPoolAddress `json:"addresses"`
+	Networks  []string                 `json:"networks"`
+}
+
+func resourceIpPool() *schema.Resource {
+	return &schema.Resource{
+		Description:   `Creates a Xen Orchestra IP pool. VMs allocate addresses from the pool with the ` + "`ip_pool_id`" + ` argument of their network blocks, and resource sets can limit the number of addresses their VMs allocate (see ` + "`xenorchestra_resource_set`" + `).`,
+		CreateContext: resourceIpPoolCreateContext,
+		ReadContext:   resourceIpPoolReadContext,
+		UpdateContext: resourceIpPoolUpdateContext,
+		DeleteContext: resourceIpPoolDeleteContext,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The name of the IP pool.",
+			},
+			"address_ranges": &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "The addresses of the pool. Each element is either a single address or an IPv4 range of the form `192.168.1.10-192.168.1.20`, bounds included.",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validateIpPoolAddressRange,
+				},
+			},
+			"network_ids": &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "The ids of the networks whose VIFs can allocate addresses from the pool.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"allocations": &schema.Schema{
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "The allocated addresses of the pool mapped to the id of the VIF they are allocated to.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"resource_set_ids": &schema.Schema{
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Description: "The ids of the resource sets the pool is attached to. The pools of a resource set are managed with the `ip_pools` argument of `xenorchestra_resource_set`.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func resourceIpPoolCreateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	addresses, err := expandIpPoolAddressRanges(d.Get("address_ranges").(*schema.Set))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	addressesParam := map[string]interface{}{}
+	for _, address := range addresses {
+		addressesParam[address] = map[string]interface{}{}
+	}
+
+	params := map[string]interface{}{
+		"name":      d.Get("name").(string),
+		"addresses": addressesParam,
+		"networks":  setToStringSlice(d.Get("network_ids").(*schema.Set)),
+	}
+	var id string
+	if err := callXoApi(c, "ipPool.create", params, &id); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to create ip pool: %w", err))
+	}
+	d.SetId(id)
+
+	return resourceIpPoolReadContext(ctx, d, m)
+}
+
+func resourceIpPoolReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	pool, err := getIpPoolObject(c, d.Id())
+	if _, ok := err.(ipPoolNotFound); ok {
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	resourceSetIds, err := getIpPoolResourceSetIds(c, pool.Id)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	data := ipPoolToMap(pool, resourceSetIds)
+
+	// The configured ranges are kept as long as they describe the pool's
+	// addresses so that they are not rewritten in a different form
+	configured, err := expandIpPoolAddressRanges(d.Get("address_ranges").(*schema.Set))
+	if err == nil && stringSetEqual(configured, sortedIpPoolAddresses(pool)) {
+		delete(data, "address_ranges")
+	}
+
+	for key, value := range data {
+		if err := d.Set(key, value); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return nil
+}
+
+func resourceIpPoolUpdateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	params := map[string]interface{}{
+		"id": d.Id(),
+	}
+	if d.HasChange("name") {
+		params["name"] = d.Get("name").(string)
+	}
+	if d.HasChange("network_ids") {
+		params["networks"] = setToStringSlice(d.Get("network_ids").(*schema.Set))
+	}
+	if d.HasChange("address_ranges") {
+		o, n := d.GetChange("address_ranges")
+		oldAddresses, err := expandIpPoolAddressRanges(o.(*schema.Set))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		newAddresses, err := expandIpPoolAddressRanges(n.(*schema.Set))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		params["addresses"] = ipPoolAddressesPatch(oldAddresses, newAddresses)
+	}
+
+	if len(params) > 1 {
+		var success bool
+		if err := callXoApi(c, "ipPool.set", params, &success); err != nil {
+			return diag.FromErr(fmt.Errorf("failed to update ip pool %s: %w", d.Id(), err))
+		}
+	}
+	return resourceIpPoolReadContext(ctx, d, m)
+}
+
+func resourceIpPoolDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	var success bool
+	if err := callXoApi(c, "ipPool.delete", map[string]interface{}{"id": d.Id()}, &success); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to delete ip pool %s: %w", d.Id(), err))
+	}
+	d.SetId("")
+	return nil
+}
+
+func getIpPoolObject(c client.XOClient, id string) (*ipPoolObject, error) {
+	var pools []ipPoolObject
+	if err := callXoApi(c, "ipPool.getAll", map[string]interface{}{}, &pools); err != nil {
+		return nil, err
+	}
+
+	for _, pool := range pools {
+		if pool.Id == id {
+			return &pool, nil
+		}
+	}
+	return nil, ipPoolNotFound{id: id}
+}
+
+// getIpPoolResourceSetIds returns the ids of the resource sets the pool is
+// attached to.
+func getIpPoolResourceSetIds(c client.XOClient, poolId string) ([]string, error) {
+	var resourceSets []resourceSetObject
+	if err := callXoApi(c, "resourceSet.getAll", map[string]interface{}{}, &resourceSets); err != nil {
+		return nil, err
+	}
+
+	ids := []string{}
+	for _, rs := range resourceSets {
+		for _, id := range rs.IpPools {
+			if id == poolId {
+				ids = append(ids, rs.Id)
+				break
+			}
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+func ipPoolToMap(pool *ipPoolObject, resourceSetIds []string) map[string]interface{} {
+	allocations := map[string]interface{}{}
+	for address, state := range pool.Addresses {
+		if len(state.Vifs) > 0 {
+			allocations[address] = state.Vifs[0]
+		}
+	}
+	networks := pool.Networks
+	if networks == nil {
+		networks = []string{}
+	}
+	return map[string]interface{}{
+		"name":             pool.Name,
+		"address_ranges":   compressIpPoolAddresses(sortedIpPoolAddresses(pool)),
+		"network_ids":      networks,
+		"allocations":      allocations,
+		"resource_set_ids": resourceSetIds,
+	}
+}
+
+func sortedIpPoolAddresses(pool *ipPoolObject) []string {
+	addresses := make([]string, 0, len(pool.Addresses))
+	for address := range pool.Addresses {
+		addresses = append(addresses, address)
+	}
+	sortIpAddresses(addresses)
+	return addresses
+}
+
+// freeIpPoolAddress returns the first address of the pool that is neither
+// allocated to a VIF nor taken.
+func freeIpPoolAddress(pool *ipPoolObject, taken map[string]bool) (string, error) {
+	for _, address := range sortedIpPoolAddresses(pool) {
+		if len(pool.Addresses[address].Vifs) == 0 && !taken[address] {
+			return address, nil
+		}
+	}
+	return "", fmt.Errorf("ip pool %s (%s) has no free address left", pool.Name, pool.Id)
+}
+
+// pickIpPoolAddresses picks a free address for each of the VM's network
+// blocks that have an ip_pool_id. The addresses are keyed by the index of
+// the network block and are only allocated once the VIFs exist, until then
+// they are reserved and must be released with releaseIpPoolReservations.
+func pickIpPoolAddresses(c client.XOClient, networks []interface{}) (map[int]string, error) {
+	ipPoolReservations.Lock()
+	defer ipPoolReservations.Unlock()
+
+	addresses := map[int]string{}
+	pools := map[string]*ipPoolObject{}
+	taken := map[string]bool{}
+	for index, network := range networks {
+		netMap := network.(map[string]interface{})
+		poolId := netMap["ip_pool_id"].(string)
+		if poolId == "" {
+			continue
+		}
+
+		pool, ok := pools[poolId]
+		if !ok {
+			var err error
+			if pool, err = getIpPoolObject(c, poolId); err != nil {
+				return nil, err
+			}
+			pools[poolId] = pool
+		}
+
+		networkId := netMap["network_id"].(string)
+		available := false
+		for _, id := range pool.Networks {
+			if id == networkId {
+				available = true
+				break
+			}
+		}
+		if !available {
+			return nil, fmt.Errorf("ip pool %s (%s) is not available on network %s of network block %d", pool.Name, pool.Id, networkId, index)
+		}
+
+		for address := range ipPoolReservations.addresses[poolId] {
+			taken[address] = true
+		}
+		address, err := freeIpPoolAddress(pool, taken)
+		if err != nil {
+			return nil, err
+		}
+		taken[address] = true
+		addresses[index] = address
+	}
+
+	for index, address := range addresses {
+		poolId := networks[index].(map[string]interface{})["ip_pool_id"].(string)
+		if ipPoolReservations.addresses[poolId] == nil {
+			ipPoolReservations.addresses[poolId] = map[string]bool{}
+		}
+		ipPoolReservations.addresses[poolId][address] = true
+	}
+	return addresses, nil
+}
+
+// releaseIpPoolReservations releases the addresses reserved by
+// pickIpPoolAddresses once they are allocated or the creation failed.
+func releaseIpPoolReservations(networks []interface{}, addresses map[int]string) {
+	ipPoolReservations.Lock()
+	defer ipPoolReservations.Unlock()
+
+	for index, address := range addresses {
+		poolId := networks[index].(map[string]interface{})["ip_pool_id"].(string)
+		delete(ipPoolReservations.addresses[poolId], address)
+		if len(ipPoolReservations.addresses[poolId]) == 0 {
+			delete(ipPoolReservations.addresses, poolId)
+		}
+	}
+}
+
+// allocateIpPoolAddresses allocates the picked addresses to the VIFs of the
+// network blocks. XO allocates an address from an ip pool when it is set as
+// an allowed address of a VIF on one of the pool's networks. The pools are
+// read again afterwards since another XO client may have allocated the same
+// address in the meantime.
+func allocateIpPoolAddresses(c client.XOClient, vifs []client.VIF, networks []interface{}, addresses map[int]string) error {
+	vifIds := map[int]string{}
+	for index, address := range addresses {
+		vif, err := findVifByDevice(vifs, strconv.Itoa(index))
+		if err != nil {
+			return err
+		}
+		if err := setVifAllowedAddresses(c, vif.Id, []string{address}); err != nil {
+			return fmt.Errorf("failed to allocate ip pool address %s to VIF %s: %w", address, vif.Id, err)
+		}
+		vifIds[index] = vif.Id
+	}
+
+	for index, address := range addresses {
+		poolId := networks[index].(map[string]interface{})["ip_pool_id"].(string)
+		pool, err := getIpPoolObject(c, poolId)
+		if err != nil {
+			return err
+		}
+		if err := checkIpPoolAllocation(pool, address, vifIds[index]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkIpPoolAllocation ensures that the address of the pool is only
+// allocated to the given VIF.
+func checkIpPoolAllocation(pool *ipPoolObject, address, vifId string) error {
+	for _, id := range pool.Addresses[address].Vifs {
+		if id != vifId {
+			return fmt.Errorf("address %s of ip pool %s (%s) was concurrently allocated to VIF %s as well as VIF %s", address, pool.Name, pool.Id, id, vifId)
+		}
+	}
+	return nil
+}
+
+// vifIpPoolAllocations returns the ip_pool_id and ip_pool_address of the
+// network blocks with an allocated address, keyed by the block's index.
+func vifIpPoolAllocations(d *schema.ResourceData) map[int]map[string]interface{} {
+	allocations := map[int]map[string]interface{}{}
+	for index, network := range d.Get("network").([]interface{}) {
+		netMap := network.(map[string]interface{})
+		poolId, _ := netMap["ip_pool_id"].(string)
+		address, _ := netMap["ip_pool_address"].(string)
+		if poolId == "" || address == "" {
+			continue
+		}
+		allocations[index] = map[string]interface{}{
+			"ip_pool_id":      poolId,
+			"ip_pool_address": address,
+		}
+	}
+	return allocations
+}
+
+func setVifAllowedAddresses(c client.XOClient, vifId string, addresses []string) error {
+	ipv4 := []string{}
+	ipv6 := []string{}
+	for _, address := range addresses {
+		if ip := net.ParseIP(address); ip != nil && ip.To4() == nil {
+			ipv6 = append(ipv6, address)
+		} else {
+			ipv4 = append(ipv4, address)
+		}
+	}
+
+	params := map[string]interface{}{
+		"id":                   vifId,
+		"allowedIpv4Addresses": ipv4,
+		"allowedIpv6Addresses": ipv6,
+	}
+	var success bool
+	return callXoApi(c, "vif.set", params, &success)
+}
+
+func findVifByDevice(vifs []client.VIF, device string) (*client.VIF, error) {
+	for i := range vifs {
+		if vifs[i].Device == device {
+			return &vifs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("could not find the VIF of device %s", device)
+}
+
+// substituteIpPoolAddresses replaces the {network.<index>.ip_pool_address}
+// placeholders of a cloud-init config with the allocated addresses.
+func substituteIpPoolAddresses(config string, addresses map[int]string) string {
+	for index, address := range addresses {
+		config = strings.ReplaceAll(config, fmt.Sprintf("{network.%d.ip_pool_address}", index), address)
+	}
+	return config
+}
+
+// ipPoolAddressesPatch returns the `addresses` parameter of `ipPool.set`,
+// which adds the addresses mapped to an object and removes the ones mapped
+// to null.
+func ipPoolAddressesPatch(oldAddresses, newAddresses []string) map[string]interface{} {
+	patch := map[string]interface{}{}
+	for _, address := range oldAddresses {
+		patch[address] = nil
+	}
+	for _, address := range newAddresses {
+		if _, ok := patch[address]; ok {
+			delete(patch, address)
+			continue
+		}
+		patch[address] = map[string]interface{}{}
+	}
+	return patch
+}
+
+func validateIpPoolAddressRange(val interface{}, key string) (warns []string, errs []error) {
+	if _, err := expandIpPoolAddressRange(val.(string)); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", key, err))
+	}
+	return
+}
+
+// expandIpPoolAddressRanges returns the sorted addresses described by the
+// ranges.
+func expandIpPoolAddressRanges(ranges *schema.Set) ([]string, error) {
+	seen := map[string]bool{}
+	addresses := []string{}
+	for _, r := range ranges.List() {
+		expanded, err := expandIpPoolAddressRange(r.(string))
+		if err != nil {
+			return nil, err
+		}
+		for _, address := range expanded {
+			if !seen[address] {
+				seen[address] = true
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	sortIpAddresses(addresses)
+	return addresses, nil
+}
+
+func expandIpPoolAddressRange(r string) ([]string, error) {
+	bounds := strings.SplitN(r, "-", 2)
+	first := net.ParseIP(strings.TrimSpace(bounds[0]))
+	if first == nil {
+		return nil, fmt.Errorf("`%s` is not a valid address or address range", r)
+	}
+	if len(bounds) == 1 {
+		return []string{first.String()}, nil
+	}
+
+	last := net.ParseIP(strings.TrimSpace(bounds[1]))
+	if last == nil || first.To4() == nil || last.To4() == nil {
+		return nil, fmt.Errorf("`%s` is not a valid IPv4 address range", r)
+	}
+	start := binary.BigEndian.Uint32(first.To4())
+	end := binary.BigEndian.Uint32(last.To4())
+	if end < start {
+		return nil, fmt.Errorf("the address range `%s` ends before it starts", r)
+	}
+	if end-start >= maxIpPoolRangeSize {
+		return nil, fmt.Errorf("the address range `%s` is larger than %d addresses", r, maxIpPoolRangeSize)
+	}
+
+	addresses := make([]string, 0, end-start+1)
+	for i := start; ; i++ {
+		ip := make(net.IP, net.IPv4len)
+		binary.BigEndian.PutUint32(ip, i)
+		addresses = append(addresses, ip.String())
+		if i == end {
+			break
+		}
+	}
+	return addresses, nil
+}
+
+// compressIpPoolAddresses turns consecutive IPv4 addresses into ranges, the
+// reverse of expandIpPoolAddressRanges.
+func compressIpPoolAddresses(addresses []string) []string {
+	ranges := []string{}
+	var start, prev uint32
+	inRange := false
+	flush := func() {
+		if !inRange {
+			return
+		}
+		if start == prev {
+			ranges = append(ranges, uint32ToIp(start))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%s-%s", uint32ToIp(start), uint32ToIp(prev)))
+		}
+		inRange = false
+	}
+
+	for _, address := range addresses {
+		ip := net.ParseIP(address).To4()
+		if ip == nil {
+			flush()
+			ranges = append(ranges, address)
+			continue
+		}
+		value := binary.BigEndian.Uint32(ip)
+		if inRange && value == prev+1 {
+			prev = value
+			continue
+		}
+		flush()
+		start, prev, inRange = value, value, true
+	}
+	flush()
+	return ranges
+}
+
+func uint32ToIp(value uint32) string {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, value)
+	return ip.String()
+}
+
+// sortIpAddresses sorts addresses numerically, IPv4 addresses first.
+func sortIpAddresses(addresses []string) {
+	sort.Slice(addresses, func(i, j int) bool {
+		a, b := net.ParseIP(addresses[i]), net.ParseIP(addresses[j])
+		if a == nil || b == nil {
+			return addresses[i] < addresses[j]
+		}
+		if (a.To4() == nil) != (b.To4() == nil) {
+			return a.To4() != nil
+		}
+		return bytes.Compare(a.To16(), b.To16()) < 0
+	})
+}
+
+func setToStringSlice(set *schema.Set) []string {
+	result := make([]string, 0, set.Len())
+	for _, value := range set.List() {
+		result = append(result, value.(string))
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,252 @@
+package xoa
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAccXenorchestraIpPool_createAndUpdate(t *testing.T) {
+	resourceName := "xenorchestra_ip_pool.pool"
+	name := fmt.Sprintf("%s-ip-pool-%s", accTestPrefix, t.Name())
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraIpPoolConfig(name, `"10.100.0.10-10.100.0.20"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, "id"),
+					resource.TestCheckResourceAttr(resourceName, "name", name),
+					resource.TestCheckResourceAttr(resourceName, "address_ranges.#", "1"),
+					resource.TestCheckTypeSetElemAttr(resourceName, "address_ranges.*", "10.100.0.10-10.100.0.20"),
+					resource.TestCheckTypeSetElemAttr(resourceName, "network_ids.*", accDefaultNetwork.Id),
+					resource.TestCheckResourceAttr(resourceName, "allocations.%", "0"),
+				),
+			},
+			{
+				Config: testAccXenorchestraIpPoolConfig(name+"-updated", `"10.100.0.10-10.100.0.15", "10.100.0.30"`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name", name+"-updated"),
+					resource.TestCheckResourceAttr(resourceName, "address_ranges.#", "2"),
+					resource.TestCheckTypeSetElemAttr(resourceName, "address_ranges.*", "10.100.0.30"),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccXenorchestraIpPool_vmAllocation(t *testing.T) {
+	vmName := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	poolName := fmt.Sprintf("%s-ip-pool-%s", accTestPrefix, t.Name())
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckXenorchestraVmDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVmConfigWithIpPool(vmName, poolName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("xenorchestra_vm.bar", "network.0.ip_pool_id", "xenorchestra_ip_pool.pool", "id"),
+					resource.TestCheckResourceAttr("xenorchestra_vm.bar", "network.0.ip_pool_address", "10.100.1.10"),
+				),
+			},
+			{
+				// The pool is refreshed after the VM allocated its address
+				Config: testAccVmConfigWithIpPool(vmName, poolName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("xenorchestra_ip_pool.pool", "allocations.%", "1"),
+				),
+			},
+		},
+	})
+}
+
+func testAccXenorchestraIpPoolConfig(name, ranges string) string {
+	return fmt.Sprintf(`
+resource "xenorchestra_ip_pool" "pool" {
+    name = "%s"
+    address_ranges = [%s]
+    network_ids = ["%s"]
+}
+`, name, ranges, accDefaultNetwork.Id)
+}
+
+func testAccVmConfigWithIpPool(vmName, poolName string) string {
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
+data "xenorchestra_network" "network" {
+    name_label = "%s"
+    pool_id = "%s"
+}
+
+resource "xenorchestra_ip_pool" "pool" {
+    name = "%s"
+    address_ranges = ["10.100.1.10-10.100.1.12"]
+    network_ids = [data.xenorchestra_network.network.id]
+}
+
+resource "xenorchestra_vm" "bar" {
+    memory_max = 4295000000
+    cpus  = 1
+    cloud_config = xenorchestra_cloud_config.bar.template
+    cloud_network_config = <<EOF
+network:
+  version: 1
+  config:
+    - type: physical
+      name: eth0
+      subnets:
+        - type: static
+          address: {network.0.ip_pool_address}/24
+EOF
+    name_label = "%s"
+    name_description = "description"
+    template = data.xenorchestra_template.template.id
+    network {
+	network_id = data.xenorchestra_network.network.id
+	ip_pool_id = xenorchestra_ip_pool.pool.id
+    }
+
+    disk {
+      sr_id = "%s"
+      name_label = "disk 1"
+      size = 10001317888
+    }
+}
+`, accDefaultNetwork.NameLabel, accTestPool.Id, poolName, vmName, accDefaultSr.Id)
+}
+
+func Test_expandIpPoolAddressRanges(t *testing.T) {
+	ranges := schema.NewSet(schema.HashString, []interface{}{
+		"10.0.0.254-10.0.1.1",
+		"10.0.0.2",
+		"10.0.0.255",
+		"fd00::1",
+	})
+
+	expected := []string{"10.0.0.2", "10.0.0.254", "10.0.0.255", "10.0.1.0", "10.0.1.1", "fd00::1"}
+	addresses, err := expandIpPoolAddressRanges(ranges)
+	if err != nil {
+		t.Fatalf("failed to expand ranges: %v", err)
+	}
+	if !reflect.DeepEqual(addresses, expected) {
+		t.Errorf("expected %v, received %v", expected, addresses)
+	}
+}
+
+func Test_expandIpPoolAddressRangeErrors(t *testing.T) {
+	for _, r := range []string{
+		"not an address",
+		"10.0.0.5-10.0.0.1",
+		"10.0.0.1-fd00::1",
+		"fd00::1-fd00::5",
+		"10.0.0.0-10.1.0.0",
+	} {
+		if _, err := expandIpPoolAddressRange(r); err == nil {
+			t.Errorf("expected `%s` to be rejected", r)
+		}
+	}
+}
+
+func Test_compressIpPoolAddresses(t *testing.T) {
+	addresses := []string{"10.0.0.2", "10.0.0.254", "10.0.0.255", "10.0.1.0", "10.0.1.1", "10.0.1.3", "fd00::1"}
+
+	expected := []string{"10.0.0.2", "10.0.0.254-10.0.1.1", "10.0.1.3", "fd00::1"}
+	if ranges := compressIpPoolAddresses(addresses); !reflect.DeepEqual(ranges, expected) {
+		t.Errorf("expected %v, received %v", expected, ranges)
+	}
+}
+
+func Test_ipPoolAddressesPatch(t *testing.T) {
+	expected := map[string]interface{}{
+		"10.0.0.1": nil,
+		"10.0.0.3": map[string]interface{}{},
+	}
+	patch := ipPoolAddressesPatch([]string{"10.0.0.1", "10.0.0.2"}, []string{"10.0.0.2", "10.0.0.3"})
+	if !reflect.DeepEqual(patch, expected) {
+		t.Errorf("expected %v, received %v", expected, patch)
+	}
+}
+
+func Test_freeIpPoolAddress(t *testing.T) {
+	pool := &ipPoolObject{
+		Id:   "pool id",
+		Name: "pool",
+		Addresses: map[string]ipPoolAddress{
+			"10.0.0.10": {},
+			"10.0.0.2":  {Vifs: []string{"vif"}},
+			"10.0.0.3":  {},
+		},
+	}
+
+	address, err := freeIpPoolAddress(pool, map[string]bool{})
+	if err != nil || address != "10.0.0.3" {
+		t.Errorf("expected 10.0.0.3 to be free, received %s (%v)", address, err)
+	}
+
+	address, err = freeIpPoolAddress(pool, map[string]bool{"10.0.0.3": true})
+	if err != nil || address != "10.0.0.10" {
+		t.Errorf("expected 10.0.0.10 to be free, received %s (%v)", address, err)
+	}
+
+	if _, err := freeIpPoolAddress(pool, map[string]bool{"10.0.0.3": true, "10.0.0.10": true}); err == nil {
+		t.Errorf("expected the pool to be exhausted")
+	}
+}
+
+func Test_checkIpPoolAllocation(t *testing.T) {
+	pool := &ipPoolObject{
+		Id:   "pool id",
+		Name: "pool",
+		Addresses: map[string]ipPoolAddress{
+			"10.0.0.2": {Vifs: []string{"vif"}},
+			"10.0.0.3": {Vifs: []string{"other vif", "vif"}},
+		},
+	}
+
+	if err := checkIpPoolAllocation(pool, "10.0.0.2", "vif"); err != nil {
+		t.Errorf("expected 10.0.0.2 to only be allocated to vif, received error: %v", err)
+	}
+	if err := checkIpPoolAllocation(pool, "10.0.0.3", "vif"); err == nil {
+		t.Errorf("expected an error for an address allocated to another VIF")
+	}
+}
+
+func Test_releaseIpPoolReservations(t *testing.T) {
+	networks := []interface{}{
+		map[string]interface{}{"ip_pool_id": "pool 1"},
+		map[string]interface{}{"ip_pool_id": ""},
+		map[string]interface{}{"ip_pool_id": "pool 2"},
+	}
+	ipPoolReservations.addresses["pool 1"] = map[string]bool{"10.0.0.2": true, "10.0.0.3": true}
+	ipPoolReservations.addresses["pool 2"] = map[string]bool{"10.0.1.2": true}
+	defer func() {
+		delete(ipPoolReservations.addresses, "pool 1")
+		delete(ipPoolReservations.addresses, "pool 2")
+	}()
+
+	releaseIpPoolReservations(networks, map[int]string{0: "10.0.0.2", 2: "10.0.1.2"})
+
+	expected := map[string]map[string]bool{"pool 1": {"10.0.0.3": true}}
+	if !reflect.DeepEqual(ipPoolReservations.addresses, expected) {
+		t.Errorf("expected the reservations %v to be left, received %v", expected, ipPoolReservations.addresses)
+	}
+}
+
+func Test_substituteIpPoolAddresses(t *testing.T) {
+	config := "address: {network.0.ip_pool_address}/24\nother: {network.1.ip_pool_address}\nunknown: {network.2.ip_pool_address}"
+
+	expected := "address: 10.0.0.2/24\nother: 10.0.1.2\nunknown: {network.2.ip_pool_address}"
+	result := substituteIpPoolAddresses(config, map[int]string{0: "10.0.0.2", 1: "10.0.1.2"})
+	if result != expected {
+		t.Errorf("expected %q, received %q", expected, result)
+	}
+}
@@ -3,6 +3,8 @@ package xoa
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -18,6 +20,7 @@ func resourceXoaNetwork() *schema.Resource {
 		DeleteContext: resourceNetworkDeleteContext,
 		ReadContext:   resourceNetworkReadContext,
 		UpdateContext: resourceNetworkUpdateContext,
+		CustomizeDiff: networkCustomizeDiff,
 		Importer: &schema.ResourceImporter{
 			State: schema.ImportStatePassthrough,
 		},
@@ -49,18 +52,34 @@ func resourceXoaNetwork() *schema.Resource {
 				RequiredWith: []string{
 					"vlan",
 				},
-				ForceNew:    true,
-				Description: "The PIF device (eth0, eth1, etc) that will be used as an input during network creation. This parameter is required if a vlan is specified.",
+				ConflictsWith: []string{"source_pif_id", "source_network_id"},
+				ForceNew:      true,
+				Description:   "The PIF device (eth0, eth1, bond0, etc) of the pool's primary host that will be used as an input during network creation. One of `source_pif_device`, `source_pif_id` or `source_network_id` is required if a vlan is specified.",
 			},
-			"vlan": &schema.Schema{
-				Type:     schema.TypeInt,
+			"source_pif_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+				RequiredWith: []string{
+					"vlan",
+				},
+				ConflictsWith: []string{"source_network_id"},
+				ForceNew:      true,
+				Description:   "The id of the PIF the VLAN is created on, for example a bond master PIF. Every host of the pool must have a PIF with the same device.",
+			},
+			"source_network_id": &schema.Schema{
+				Type:     schema.TypeString,
 				Optional: true,
-				Default:  0,
 				RequiredWith: []string{
-					"source_pif_device",
+					"vlan",
 				},
 				ForceNew:    true,
-				Description: "The vlan to use for the network. Defaults to `0` meaning no VLAN.",
+				Description: "The id of the network the VLAN is created on, for example a `xenorchestra_bonded_network`. Every host of the pool must have a PIF on that network.",
+			},
+			"vlan": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     0,
+				Description: "The vlan to use for the network. Defaults to `0` meaning no VLAN. Changing between two VLANs is done in place while adding or removing the VLAN recreates the network.",
 			},
 			"pool_id": &schema.Schema{
 				Type:        schema.TypeString,
@@ -72,8 +91,13 @@ func resourceXoaNetwork() *schema.Resource {
 				Type:        schema.TypeInt,
 				Optional:    true,
 				Default:     1500,
-				ForceNew:    true,
-				Description: "The MTU of the network. Defaults to `1500` if unspecified.",
+				Description: "The MTU of the network. Defaults to `1500` if unspecified. Changing it recreates the network unless `allow_pif_replug` is `true`.",
+			},
+			"allow_pif_replug": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Declares a maintenance window during which the network's PIFs may be unplugged and plugged again to apply an `mtu` change in place. This briefly interrupts the traffic of every VIF on the network. Networks carrying a management interface cannot be replugged.",
 			},
 			"nbd": &schema.Schema{
 				Type:        schema.TypeBool,
@@ -81,21 +105,94 @@ func resourceXoaNetwork() *schema.Resource {
 				Default:     false,
 				Description: "Whether the network should use a network block device. Defaults to `false` if unspecified.",
 			},
+			"host_pifs": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The network's PIF on each host of the pool, sorted by host id.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"host_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"pif_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"device": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"vlan": &schema.Schema{
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"attached": &schema.Schema{
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
 		},
 	}
 }
 
+func networkCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, v interface{}) error {
+	if diff.Get("vlan").(int) != 0 {
+		hasSource := false
+		for _, attr := range []string{"source_pif_device", "source_pif_id", "source_network_id"} {
+			if !diff.NewValueKnown(attr) || diff.Get(attr).(string) != "" {
+				hasSource = true
+			}
+		}
+		if !hasSource {
+			return fmt.Errorf("one of `source_pif_device,source_pif_id,source_network_id` must be specified when vlan is set")
+		}
+	}
+
+	// XO can only move a network between VLANs, the untagged network
+	// and its PIFs have to be recreated.
+	if diff.Id() != "" && diff.HasChange("vlan") {
+		oldVlan, newVlan := diff.GetChange("vlan")
+		if oldVlan.(int) == 0 || newVlan.(int) == 0 {
+			if err := diff.ForceNew("vlan"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if diff.Id() != "" && diff.HasChange("mtu") && !diff.Get("allow_pif_replug").(bool) {
+		if err := diff.ForceNew("mtu"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func resourceNetworkCreateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(client.XOClient)
 
-	var pifId string
+	poolId := d.Get("pool_id").(string)
+	var sourcePIF *client.PIF
+	var err error
 	if sourcePIFDevice := d.Get("source_pif_device").(string); sourcePIFDevice != "" {
-		pif, err := getNetworkCreationSourcePIF(c, sourcePIFDevice, d.Get("pool_id").(string))
+		sourcePIF, err = getNetworkCreationSourcePIF(c, sourcePIFDevice, poolId)
+	} else if sourcePIFId := d.Get("source_pif_id").(string); sourcePIFId != "" {
+		sourcePIF, err = getNetworkCreationSourcePIFById(c, sourcePIFId, poolId)
+	} else if sourceNetworkId := d.Get("source_network_id").(string); sourceNetworkId != "" {
+		sourcePIF, err = getNetworkCreationSourcePIFByNetwork(c, sourceNetworkId, poolId)
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
 
-		if err != nil {
+	var pifId string
+	if sourcePIF != nil {
+		if err := validateNetworkCreationSourcePIF(c, sourcePIF, poolId); err != nil {
 			return diag.FromErr(err)
 		}
-		pifId = pif.Id
+		pifId = sourcePIF.Id
 	}
 
 	network, err := c.CreateNetwork(client.CreateNetworkRequest{
@@ -112,11 +209,11 @@ func resourceNetworkCreateContext(ctx context.Context, d *schema.ResourceData, m
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	vlan, pifDevice, err := getVlanForNetwork(c, network)
+	pifs, err := getNetworkPIFs(c, network)
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	return diag.FromErr(networkToData(ctx, network, vlan, pifDevice, d))
+	return diag.FromErr(networkToData(ctx, network, pifs, d))
 }
 
 // This function returns the PIF specified the given device name on the pool's primary host. In order to create
@@ -150,20 +247,140 @@ func getNetworkCreationSourcePIF(c client.XOClient, pifDevice, poolId string) (*
 	return &pifs[0], nil
 }
 
-// Returns the VLAN and device name for the given network.
-func getVlanForNetwork(c client.XOClient, net *client.Network) (int, string, error) {
-	if len(net.PIFs) > 0 {
-		pifs, err := c.GetPIF(client.PIF{Id: net.PIFs[0]})
+// Returns the given PIF after checking that it belongs to the pool.
+func getNetworkCreationSourcePIFById(c client.XOClient, pifId, poolId string) (*client.PIF, error) {
+	pifs, err := c.GetPIF(client.PIF{Id: pifId})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pifs) != 1 {
+		return nil, fmt.Errorf("expected to find a single PIF with id %s, instead found %d", pifId, len(pifs))
+	}
+
+	if pifs[0].PoolId != poolId {
+		return nil, fmt.Errorf("PIF %s belongs to pool %s rather than pool %s", pifId, pifs[0].PoolId, poolId)
+	}
+	return &pifs[0], nil
+}
+
+// Returns the source network's PIF on the pool's primary host, which is the
+// PIF Xen Orchestra would use when creating the VLAN from the network.
+func getNetworkCreationSourcePIFByNetwork(c client.XOClient, networkId, poolId string) (*client.PIF, error) {
+	network, err := c.GetNetwork(client.Network{Id: networkId})
+	if err != nil {
+		return nil, err
+	}
+
+	if network.PoolId != poolId {
+		return nil, fmt.Errorf("network %s belongs to pool %s rather than pool %s", networkId, network.PoolId, poolId)
+	}
+
+	pools, err := c.GetPools(client.Pool{Id: poolId})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pools) != 1 {
+		return nil, fmt.Errorf("expected to find a single pool, instead found %d", len(pools))
+	}
+
+	pifs, err := getNetworkPIFs(c, network)
+	if err != nil {
+		return nil, err
+	}
+	for _, pif := range pifs {
+		if pif.Host == pools[0].Master {
+			return &pif, nil
+		}
+	}
+	return nil, fmt.Errorf("network %s has no PIF on the pool's primary host %s", networkId, pools[0].Master)
+}
+
+// XAPI creates a VLAN on every host's PIF that has the same device as the
+// source PIF. This function ensures that every host of the pool has such a
+// PIF, so that hosts whose NICs are named differently are reported rather
+// than silently left without the VLAN.
+func validateNetworkCreationSourcePIF(c client.XOClient, sourcePIF *client.PIF, poolId string) error {
+	hosts, err := c.GetSortedHosts(client.Host{Pool: poolId}, "", "")
+	if err != nil {
+		return err
+	}
+
+	pifs := map[string]client.PIF{}
+	filter := map[string]interface{}{
+		"type":    "PIF",
+		"$poolId": poolId,
+	}
+	if err := getXoObjects(c, filter, &pifs); err != nil {
+		return err
+	}
+
+	hostsWithPIF := map[string]bool{}
+	for _, pif := range pifs {
+		if pif.Device == sourcePIF.Device && pif.Vlan == sourcePIF.Vlan {
+			hostsWithPIF[pif.Host] = true
+		}
+	}
+
+	missing := []string{}
+	for _, host := range hosts {
+		if !hostsWithPIF[host.Id] {
+			missing = append(missing, fmt.Sprintf("%s (%s)", host.NameLabel, host.Id))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("the following hosts have no PIF with device %s to create the VLAN on: %s", sourcePIF.Device, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Returns the PIFs of the given network, one per host, sorted by host id.
+func getNetworkPIFs(c client.XOClient, net *client.Network) ([]client.PIF, error) {
+	pifs := make([]client.PIF, 0, len(net.PIFs))
+	for _, pifId := range net.PIFs {
+		found, err := c.GetPIF(client.PIF{Id: pifId})
 		if err != nil {
-			return -1, "", err
+			return nil, err
 		}
 
-		if len(pifs) != 1 {
-			return -1, "", fmt.Errorf("expected to find single PIF")
+		if len(found) != 1 {
+			return nil, fmt.Errorf("expected to find single PIF")
 		}
-		return pifs[0].Vlan, pifs[0].Device, nil
+		pifs = append(pifs, found[0])
 	}
-	return 0, "", nil
+	sort.Slice(pifs, func(i, j int) bool {
+		return pifs[i].Host < pifs[j].Host
+	})
+	return pifs, nil
+}
+
+// Returns the VLAN and device name for the given network. Every host's PIF
+// of a network shares them so the network's first PIF is used.
+func getVlanForNetwork(net *client.Network, pifs []client.PIF) (int, string) {
+	if len(net.PIFs) == 0 {
+		return 0, ""
+	}
+	for _, pif := range pifs {
+		if pif.Id == net.PIFs[0] {
+			return pif.Vlan, pif.Device
+		}
+	}
+	return pifs[0].Vlan, pifs[0].Device
+}
+
+func networkHostPIFsToMapList(pifs []client.PIF) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(pifs))
+	for _, pif := range pifs {
+		result = append(result, map[string]interface{}{
+			"host_id":  pif.Host,
+			"pif_id":   pif.Id,
+			"device":   pif.Device,
+			"vlan":     pif.Vlan,
+			"attached": pif.Attached,
+		})
+	}
+	return result
 }
 
 func resourceNetworkReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -180,11 +397,11 @@ func resourceNetworkReadContext(ctx context.Context, d *schema.ResourceData, m i
 		return diag.FromErr(err)
 	}
 
-	vlan, pifDevice, err := getVlanForNetwork(c, net)
+	pifs, err := getNetworkPIFs(c, net)
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	return diag.FromErr(networkToData(ctx, net, vlan, pifDevice, d))
+	return diag.FromErr(networkToData(ctx, net, pifs, d))
 }
 
 func resourceNetworkUpdateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -211,9 +428,85 @@ func resourceNetworkUpdateContext(ctx context.Context, d *schema.ResourceData, m
 	if err != nil {
 		return diag.FromErr(err)
 	}
+
+	if d.HasChange("vlan") {
+		if err := updateNetworkVlan(c, d.Id(), d.Get("vlan").(int)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if d.HasChange("mtu") {
+		if err := updateNetworkMtu(ctx, c, d.Id(), d.Get("mtu").(int)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	return resourceNetworkReadContext(ctx, d, m)
 }
 
+// updateNetworkVlan moves the network's PIFs on every host to the given VLAN.
+func updateNetworkVlan(c client.XOClient, networkId string, vlan int) error {
+	net, err := c.GetNetwork(client.Network{Id: networkId})
+	if err != nil {
+		return err
+	}
+	if len(net.PIFs) == 0 {
+		return fmt.Errorf("network %s has no PIF to change the VLAN of", networkId)
+	}
+
+	var success bool
+	params := map[string]interface{}{
+		"pif":  net.PIFs[0],
+		"vlan": vlan,
+	}
+	if err := callXoApi(c, "pif.editPif", params, &success); err != nil {
+		return fmt.Errorf("failed to change the VLAN of network %s: %w", networkId, err)
+	}
+	return nil
+}
+
+// updateNetworkMtu sets the network's MTU and replugs its PIFs since XAPI
+// only applies the MTU when a PIF is plugged.
+func updateNetworkMtu(ctx context.Context, c client.XOClient, networkId string, mtu int) error {
+	net, err := c.GetNetwork(client.Network{Id: networkId})
+	if err != nil {
+		return err
+	}
+	pifs, err := getNetworkPIFs(c, net)
+	if err != nil {
+		return err
+	}
+	for _, pif := range pifs {
+		if pif.Management {
+			return fmt.Errorf("cannot change the MTU of network %s in place since PIF %s on host %s is a management interface", networkId, pif.Id, pif.Host)
+		}
+	}
+
+	var success bool
+	params := map[string]interface{}{
+		"id":  networkId,
+		"mtu": mtu,
+	}
+	if err := callXoApi(c, "network.set", params, &success); err != nil {
+		return fmt.Errorf("failed to set the MTU of network %s: %w", networkId, err)
+	}
+
+	for _, pif := range pifs {
+		if !pif.Attached {
+			continue
+		}
+		tflog.Debug(ctx, "Replugging PIF to apply MTU", map[string]interface{}{
+			"pif_id":  pif.Id,
+			"host_id": pif.Host,
+		})
+		if err := callXoApi(c, "pif.disconnect", map[string]interface{}{"pif": pif.Id}, &success); err != nil {
+			return fmt.Errorf("failed to unplug PIF %s: %w", pif.Id, err)
+		}
+		if err := callXoApi(c, "pif.connect", map[string]interface{}{"pif": pif.Id}, &success); err != nil {
+			return fmt.Errorf("failed to plug PIF %s back: %w", pif.Id, err)
+		}
+	}
+	return nil
+}
+
 func resourceNetworkDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(client.XOClient)
 
@@ -226,7 +519,8 @@ func resourceNetworkDeleteContext(ctx context.Context, d *schema.ResourceData, m
 	return nil
 }
 
-func networkToData(ctx context.Context, network *client.Network, vlan int, pifDevice string, d *schema.ResourceData) error {
+func networkToData(ctx context.Context, network *client.Network, pifs []client.PIF, d *schema.ResourceData) error {
+	vlan, pifDevice := getVlanForNetwork(network, pifs)
 	d.SetId(network.Id)
 	if err := d.Set("name_label", network.NameLabel); err != nil {
 		return err
@@ -253,7 +547,14 @@ func networkToData(ctx context.Context, network *client.Network, vlan int, pifDe
 	if err := d.Set("vlan", vlan); err != nil {
 		return err
 	}
-	if err := d.Set("source_pif_device", pifDevice); err != nil {
+	// The device is only reported when the VLAN was not created from a PIF
+	// or network id, otherwise it would conflict with them.
+	if d.Get("source_pif_id").(string) == "" && d.Get("source_network_id").(string) == "" {
+		if err := d.Set("source_pif_device", pifDevice); err != nil {
+			return err
+		}
+	}
+	if err := d.Set("host_pifs", networkHostPIFsToMapList(pifs)); err != nil {
 		return err
 	}
 	return nil
@@ -86,7 +86,7 @@ func TestAccXONetwork_createWithVlanRequiresPIF(t *testing.T) {
 			},
 			{
 				Config:      testAccXenorchestraNetworkConfigWithoutPIF(nameLabel),
-				ExpectError: regexp.MustCompile("all of `source_pif_device,vlan` must be specified"),
+				ExpectError: regexp.MustCompile("one of `source_pif_device,source_pif_id,source_network_id` must be specified when vlan is set"),
 			},
 		},
 	},
@@ -214,6 +214,107 @@ func TestAccXONetwork_updateForceNew(t *testing.T) {
 	)
 }
 
+func TestAccXONetwork_updateVlanAndMtuInPlace(t *testing.T) {
+	if accTestPIF.Id == "" {
+		t.Skip()
+	}
+	resourceName := "xenorchestra_network.network"
+	nameLabel := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	var networkId string
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraNetworkConfigWithPIFReplug(nameLabel, "950", "22"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckXenorchestraNetwork(resourceName),
+					testAccCheckXenorchestraNetworkId(resourceName, &networkId, false),
+					resource.TestCheckResourceAttr(resourceName, "mtu", "950"),
+					resource.TestCheckResourceAttr(resourceName, "vlan", "22"),
+					resource.TestCheckResourceAttrSet(resourceName, "host_pifs.0.host_id"),
+					resource.TestCheckResourceAttrSet(resourceName, "host_pifs.0.pif_id"),
+					resource.TestCheckResourceAttr(resourceName, "host_pifs.0.device", "eth0"),
+					resource.TestCheckResourceAttr(resourceName, "host_pifs.0.vlan", "22")),
+			},
+			{
+				Config: testAccXenorchestraNetworkConfigWithPIFReplug(nameLabel, "1000", "23"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckXenorchestraNetworkId(resourceName, &networkId, true),
+					resource.TestCheckResourceAttr(resourceName, "mtu", "1000"),
+					resource.TestCheckResourceAttr(resourceName, "vlan", "23"),
+					resource.TestCheckResourceAttr(resourceName, "host_pifs.0.vlan", "23")),
+			},
+		},
+	},
+	)
+}
+
+func TestAccXONetwork_createVlanFromPIFId(t *testing.T) {
+	if accTestPIF.Id == "" {
+		t.Skip()
+	}
+	resourceName := "xenorchestra_network.network"
+	nameLabel := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraNetworkConfigVlanFromPIFId(nameLabel),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckXenorchestraNetwork(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "source_pif_id", accTestPIF.Id),
+					resource.TestCheckResourceAttr(resourceName, "source_pif_device", ""),
+					resource.TestCheckResourceAttr(resourceName, "vlan", "24"),
+					resource.TestCheckResourceAttr(resourceName, "host_pifs.0.device", accTestPIF.Device)),
+			},
+		},
+	},
+	)
+}
+
+func TestAccXONetwork_createVlanOnBondedNetwork(t *testing.T) {
+	if accTestPIF.Id == "" {
+		t.Skip()
+	}
+	resourceName := "xenorchestra_network.network"
+	nameLabel := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraNetworkConfigVlanOnBond(nameLabel),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccCheckXenorchestraNetwork(resourceName),
+					resource.TestCheckResourceAttrPair(resourceName, "source_network_id", "xenorchestra_bonded_network.bond", "id"),
+					resource.TestCheckResourceAttr(resourceName, "vlan", "25"),
+					resource.TestCheckResourceAttrSet(resourceName, "host_pifs.0.pif_id")),
+			},
+		},
+	},
+	)
+}
+
+// testAccCheckXenorchestraNetworkId records the network's id or, when
+// unchanged is set, verifies that the network was not recreated.
+func testAccCheckXenorchestraNetworkId(n string, id *string, unchanged bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[n]
+		if !ok {
+			return fmt.Errorf("Can't find Network resource: %s", n)
+		}
+
+		if unchanged && rs.Primary.ID != *id {
+			return fmt.Errorf("expected network %s to be updated in place, it was recreated as %s", *id, rs.Primary.ID)
+		}
+		*id = rs.Primary.ID
+		return nil
+	}
+}
+
 func testAccCheckXenorchestraNetwork(n string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[n]
@@ -295,3 +396,48 @@ resource "xenorchestra_network" "network" {
 }
 `, name, desc, accTestPIF.PoolId, nbd, automatic, isLocked)
 }
+
+var testAccXenorchestraNetworkConfigWithPIFReplug = func(name, mtu, vlan string) string {
+	return fmt.Sprintf(`
+resource "xenorchestra_network" "network" {
+    name_label = "%s"
+    pool_id = "%s"
+    mtu = %s
+    source_pif_device = "eth0"
+    vlan = %s
+    allow_pif_replug = true
+}
+`, name, accTestPIF.PoolId, mtu, vlan)
+}
+
+var testAccXenorchestraNetworkConfigVlanFromPIFId = func(name string) string {
+	return fmt.Sprintf(`
+resource "xenorchestra_network" "network" {
+    name_label = "%s"
+    pool_id = "%s"
+    source_pif_id = "%s"
+    vlan = 24
+}
+`, name, accTestPIF.PoolId, accTestPIF.Id)
+}
+
+var testAccXenorchestraNetworkConfigVlanOnBond = func(name string) string {
+	return testAccXenorchestraBondedNetworkPIFs() + fmt.Sprintf(`
+resource "xenorchestra_bonded_network" "bond" {
+    name_label = "%s - bond"
+    pool_id = "%s"
+    pif_ids = [
+      data.xenorchestra_pif.eth1.id,
+      data.xenorchestra_pif.eth2.id,
+    ]
+    bond_mode = "active-backup"
+}
+
+resource "xenorchestra_network" "network" {
+    name_label = "%s"
+    pool_id = "%s"
+    source_network_id = xenorchestra_bonded_network.bond.id
+    vlan = 25
+}
+`, name, accTestPIF.PoolId, name, accTestPIF.PoolId)
+}
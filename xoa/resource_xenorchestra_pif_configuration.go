@@ -0,0 +1,441 @@
+package xoa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+// The IPv4 configuration modes of a PIF and their XAPI equivalent
+var pifIpModes = map[string]string{
+	"none":   "None",
+	"dhcp":   "DHCP",
+	"static": "Static",
+}
+
+// The IPv6 configuration modes of a PIF and their XAPI equivalent
+var pifIpv6Modes = map[string]string{
+	"none":     "None",
+	"dhcp":     "DHCP",
+	"static":   "Static",
+	"autoconf": "Autoconf",
+}
+
+var pifStaticIpAttributes = []string{"ipv4_address", "ipv4_netmask", "ipv4_gateway", "dns"}
+
+var pifStaticIpv6Attributes = []string{"ipv6_address", "ipv6_gateway"}
+
+// pifObject is a PIF along with its IP configuration, which the SDK's
+// type does not expose.
+type pifObject struct {
+	client.PIF
+	Mode           string   `json:"mode"`
+	Ip             string   `json:"ip"`
+	Netmask        string   `json:"netmask"`
+	Gateway        string   `json:"gateway"`
+	Dns            string   `json:"dns"`
+	Ipv6Mode       string   `json:"ipv6Mode"`
+	Ipv6           []string `json:"ipv6"`
+	DisallowUnplug bool     `json:"disallowUnplug"`
+	Carrier        bool     `json:"carrier"`
+}
+
+func resourcePifConfiguration() *schema.Resource {
+	return &schema.Resource{
+		Description: `Manages the IP configuration of a host's PIF, for example to give each host a static address on a storage or migration network.
+
+**Note:** Destroying this resource only removes it from the terraform state, the PIF keeps its IP configuration and flags. The IPv6 configuration, ` + "`management`" + ` and ` + "`disallow_unplug`" + ` are only changed when they are set, otherwise they are reported as they are.`,
+		CreateContext: resourcePifConfigurationCreateContext,
+		ReadContext:   resourcePifConfigurationReadContext,
+		UpdateContext: resourcePifConfigurationUpdateContext,
+		DeleteContext: resourcePifConfigurationDeleteContext,
+		CustomizeDiff: pifConfigurationCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+		Schema: map[string]*schema.Schema{
+			"pif_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The id of the PIF to configure.",
+			},
+			"mode": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice([]string{"none", "dhcp", "static"}, false),
+				Description:  "The IPv4 configuration mode of the PIF, one of `none`, `dhcp` or `static`.",
+			},
+			"ipv4_address": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsIPv4Address,
+				Description:  "The static IPv4 address of the PIF. Required when `mode` is `static`.",
+			},
+			"ipv4_netmask": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsIPv4Address,
+				Description:  "The netmask of the static IPv4 address, e.g. `255.255.255.0`. Required when `mode` is `static`.",
+			},
+			"ipv4_gateway": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsIPv4Address,
+				Description:  "The IPv4 gateway of the PIF when `mode` is `static`.",
+			},
+			"dns": &schema.Schema{
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "The DNS servers of the PIF when `mode` is `static`.",
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.IsIPAddress,
+				},
+			},
+			"allow_management_reconfiguration": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Confirms that the PIF may be reconfigured when it is its host's management interface. A wrong configuration makes the host unreachable from Xen Orchestra.",
+			},
+			"current_ipv4_address": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The IPv4 address currently assigned to the PIF, including addresses leased through DHCP.",
+			},
+			"ipv6_mode": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice([]string{"none", "dhcp", "static", "autoconf"}, false),
+				Description:  "The IPv6 configuration mode of the PIF, one of `none`, `dhcp`, `static` or `autoconf`. The current mode is kept when unspecified.",
+			},
+			"ipv6_address": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IsCIDR,
+				Description:  "The static IPv6 address of the PIF in CIDR notation, e.g. `fd00::10/64`. Required when `ipv6_mode` is `static`.",
+			},
+			"ipv6_gateway": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsIPv6Address,
+				Description:  "The IPv6 gateway of the PIF when `ipv6_mode` is `static`. Xen Orchestra does not report it, so it is only applied when the IPv6 configuration changes.",
+			},
+			"ipv6_addresses": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The IPv6 addresses of the PIF.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"management": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: "Whether the PIF is its host's management interface. Setting it to `true` moves the host's management interface to this PIF and requires `allow_management_reconfiguration`. It cannot be set to `false`, set it on the new management PIF instead.",
+			},
+			"disallow_unplug": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: "Whether XAPI prevents the PIF from being unplugged, which is the case for management and storage interfaces. The current value is kept when unspecified.",
+			},
+			"attached": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "If the PIF is attached to the network.",
+			},
+			"device": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The name of the network device.",
+			},
+			"host_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The ID of the host that the PIF belongs to.",
+			},
+			"network_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The ID of the network the PIF belongs to.",
+			},
+		},
+	}
+}
+
+func pifConfigurationCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, v interface{}) error {
+	if err := validatePifStaticAttributes(diff, "mode", []string{"ipv4_address", "ipv4_netmask"}, pifStaticIpAttributes); err != nil {
+		return err
+	}
+	if err := validatePifStaticAttributes(diff, "ipv6_mode", []string{"ipv6_address"}, pifStaticIpv6Attributes); err != nil {
+		return err
+	}
+
+	if diff.Id() != "" && diff.HasChange("management") {
+		if !diff.Get("management").(bool) {
+			return errors.New("management cannot be set to `false`, set management on the PIF that should become the management interface instead")
+		}
+		if !diff.Get("allow_management_reconfiguration").(bool) {
+			return errors.New("set allow_management_reconfiguration to `true` to move the management interface")
+		}
+	}
+	return nil
+}
+
+// validatePifStaticAttributes ensures that the required attributes are set
+// when modeKey is `static` and that the static attributes are only set then.
+func validatePifStaticAttributes(diff *schema.ResourceDiff, modeKey string, required, static []string) error {
+	if diff.Get(modeKey).(string) == "static" {
+		for _, attr := range required {
+			if diff.NewValueKnown(attr) && diff.Get(attr).(string) == "" {
+				return fmt.Errorf("%s must be set when %s is `static`", attr, modeKey)
+			}
+		}
+		return nil
+	}
+
+	for _, attr := range static {
+		// Computed attributes keep their previous value when unset
+		if value, ok := diff.GetOk(attr); ok && value != nil && !diff.GetRawConfig().GetAttr(attr).IsNull() {
+			return fmt.Errorf("%s can only be set when %s is `static`", attr, modeKey)
+		}
+	}
+	return nil
+}
+
+func resourcePifConfigurationCreateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	pifId := d.Get("pif_id").(string)
+	pif, err := getPifObject(c, pifId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := reconfigurePifIp(ctx, c, pif, d); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := updatePifSettings(ctx, c, pif, d); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(pifId)
+	return resourcePifConfigurationReadContext(ctx, d, m)
+}
+
+func resourcePifConfigurationReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	pif, err := getPifObject(c, d.Id())
+	if _, ok := err.(client.NotFound); ok {
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	data := pifConfigurationToMap(pif)
+	data["ipv6_address"] = staticIpv6Address(data["ipv6_mode"].(string), d.Get("ipv6_address").(string), pif.Ipv6)
+	for key, value := range data {
+		if err := d.Set(key, value); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return nil
+}
+
+func resourcePifConfigurationUpdateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	pif, err := getPifObject(c, d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if d.HasChanges("mode", "ipv4_address", "ipv4_netmask", "ipv4_gateway", "dns") {
+		if err := reconfigurePifIp(ctx, c, pif, d); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if err := updatePifSettings(ctx, c, pif, d); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourcePifConfigurationReadContext(ctx, d, m)
+}
+
+func resourcePifConfigurationDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
+
+func reconfigurePifIp(ctx context.Context, c client.XOClient, pif *pifObject, d *schema.ResourceData) error {
+	if pif.Management && !d.Get("allow_management_reconfiguration").(bool) {
+		return fmt.Errorf("PIF %s is the management interface of host %s, set allow_management_reconfiguration to `true` to reconfigure it", pif.Id, pif.Host)
+	}
+
+	dns := []string{}
+	for _, server := range d.Get("dns").([]interface{}) {
+		dns = append(dns, server.(string))
+	}
+	params := map[string]interface{}{
+		"pif":     pif.Id,
+		"mode":    pifIpModes[d.Get("mode").(string)],
+		"ip":      d.Get("ipv4_address").(string),
+		"netmask": d.Get("ipv4_netmask").(string),
+		"gateway": d.Get("ipv4_gateway").(string),
+		"dns":     strings.Join(dns, ","),
+	}
+	tflog.Debug(ctx, "Reconfiguring PIF IP", params)
+
+	var success bool
+	if err := callXoApi(c, "pif.reconfigureIp", params, &success); err != nil {
+		return fmt.Errorf("failed to reconfigure the IP of PIF %s: %w", pif.Id, err)
+	}
+	return nil
+}
+
+// updatePifSettings applies the configured IPv6 configuration and flags
+// that differ from the PIF's current ones, in the same way as
+// updateHostSettings.
+func updatePifSettings(ctx context.Context, c client.XOClient, pif *pifObject, d *schema.ResourceData) error {
+	current := pifConfigurationToMap(pif)
+	changed := func(key string) bool {
+		return isConfigured(d, key) && d.Get(key) != current[key]
+	}
+
+	var success bool
+	if changed("ipv6_mode") || (isConfigured(d, "ipv6_mode") && d.HasChanges(pifStaticIpv6Attributes...)) {
+		if pif.Management && !d.Get("allow_management_reconfiguration").(bool) {
+			return fmt.Errorf("PIF %s is the management interface of host %s, set allow_management_reconfiguration to `true` to reconfigure it", pif.Id, pif.Host)
+		}
+		mode := d.Get("ipv6_mode").(string)
+		params := map[string]interface{}{
+			"pif":         pif.Id,
+			"ipv6Mode":    pifIpv6Modes[mode],
+			"ipv6":        "",
+			"ipv6Gateway": "",
+		}
+		if mode == "static" {
+			params["ipv6"] = d.Get("ipv6_address").(string)
+			params["ipv6Gateway"] = d.Get("ipv6_gateway").(string)
+		}
+		tflog.Debug(ctx, "Reconfiguring PIF IPv6", params)
+		if err := callXoApi(c, "pif.reconfigureIp", params, &success); err != nil {
+			return fmt.Errorf("failed to reconfigure the IPv6 of PIF %s: %w", pif.Id, err)
+		}
+	}
+
+	if changed("disallow_unplug") {
+		params := map[string]interface{}{
+			"id":             pif.Id,
+			"disallowUnplug": d.Get("disallow_unplug").(bool),
+		}
+		if err := callXoApi(c, "pif.set", params, &success); err != nil {
+			return fmt.Errorf("failed to set disallow_unplug of PIF %s: %w", pif.Id, err)
+		}
+	}
+
+	// The management interface is moved last since the host may briefly
+	// be unreachable while it changes.
+	if changed("management") && d.Get("management").(bool) {
+		if !d.Get("allow_management_reconfiguration").(bool) {
+			return fmt.Errorf("set allow_management_reconfiguration to `true` to move the management interface of host %s to PIF %s", pif.Host, pif.Id)
+		}
+		tflog.Debug(ctx, "Moving the management interface", map[string]interface{}{
+			"host_id": pif.Host,
+			"pif_id":  pif.Id,
+		})
+		params := map[string]interface{}{
+			"id":  pif.Host,
+			"pif": pif.Id,
+		}
+		if err := callXoApi(c, "host.managementReconfigure", params, &success); err != nil {
+			return fmt.Errorf("failed to move the management interface of host %s to PIF %s: %w", pif.Host, pif.Id, err)
+		}
+	}
+	return nil
+}
+
+// staticIpv6Address returns the static IPv6 address to report for a PIF.
+// XO reports every address of the PIF, so the configured one is kept while
+// the PIF still has it and the first address is reported otherwise.
+func staticIpv6Address(mode, configured string, addresses []string) string {
+	if mode != "static" {
+		return ""
+	}
+	for _, address := range addresses {
+		if address == configured {
+			return configured
+		}
+	}
+	if len(addresses) > 0 {
+		return addresses[0]
+	}
+	return ""
+}
+
+func getPifObject(c client.XOClient, id string) (*pifObject, error) {
+	pifs := map[string]pifObject{}
+	filter := map[string]interface{}{
+		"type": "PIF",
+		"id":   id,
+	}
+	if err := getXoObjects(c, filter, &pifs); err != nil {
+		return nil, err
+	}
+
+	pif, ok := pifs[id]
+	if !ok {
+		return nil, client.NotFound{Query: client.PIF{Id: id}}
+	}
+	return &pif, nil
+}
+
+// pifConfigurationToMap returns the resource attributes of a PIF. The
+// static IPv4 attributes are only reported for statically configured PIFs
+// since a DHCP lease is not part of the configuration.
+func pifConfigurationToMap(pif *pifObject) map[string]interface{} {
+	mode := strings.ToLower(pif.Mode)
+	data := map[string]interface{}{
+		"pif_id":               pif.Id,
+		"mode":                 mode,
+		"ipv4_address":         "",
+		"ipv4_netmask":         "",
+		"ipv4_gateway":         "",
+		"dns":                  []string{},
+		"current_ipv4_address": pif.Ip,
+		"ipv6_mode":            strings.ToLower(pif.Ipv6Mode),
+		"ipv6_addresses":       pif.Ipv6,
+		"management":           pif.Management,
+		"disallow_unplug":      pif.DisallowUnplug,
+		"attached":             pif.Attached,
+		"device":               pif.Device,
+		"host_id":              pif.Host,
+		"network_id":           pif.Network,
+	}
+
+	if mode == "static" {
+		data["ipv4_address"] = pif.Ip
+		data["ipv4_netmask"] = pif.Netmask
+		data["ipv4_gateway"] = pif.Gateway
+		dns := []string{}
+		for _, server := range strings.Split(pif.Dns, ",") {
+			if server = strings.TrimSpace(server); server != "" {
+				dns = append(dns, server)
+			}
+		}
+		data["dns"] = dns
+	}
+	return data
+}
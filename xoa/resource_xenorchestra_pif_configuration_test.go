@@ -0,0 +1,210 @@
+package xoa
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func TestAccXenorchestraPifConfiguration_staticAndNone(t *testing.T) {
+	if accTestPIF.Id == "" {
+		t.Skip()
+	}
+	resourceName := "xenorchestra_pif_configuration.storage"
+	nameLabel := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraPifConfigurationStaticConfig(nameLabel, "10.254.0.10"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair(resourceName, "pif_id", "xenorchestra_network.storage", "host_pifs.0.pif_id"),
+					resource.TestCheckResourceAttr(resourceName, "mode", "static"),
+					resource.TestCheckResourceAttr(resourceName, "ipv4_address", "10.254.0.10"),
+					resource.TestCheckResourceAttr(resourceName, "ipv4_netmask", "255.255.255.0"),
+					resource.TestCheckResourceAttr(resourceName, "dns.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "current_ipv4_address", "10.254.0.10"),
+					resource.TestCheckResourceAttr(resourceName, "management", "false"),
+					resource.TestCheckResourceAttrPair(resourceName, "network_id", "xenorchestra_network.storage", "id"),
+				),
+			},
+			{
+				Config: testAccXenorchestraPifConfigurationStaticConfig(nameLabel, "10.254.0.11"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "ipv4_address", "10.254.0.11"),
+				),
+			},
+			{
+				Config: testAccXenorchestraPifConfigurationIpv6Config(nameLabel),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "ipv6_mode", "static"),
+					resource.TestCheckResourceAttr(resourceName, "ipv6_address", "fd00:254::10/64"),
+					resource.TestCheckResourceAttr(resourceName, "disallow_unplug", "true"),
+				),
+			},
+			{
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"allow_management_reconfiguration", "ipv6_gateway"},
+			},
+			{
+				Config: testAccXenorchestraPifConfigurationNoneConfig(nameLabel),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "mode", "none"),
+					resource.TestCheckResourceAttr(resourceName, "ipv4_address", ""),
+				),
+			},
+		},
+	},
+	)
+}
+
+func TestAccXenorchestraPifConfiguration_staticRequiresAddress(t *testing.T) {
+	if accTestPIF.Id == "" {
+		t.Skip()
+	}
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "xenorchestra_pif_configuration" "storage" {
+    pif_id = "%s"
+    mode = "static"
+    ipv4_netmask = "255.255.255.0"
+}
+`, accTestPIF.Id),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("ipv4_address must be set when mode is `static`"),
+			},
+		},
+	},
+	)
+}
+
+func testAccXenorchestraPifConfigurationNetworkConfig(name string) string {
+	return fmt.Sprintf(`
+resource "xenorchestra_network" "storage" {
+    name_label = "%s"
+    pool_id = "%s"
+    source_pif_device = "%s"
+    vlan = 254
+}
+`, name, accTestPIF.PoolId, accTestPIF.Device)
+}
+
+func testAccXenorchestraPifConfigurationStaticConfig(name, ip string) string {
+	return testAccXenorchestraPifConfigurationNetworkConfig(name) + fmt.Sprintf(`
+resource "xenorchestra_pif_configuration" "storage" {
+    pif_id = xenorchestra_network.storage.host_pifs[0].pif_id
+    mode = "static"
+    ipv4_address = "%s"
+    ipv4_netmask = "255.255.255.0"
+    dns = ["10.254.0.1"]
+}
+`, ip)
+}
+
+func testAccXenorchestraPifConfigurationIpv6Config(name string) string {
+	return testAccXenorchestraPifConfigurationNetworkConfig(name) + `
+resource "xenorchestra_pif_configuration" "storage" {
+    pif_id = xenorchestra_network.storage.host_pifs[0].pif_id
+    mode = "static"
+    ipv4_address = "10.254.0.11"
+    ipv4_netmask = "255.255.255.0"
+    dns = ["10.254.0.1"]
+    ipv6_mode = "static"
+    ipv6_address = "fd00:254::10/64"
+    ipv6_gateway = "fd00:254::1"
+    disallow_unplug = true
+}
+`
+}
+
+func testAccXenorchestraPifConfigurationNoneConfig(name string) string {
+	return testAccXenorchestraPifConfigurationNetworkConfig(name) + `
+resource "xenorchestra_pif_configuration" "storage" {
+    pif_id = xenorchestra_network.storage.host_pifs[0].pif_id
+    mode = "none"
+}
+`
+}
+
+func Test_pifConfigurationToMap(t *testing.T) {
+	pif := &pifObject{
+		PIF: client.PIF{
+			Id:         "pif id",
+			Host:       "host id",
+			Network:    "network id",
+			Device:     "eth1",
+			Attached:   true,
+			Management: false,
+		},
+		Mode:     "Static",
+		Ip:       "10.0.0.10",
+		Netmask:  "255.255.255.0",
+		Gateway:  "10.0.0.1",
+		Dns:      "10.0.0.2, 10.0.0.3",
+		Ipv6Mode: "None",
+		Ipv6:     []string{},
+	}
+
+	data := pifConfigurationToMap(pif)
+	expected := map[string]interface{}{
+		"pif_id":               "pif id",
+		"mode":                 "static",
+		"ipv4_address":         "10.0.0.10",
+		"ipv4_netmask":         "255.255.255.0",
+		"ipv4_gateway":         "10.0.0.1",
+		"dns":                  []string{"10.0.0.2", "10.0.0.3"},
+		"current_ipv4_address": "10.0.0.10",
+		"ipv6_mode":            "none",
+		"ipv6_addresses":       []string{},
+		"management":           false,
+		"disallow_unplug":      false,
+		"attached":             true,
+		"device":               "eth1",
+		"host_id":              "host id",
+		"network_id":           "network id",
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected %+v, received %+v", expected, data)
+	}
+
+	// A DHCP lease is reported without being part of the configuration
+	pif.Mode = "DHCP"
+	data = pifConfigurationToMap(pif)
+	if data["mode"] != "dhcp" || data["ipv4_address"] != "" || data["current_ipv4_address"] != "10.0.0.10" {
+		t.Errorf("expected the DHCP address to only be reported as current_ipv4_address, received %+v", data)
+	}
+}
+
+func Test_staticIpv6Address(t *testing.T) {
+	addresses := []string{"fd00::10/64", "fe80::1/64"}
+	tests := []struct {
+		mode       string
+		configured string
+		expected   string
+	}{
+		{mode: "static", configured: "fe80::1/64", expected: "fe80::1/64"},
+		{mode: "static", configured: "fd00::20/64", expected: "fd00::10/64"},
+		{mode: "static", configured: "", expected: "fd00::10/64"},
+		{mode: "autoconf", configured: "fd00::10/64", expected: ""},
+	}
+
+	for _, test := range tests {
+		if address := staticIpv6Address(test.mode, test.configured, addresses); address != test.expected {
+			t.Errorf("expected %q for mode %s and configured address %q, received %q", test.expected, test.mode, test.configured, address)
+		}
+	}
+	if address := staticIpv6Address("static", "fd00::10/64", nil); address != "" {
+		t.Errorf("expected no address for a PIF without IPv6 addresses, received %q", address)
+	}
+}
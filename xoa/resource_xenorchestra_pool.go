@@ -0,0 +1,315 @@
+package xoa
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+// The pool other_config key where Xen Orchestra stores the migration network
+const poolMigrationNetworkKey = "xo:migrationNetwork"
+
+// poolObject is a pool along with the settings that the SDK's type does
+// not expose.
+type poolObject struct {
+	client.Pool
+	AutoPoweron bool              `json:"auto_poweron"`
+	SuspendSr   string            `json:"suspendSr"`
+	CrashDumpSr string            `json:"crashDumpSr"`
+	HaEnabled   bool              `json:"HA_enabled"`
+	HaSrs       []string          `json:"haSrs"`
+	Tags        []string          `json:"tags"`
+	OtherConfig map[string]string `json:"otherConfig"`
+}
+
+func resourcePoolRecord() *schema.Resource {
+	return &schema.Resource{
+		Description: `Manages the settings of an existing pool. Pools cannot be created by terraform, so this resource adopts the pool given by ` + "`pool_id`" + `.
+
+**Note:** Destroying this resource only removes it from the terraform state, the pool keeps its settings. Settings that are not specified are left as they are.`,
+		CreateContext: resourcePoolCreateContext,
+		ReadContext:   resourcePoolReadContext,
+		UpdateContext: resourcePoolUpdateContext,
+		DeleteContext: resourcePoolDeleteContext,
+		CustomizeDiff: poolCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourcePoolImportContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"pool_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The id of the pool to manage.",
+			},
+			"name_label": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The name of the pool.",
+			},
+			"name_description": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The description of the pool.",
+			},
+			"default_sr_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The id of the pool's default storage repository.",
+			},
+			"suspend_sr_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The id of the storage repository suspended VMs' memory is written to.",
+			},
+			"crash_dump_sr_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The id of the storage repository crash dumps are written to.",
+			},
+			"migration_network_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The id of the network used for VM migrations within the pool.",
+			},
+			"auto_poweron": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: "Whether the pool's VMs with auto power on enabled are started when their host boots.",
+			},
+			"ha_enabled": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: "Whether high availability is enabled on the pool. Enabling it requires `ha_heartbeat_sr_ids`.",
+			},
+			"ha_heartbeat_sr_ids": &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Computed:    true,
+				Description: "The ids of the shared storage repositories used for the high availability heartbeat. Changing them while high availability is enabled disables and enables it again.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"tags": adoptedTagsSchema("pool"),
+			"master": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The id of the primary instance in the pool.",
+			},
+		},
+	}
+}
+
+func poolCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, v interface{}) error {
+	if !diff.Get("ha_enabled").(bool) || !diff.NewValueKnown("ha_heartbeat_sr_ids") {
+		return nil
+	}
+	if diff.Get("ha_heartbeat_sr_ids").(*schema.Set).Len() == 0 {
+		return fmt.Errorf("ha_heartbeat_sr_ids must be specified when ha_enabled is set to `true`")
+	}
+	return nil
+}
+
+func resourcePoolCreateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	poolId := d.Get("pool_id").(string)
+	pool, err := getPoolObject(c, poolId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(poolId)
+
+	if err := updatePoolSettings(ctx, c, pool, d); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourcePoolReadContext(ctx, d, m)
+}
+
+func resourcePoolReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	pool, err := getPoolObject(c, d.Id())
+	if _, ok := err.(client.NotFound); ok {
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	for key, value := range poolObjectToMap(pool) {
+		if err := d.Set(key, value); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return nil
+}
+
+func resourcePoolUpdateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	pool, err := getPoolObject(c, d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := updatePoolSettings(ctx, c, pool, d); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourcePoolReadContext(ctx, d, m)
+}
+
+func resourcePoolDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
+
+func resourcePoolImportContext(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	d.Set("pool_id", d.Id())
+	return []*schema.ResourceData{d}, nil
+}
+
+// updatePoolSettings applies the configured settings that differ from the
+// pool's current ones. Comparing against the pool rather than the previous
+// state lets the adopting create share this with updates, and settings that
+// are not configured are left untouched.
+func updatePoolSettings(ctx context.Context, c client.XOClient, pool *poolObject, d *schema.ResourceData) error {
+	current := poolObjectToMap(pool)
+	changed := func(key string) bool {
+		return isConfigured(d, key) && d.Get(key) != current[key]
+	}
+
+	params := map[string]interface{}{"id": pool.Id}
+	for _, attr := range []struct {
+		key   string
+		param string
+	}{
+		{"name_label", "name_label"},
+		{"name_description", "name_description"},
+		{"suspend_sr_id", "suspendSr"},
+		{"crash_dump_sr_id", "crashDumpSr"},
+		{"migration_network_id", "migrationNetwork"},
+		{"auto_poweron", "auto_poweron"},
+	} {
+		if changed(attr.key) {
+			params[attr.param] = d.Get(attr.key)
+		}
+	}
+	if len(params) > 1 {
+		tflog.Debug(ctx, "Updating pool settings", params)
+		var success bool
+		if err := callXoApi(c, "pool.set", params, &success); err != nil {
+			return fmt.Errorf("failed to update pool %s: %w", pool.Id, err)
+		}
+	}
+
+	if changed("default_sr_id") {
+		var success bool
+		if err := callXoApi(c, "pool.setDefaultSr", map[string]interface{}{"sr": d.Get("default_sr_id").(string)}, &success); err != nil {
+			return fmt.Errorf("failed to set the default SR of pool %s: %w", pool.Id, err)
+		}
+	}
+
+	if err := updatePoolHa(ctx, c, pool, d); err != nil {
+		return err
+	}
+	return updateAdoptedTags(c, pool.Id, pool.Tags, d)
+}
+
+func updatePoolHa(ctx context.Context, c client.XOClient, pool *poolObject, d *schema.ResourceData) error {
+	if !isConfigured(d, "ha_enabled") {
+		return nil
+	}
+	haEnabled := d.Get("ha_enabled").(bool)
+	heartbeatSrs := tagsFromInterfaceSlice(d.Get("ha_heartbeat_sr_ids").(*schema.Set).List())
+	srsChanged := haEnabled && pool.HaEnabled && isConfigured(d, "ha_heartbeat_sr_ids") && !stringSetEqual(pool.HaSrs, heartbeatSrs)
+
+	var success bool
+	if pool.HaEnabled && (!haEnabled || srsChanged) {
+		tflog.Debug(ctx, "Disabling pool HA", map[string]interface{}{"pool_id": pool.Id})
+		if err := callXoApi(c, "pool.disableHa", map[string]interface{}{"pool": pool.Id}, &success); err != nil {
+			return fmt.Errorf("failed to disable HA on pool %s: %w", pool.Id, err)
+		}
+	}
+
+	if haEnabled && (!pool.HaEnabled || srsChanged) {
+		params := map[string]interface{}{
+			"pool":          pool.Id,
+			"heartbeatSrs":  heartbeatSrs,
+			"configuration": map[string]interface{}{},
+		}
+		tflog.Debug(ctx, "Enabling pool HA", params)
+		if err := callXoApi(c, "pool.enableHa", params, &success); err != nil {
+			return fmt.Errorf("failed to enable HA on pool %s: %w", pool.Id, err)
+		}
+	}
+	return nil
+}
+
+func stringSetEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]bool, len(a))
+	for _, s := range a {
+		set[s] = true
+	}
+	for _, s := range b {
+		if !set[s] {
+			return false
+		}
+	}
+	return true
+}
+
+func getPoolObject(c client.XOClient, id string) (*poolObject, error) {
+	pools := map[string]poolObject{}
+	if err := getXoObjects(c, map[string]interface{}{"type": "pool", "id": id}, &pools); err != nil {
+		return nil, err
+	}
+
+	pool, ok := pools[id]
+	if !ok {
+		return nil, client.NotFound{Query: client.Pool{Id: id}}
+	}
+	return &pool, nil
+}
+
+func poolObjectToMap(pool *poolObject) map[string]interface{} {
+	haSrs := pool.HaSrs
+	if haSrs == nil {
+		haSrs = []string{}
+	}
+	tags := pool.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return map[string]interface{}{
+		"pool_id":              pool.Id,
+		"name_label":           pool.NameLabel,
+		"name_description":     pool.Description,
+		"default_sr_id":        pool.DefaultSR,
+		"suspend_sr_id":        pool.SuspendSr,
+		"crash_dump_sr_id":     pool.CrashDumpSr,
+		"migration_network_id": pool.OtherConfig[poolMigrationNetworkKey],
+		"auto_poweron":         pool.AutoPoweron,
+		"ha_enabled":           pool.HaEnabled,
+		"ha_heartbeat_sr_ids":  haSrs,
+		"tags":                 tags,
+		"master":               pool.Master,
+	}
+}
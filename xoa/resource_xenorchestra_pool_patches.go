@@ -0,0 +1,373 @@
+package xoa
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+// How often the hosts of a pool are checked after a rolling update
+var poolPatchesPollInterval = 30 * time.Second
+
+// poolPatch is a patch returned by the `pool.listMissingPatches` XO method.
+type poolPatch struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Release     string `json:"release"`
+	Description string `json:"description"`
+}
+
+// Label returns the name identifying the patch in the plan output, e.g.
+// xapi-core-1.249.26-1.xcpng8.2 for XCP-ng packages.
+func (p poolPatch) Label() string {
+	if p.Version == "" {
+		return p.Name
+	}
+	if p.Release == "" {
+		return fmt.Sprintf("%s-%s", p.Name, p.Version)
+	}
+	return fmt.Sprintf("%s-%s-%s", p.Name, p.Version, p.Release)
+}
+
+func resourcePoolPatchesRecord() *schema.Resource {
+	return &schema.Resource{
+		Description: `Reports the patches missing on the hosts of a pool and installs them with a rolling pool update. During the rolling update, the hosts are evacuated, patched and rebooted one after the other, starting with the pool master.
+
+The patches that an apply would install are shown in the plan as the new value of ` + "`installed_patches`" + `.
+
+**Note:** The rolling update requires every host of the pool to be running and enabled, and the other hosts to have enough capacity to run the VMs of the host being rebooted. Destroying this resource only removes it from the terraform state.`,
+		CreateContext: resourcePoolPatchesCreateContext,
+		ReadContext:   resourcePoolPatchesReadContext,
+		UpdateContext: resourcePoolPatchesUpdateContext,
+		DeleteContext: resourcePoolPatchesDeleteContext,
+		CustomizeDiff: poolPatchesCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourcePoolPatchesImportContext,
+		},
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(2 * time.Hour),
+			Update: schema.DefaultTimeout(2 * time.Hour),
+		},
+		Schema: map[string]*schema.Schema{
+			"pool_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The id of the pool to patch.",
+			},
+			"install": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether the missing patches are installed on apply. When `false`, the resource only reports the missing patches.",
+			},
+			"missing_patches": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The patches missing on at least one host of the pool.",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"version": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"release": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"description": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"host_ids": &schema.Schema{
+							Type:        schema.TypeList,
+							Computed:    true,
+							Description: "The ids of the hosts missing the patch.",
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+					},
+				},
+			},
+			"installed_patches": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The patches installed by the last rolling update of this resource.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+// poolPatchesCustomizeDiff looks up the missing patches so that the plan
+// shows the patches that will be installed.
+func poolPatchesCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, v interface{}) error {
+	c, ok := v.(client.XOClient)
+	if !ok || !diff.Get("install").(bool) || !diff.NewValueKnown("pool_id") {
+		return nil
+	}
+
+	patches, err := getPoolMissingPatches(c, diff.Get("pool_id").(string))
+	if err != nil {
+		return err
+	}
+	if len(patches) == 0 {
+		return nil
+	}
+
+	labels := make([]string, 0, len(patches))
+	for _, patch := range patches {
+		labels = append(labels, patch["label"].(string))
+	}
+	if err := diff.SetNew("installed_patches", labels); err != nil {
+		return err
+	}
+	return diff.SetNew("missing_patches", []interface{}{})
+}
+
+func resourcePoolPatchesCreateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	poolId := d.Get("pool_id").(string)
+	if _, err := getPoolObject(c, poolId); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(poolId)
+
+	if err := installPoolPatches(ctx, c, poolId, d); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourcePoolPatchesReadContext(ctx, d, m)
+}
+
+func resourcePoolPatchesReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	if _, err := getPoolObject(c, d.Id()); err != nil {
+		if _, ok := err.(client.NotFound); ok {
+			d.SetId("")
+			return nil
+		}
+		return diag.FromErr(err)
+	}
+
+	patches, err := getPoolMissingPatches(c, d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	for _, patch := range patches {
+		delete(patch, "label")
+	}
+	if err := d.Set("missing_patches", patches); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func resourcePoolPatchesUpdateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+
+	if err := installPoolPatches(ctx, c, d.Id(), d); err != nil {
+		return diag.FromErr(err)
+	}
+	return resourcePoolPatchesReadContext(ctx, d, m)
+}
+
+func resourcePoolPatchesDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
+
+func resourcePoolPatchesImportContext(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	d.Set("pool_id", d.Id())
+	d.Set("install", true)
+	return []*schema.ResourceData{d}, nil
+}
+
+// installPoolPatches runs a rolling update of the pool if patches are
+// missing. It refuses to start unless every host is up and fails if a host
+// does not come back or patches are still missing afterwards.
+func installPoolPatches(ctx context.Context, c client.XOClient, poolId string, d *schema.ResourceData) error {
+	if !d.Get("install").(bool) {
+		return nil
+	}
+	patches, err := getPoolMissingPatches(c, poolId)
+	if err != nil {
+		return err
+	}
+	if len(patches) == 0 {
+		return nil
+	}
+
+	hosts, err := getPoolHostObjects(c, poolId)
+	if err != nil {
+		return err
+	}
+	if down := unavailableHosts(hosts); len(down) > 0 {
+		return fmt.Errorf("refusing to start the rolling update of pool %s, the following hosts are not running or are disabled: %s", poolId, strings.Join(down, ", "))
+	}
+
+	tflog.Debug(ctx, "Starting rolling pool update", map[string]interface{}{
+		"pool_id": poolId,
+		"patches": d.Get("installed_patches"),
+	})
+	if err := callXoApiWithContext(ctx, c, "pool.rollingUpdate", map[string]interface{}{"pool": poolId}); err != nil {
+		return fmt.Errorf("rolling update of pool %s failed: %w", poolId, err)
+	}
+
+	if err := waitForPoolHosts(ctx, c, poolId); err != nil {
+		return err
+	}
+
+	patches, err = getPoolMissingPatches(c, poolId)
+	if err != nil {
+		return err
+	}
+	if len(patches) > 0 {
+		labels := make([]string, 0, len(patches))
+		for _, patch := range patches {
+			labels = append(labels, patch["label"].(string))
+		}
+		return fmt.Errorf("patches are still missing on pool %s after the rolling update: %s", poolId, strings.Join(labels, ", "))
+	}
+	return nil
+}
+
+// callXoApiWithContext calls a long running XO method and gives up waiting
+// for it when ctx is done. XO keeps running the method in that case.
+func callXoApiWithContext(ctx context.Context, c client.XOClient, method string, params map[string]interface{}) error {
+	errs := make(chan error, 1)
+	go func() {
+		var result interface{}
+		errs <- callXoApi(c, method, params, &result)
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for `%s` to finish, it may still be running in Xen Orchestra: %w", method, ctx.Err())
+	}
+}
+
+// waitForPoolHosts waits until every host of the pool is running and
+// enabled again.
+func waitForPoolHosts(ctx context.Context, c client.XOClient, poolId string) error {
+	ticker := time.NewTicker(poolPatchesPollInterval)
+	defer ticker.Stop()
+
+	for {
+		hosts, err := getPoolHostObjects(c, poolId)
+		if err != nil {
+			return err
+		}
+		down := unavailableHosts(hosts)
+		if len(down) == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for the hosts of pool %s to come back after the rolling update: %s", poolId, strings.Join(down, ", "))
+		case <-ticker.C:
+		}
+	}
+}
+
+func getPoolHostObjects(c client.XOClient, poolId string) ([]hostObject, error) {
+	hostsById := map[string]hostObject{}
+	if err := getXoObjects(c, map[string]interface{}{"type": "host", "$pool": poolId}, &hostsById); err != nil {
+		return nil, err
+	}
+
+	hosts := make([]hostObject, 0, len(hostsById))
+	for _, host := range hostsById {
+		hosts = append(hosts, host)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].Id < hosts[j].Id
+	})
+	return hosts, nil
+}
+
+// unavailableHosts returns the hosts that are not running or disabled.
+func unavailableHosts(hosts []hostObject) []string {
+	down := []string{}
+	for _, host := range hosts {
+		if host.PowerState != "Running" || !host.Enabled {
+			down = append(down, fmt.Sprintf("%s (%s)", host.NameLabel, host.Id))
+		}
+	}
+	return down
+}
+
+// getPoolMissingPatches returns the patches missing on the hosts of the
+// pool, merged across hosts.
+func getPoolMissingPatches(c client.XOClient, poolId string) ([]map[string]interface{}, error) {
+	hosts, err := getPoolHostObjects(c, poolId)
+	if err != nil {
+		return nil, err
+	}
+
+	patchesByHost := map[string][]poolPatch{}
+	for _, host := range hosts {
+		patches := []poolPatch{}
+		if err := callXoApi(c, "pool.listMissingPatches", map[string]interface{}{"host": host.Id}, &patches); err != nil {
+			return nil, fmt.Errorf("failed to list the missing patches of host %s: %w", host.Id, err)
+		}
+		patchesByHost[host.Id] = patches
+	}
+	return mergeMissingPatches(patchesByHost), nil
+}
+
+func mergeMissingPatches(patchesByHost map[string][]poolPatch) []map[string]interface{} {
+	hostIds := make([]string, 0, len(patchesByHost))
+	for hostId := range patchesByHost {
+		hostIds = append(hostIds, hostId)
+	}
+	sort.Strings(hostIds)
+
+	patches := []map[string]interface{}{}
+	byLabel := map[string]map[string]interface{}{}
+	for _, hostId := range hostIds {
+		for _, patch := range patchesByHost[hostId] {
+			label := patch.Label()
+			if merged, ok := byLabel[label]; ok {
+				merged["host_ids"] = append(merged["host_ids"].([]string), hostId)
+				continue
+			}
+			merged := map[string]interface{}{
+				"label":       label,
+				"name":        patch.Name,
+				"version":     patch.Version,
+				"release":     patch.Release,
+				"description": patch.Description,
+				"host_ids":    []string{hostId},
+			}
+			byLabel[label] = merged
+			patches = append(patches, merged)
+		}
+	}
+	sort.SliceStable(patches, func(i, j int) bool {
+		return patches[i]["label"].(string) < patches[j]["label"].(string)
+	})
+	return patches
+}
@@ -0,0 +1,108 @@
+package xoa
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+// Installing patches reboots the hosts of the test pool, so the acceptance
+// test only covers reporting the missing patches.
+func TestAccXenorchestraPoolPatches_reportOnly(t *testing.T) {
+	resourceName := "xenorchestra_pool_patches.patches"
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "xenorchestra_pool_patches" "patches" {
+    pool_id = "%s"
+    install = false
+}
+`, accTestPool.Id),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "id", accTestPool.Id),
+					resource.TestCheckResourceAttr(resourceName, "install", "false"),
+					resource.TestCheckResourceAttrSet(resourceName, "missing_patches.#"),
+					resource.TestCheckResourceAttr(resourceName, "installed_patches.#", "0"),
+				),
+			},
+		},
+	})
+}
+
+func Test_poolPatchLabel(t *testing.T) {
+	tests := []struct {
+		patch poolPatch
+		label string
+	}{
+		{
+			patch: poolPatch{Name: "xapi-core", Version: "1.249.26", Release: "1.xcpng8.2"},
+			label: "xapi-core-1.249.26-1.xcpng8.2",
+		},
+		{
+			patch: poolPatch{Name: "xapi-core", Version: "1.249.26"},
+			label: "xapi-core-1.249.26",
+		},
+		{
+			patch: poolPatch{Name: "XS82E001"},
+			label: "XS82E001",
+		},
+	}
+
+	for _, test := range tests {
+		if label := test.patch.Label(); label != test.label {
+			t.Errorf("expected label %q for %+v, received %q", test.label, test.patch, label)
+		}
+	}
+}
+
+func Test_mergeMissingPatches(t *testing.T) {
+	xapi := poolPatch{Name: "xapi-core", Version: "1.249.26", Release: "1.xcpng8.2", Description: "xapi"}
+	kernel := poolPatch{Name: "kernel", Version: "4.19.19", Release: "8.0.20.1.xcpng8.2", Description: "kernel"}
+	patchesByHost := map[string][]poolPatch{
+		"host 2": []poolPatch{xapi},
+		"host 1": []poolPatch{xapi, kernel},
+		"host 3": []poolPatch{},
+	}
+
+	expected := []map[string]interface{}{
+		{
+			"label":       "kernel-4.19.19-8.0.20.1.xcpng8.2",
+			"name":        "kernel",
+			"version":     "4.19.19",
+			"release":     "8.0.20.1.xcpng8.2",
+			"description": "kernel",
+			"host_ids":    []string{"host 1"},
+		},
+		{
+			"label":       "xapi-core-1.249.26-1.xcpng8.2",
+			"name":        "xapi-core",
+			"version":     "1.249.26",
+			"release":     "1.xcpng8.2",
+			"description": "xapi",
+			"host_ids":    []string{"host 1", "host 2"},
+		},
+	}
+	if patches := mergeMissingPatches(patchesByHost); !reflect.DeepEqual(patches, expected) {
+		t.Errorf("expected %+v, received %+v", expected, patches)
+	}
+}
+
+func Test_unavailableHosts(t *testing.T) {
+	hosts := []hostObject{
+		{PowerState: "Running", Enabled: true},
+		{PowerState: "Halted", Enabled: true},
+		{PowerState: "Running", Enabled: false},
+	}
+	hosts[1].Id, hosts[1].NameLabel = "host 2", "halted"
+	hosts[2].Id, hosts[2].NameLabel = "host 3", "disabled"
+
+	expected := []string{"halted (host 2)", "disabled (host 3)"}
+	if down := unavailableHosts(hosts); !reflect.DeepEqual(down, expected) {
+		t.Errorf("expected %v, received %v", expected, down)
+	}
+}
@@ -0,0 +1,115 @@
+package xoa
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func TestAccXenorchestraPool_adoptAndUpdate(t *testing.T) {
+	resourceName := "xenorchestra_pool.pool"
+	description := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	tag := fmt.Sprintf("%s-pool-tag", accTestPrefix)
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraPoolConfig(description, tag),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "id", accTestPool.Id),
+					resource.TestCheckResourceAttr(resourceName, "name_label", accTestPool.NameLabel),
+					resource.TestCheckResourceAttr(resourceName, "name_description", description),
+					resource.TestCheckResourceAttr(resourceName, "default_sr_id", accDefaultSr.Id),
+					resource.TestCheckResourceAttr(resourceName, "master", accTestPool.Master),
+					resource.TestCheckResourceAttr(resourceName, "tags.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "ha_enabled", "false"),
+				),
+			},
+			{
+				Config: testAccXenorchestraPoolConfig(description+" updated", tag),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name_description", description+" updated"),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func TestAccXenorchestraPool_haRequiresHeartbeatSrs(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "xenorchestra_pool" "pool" {
+    pool_id = "%s"
+    ha_enabled = true
+}
+`, accTestPool.Id),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("ha_heartbeat_sr_ids must be specified when ha_enabled is set to `true`"),
+			},
+		},
+	})
+}
+
+func testAccXenorchestraPoolConfig(description, tag string) string {
+	return fmt.Sprintf(`
+resource "xenorchestra_pool" "pool" {
+    pool_id = "%s"
+    name_label = "%s"
+    name_description = "%s"
+    default_sr_id = "%s"
+    tags = ["%s"]
+}
+`, accTestPool.Id, accTestPool.NameLabel, description, accDefaultSr.Id, tag)
+}
+
+func Test_poolObjectToMap(t *testing.T) {
+	pool := &poolObject{
+		Pool: client.Pool{
+			Id:          "pool id",
+			NameLabel:   "pool",
+			Description: "description",
+			DefaultSR:   "default sr",
+			Master:      "master host",
+		},
+		AutoPoweron: true,
+		SuspendSr:   "suspend sr",
+		CrashDumpSr: "crash dump sr",
+		HaEnabled:   true,
+		HaSrs:       []string{"heartbeat sr"},
+		OtherConfig: map[string]string{
+			poolMigrationNetworkKey: "migration network",
+		},
+	}
+
+	expected := map[string]interface{}{
+		"pool_id":              "pool id",
+		"name_label":           "pool",
+		"name_description":     "description",
+		"default_sr_id":        "default sr",
+		"suspend_sr_id":        "suspend sr",
+		"crash_dump_sr_id":     "crash dump sr",
+		"migration_network_id": "migration network",
+		"auto_poweron":         true,
+		"ha_enabled":           true,
+		"ha_heartbeat_sr_ids":  []string{"heartbeat sr"},
+		"tags":                 []string{},
+		"master":               "master host",
+	}
+	if data := poolObjectToMap(pool); !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected %+v, received %+v", expected, data)
+	}
+}
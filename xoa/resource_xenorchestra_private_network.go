@@ -0,0 +1,427 @@
+package xoa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+// The other_config keys the XO SDN Controller plugin stores on the networks
+// that make up a private network.
+const (
+	sdnPrivateNetworkUuidKey = "xo:sdn-controller:private-network-uuid"
+	sdnEncapsulationKey      = "xo:sdn-controller:encapsulation"
+	sdnEncryptedKey          = "xo:sdn-controller:encrypted"
+	sdnPreferredCenterKey    = "xo:sdn-controller:preferred-center"
+	sdnPifDeviceKey          = "xo:sdn-controller:pif-device"
+	sdnVlanKey               = "xo:sdn-controller:vlan"
+)
+
+var validPrivateNetworkEncapsulations = []string{"gre", "vxlan"}
+
+// privateNetworkObject is a network along with the other_config that
+// identifies which private network it belongs to.
+type privateNetworkObject struct {
+	client.Network
+	OtherConfig map[string]string `json:"other_config"`
+}
+
+func resourceXoaPrivateNetwork() *schema.Resource {
+	return &schema.Resource{
+		Description:   "Creates a private network using the XO SDN Controller plugin. A private network is made of one network per member pool whose hosts are connected through GRE or VXLAN tunnels. The SDN Controller plugin must be enabled in Xen Orchestra.",
+		CreateContext: resourcePrivateNetworkCreateContext,
+		ReadContext:   resourcePrivateNetworkReadContext,
+		UpdateContext: resourcePrivateNetworkUpdateContext,
+		DeleteContext: resourcePrivateNetworkDeleteContext,
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+		Schema: map[string]*schema.Schema{
+			"name_label": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The name label of the private network's networks.",
+			},
+			"name_description": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     netDefaultDesc,
+				Description: "The name description of the private network's networks.",
+			},
+			"encapsulation": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "gre",
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(validPrivateNetworkEncapsulations, false),
+				Description:  "The tunnel encapsulation, either `gre` or `vxlan`. Defaults to `gre`.",
+			},
+			"encrypted": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				ForceNew:    true,
+				Description: "Whether the tunnels should be encrypted with IPsec. This requires the hosts' openvswitch-ipsec support. Defaults to `false`.",
+			},
+			"mtu": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IntAtLeast(68),
+				Description:  "The MTU of the private network. Defaults to the SDN Controller's default when unspecified.",
+			},
+			"pool_ids": &schema.Schema{
+				Type:        schema.TypeSet,
+				Required:    true,
+				ForceNew:    true,
+				MinItems:    1,
+				Description: "The ids of the pools the private network spans.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"pif_ids": &schema.Schema{
+				Type:        schema.TypeSet,
+				Required:    true,
+				ForceNew:    true,
+				MinItems:    1,
+				Description: "The ids of the PIFs the tunnels are built on, one for each pool in `pool_ids`. The hosts of a pool use the PIF with the same device.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"preferred_center_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "The id of the host that should be the center of the network's star topology. The SDN Controller elects one when unspecified.",
+			},
+			"network_ids": &schema.Schema{
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "The id of the private network's network in each member pool, keyed by pool id.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+func resourcePrivateNetworkCreateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	nameLabel := d.Get("name_label").(string)
+	poolIds := tagsFromInterfaceSlice(d.Get("pool_ids").(*schema.Set).List())
+	pifIds := tagsFromInterfaceSlice(d.Get("pif_ids").(*schema.Set).List())
+
+	if err := validatePrivateNetworkPIFs(c, poolIds, pifIds); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// The SDN Controller does not return the private network it creates so
+	// note the existing ones in order to find the new one afterwards.
+	existing, err := getPrivateNetworkObjects(c, "")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	existingUuids := map[string]bool{}
+	for _, net := range existing {
+		existingUuids[net.OtherConfig[sdnPrivateNetworkUuidKey]] = true
+	}
+
+	params := map[string]interface{}{
+		"poolIds":       poolIds,
+		"pifIds":        pifIds,
+		"name":          nameLabel,
+		"description":   d.Get("name_description").(string),
+		"encapsulation": d.Get("encapsulation").(string),
+		"encrypted":     d.Get("encrypted").(bool),
+	}
+	if mtu := d.Get("mtu").(int); mtu != 0 {
+		params["mtu"] = mtu
+	}
+	if preferredCenter := d.Get("preferred_center_id").(string); preferredCenter != "" {
+		params["preferredCenterId"] = preferredCenter
+	}
+
+	tflog.Debug(ctx, "Creating private network", params)
+	var result interface{}
+	if err := callXoApi(c, "sdnController.createPrivateNetwork", params, &result); err != nil {
+		return diag.FromErr(err)
+	}
+
+	created, err := getPrivateNetworkObjects(c, "")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	uuid := ""
+	for _, net := range created {
+		netUuid := net.OtherConfig[sdnPrivateNetworkUuidKey]
+		if existingUuids[netUuid] || net.NameLabel != nameLabel {
+			continue
+		}
+		if uuid != "" && uuid != netUuid {
+			return diag.Errorf("found several new private networks named `%s`", nameLabel)
+		}
+		uuid = netUuid
+	}
+	if uuid == "" {
+		return diag.Errorf("failed to find the private network `%s` after creating it", nameLabel)
+	}
+	d.SetId(uuid)
+
+	return resourcePrivateNetworkReadContext(ctx, d, m)
+}
+
+// validatePrivateNetworkPIFs ensures that each member pool has exactly one
+// of the given PIFs, which the SDN Controller requires.
+func validatePrivateNetworkPIFs(c client.XOClient, poolIds, pifIds []string) error {
+	pifsPerPool := map[string]int{}
+	for _, pifId := range pifIds {
+		pifs, err := c.GetPIF(client.PIF{Id: pifId})
+		if err != nil {
+			return err
+		}
+		if len(pifs) != 1 {
+			return fmt.Errorf("expected to find a single PIF with id `%s`, instead found %d", pifId, len(pifs))
+		}
+		pifsPerPool[pifs[0].PoolId]++
+	}
+
+	members := map[string]bool{}
+	for _, poolId := range poolIds {
+		if pifsPerPool[poolId] != 1 {
+			return fmt.Errorf("pif_ids must contain exactly one PIF of pool `%s`, found %d", poolId, pifsPerPool[poolId])
+		}
+		members[poolId] = true
+	}
+	for poolId := range pifsPerPool {
+		if !members[poolId] {
+			return fmt.Errorf("pif_ids contains a PIF of pool `%s` which is not in pool_ids", poolId)
+		}
+	}
+	return nil
+}
+
+func resourcePrivateNetworkReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	networks, err := getPrivateNetworkObjects(c, d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if len(networks) == 0 {
+		d.SetId("")
+		return nil
+	}
+
+	data, err := privateNetworkToMap(networks, d.Get("name_label").(string), d.Get("name_description").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	pifIds, err := getPrivateNetworkPIFs(c, networks, tagsFromInterfaceSlice(d.Get("pif_ids").(*schema.Set).List()))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	data["pif_ids"] = pifIds
+
+	for key, value := range data {
+		if err := d.Set(key, value); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return nil
+}
+
+// getPrivateNetworkPIFs returns the PIF the tunnels of each member network
+// are built on. The SDN Controller only records the device and VLAN of that
+// PIF, so a PIF of currentPifIds is kept while it still matches and the pool
+// master's PIF is used otherwise, e.g. when importing.
+func getPrivateNetworkPIFs(c client.XOClient, networks []privateNetworkObject, currentPifIds []string) ([]string, error) {
+	current := map[string]bool{}
+	for _, pifId := range currentPifIds {
+		current[pifId] = true
+	}
+
+	pifIds := make([]string, 0, len(networks))
+	for _, net := range networks {
+		pools, err := c.GetPools(client.Pool{Id: net.PoolId})
+		if err != nil {
+			return nil, err
+		}
+		if len(pools) != 1 {
+			return nil, fmt.Errorf("expected to find a single pool with id `%s`, instead found %d", net.PoolId, len(pools))
+		}
+
+		pifs := map[string]client.PIF{}
+		filter := map[string]interface{}{
+			"type":    "PIF",
+			"$poolId": net.PoolId,
+			"device":  net.OtherConfig[sdnPifDeviceKey],
+		}
+		if err := getXoObjects(c, filter, &pifs); err != nil {
+			if _, ok := err.(client.NotFound); !ok {
+				return nil, err
+			}
+		}
+
+		pifId, err := matchPrivateNetworkPIF(net, pifs, current, pools[0].Master)
+		if err != nil {
+			return nil, err
+		}
+		pifIds = append(pifIds, pifId)
+	}
+	return pifIds, nil
+}
+
+// matchPrivateNetworkPIF returns the id of the PIF among pifs that has the
+// device and VLAN recorded on the member network, preferring one of current
+// and then the one of masterHost.
+func matchPrivateNetworkPIF(net privateNetworkObject, pifs map[string]client.PIF, current map[string]bool, masterHost string) (string, error) {
+	device := net.OtherConfig[sdnPifDeviceKey]
+	vlan, err := strconv.Atoi(net.OtherConfig[sdnVlanKey])
+	if err != nil {
+		vlan = -1
+	}
+
+	match := ""
+	for id, pif := range pifs {
+		if pif.PoolId != net.PoolId || pif.Device != device || pif.Vlan != vlan {
+			continue
+		}
+		if current[id] {
+			return id, nil
+		}
+		if pif.Host == masterHost {
+			match = id
+		}
+	}
+	if match == "" {
+		return "", fmt.Errorf("failed to find the PIF of network `%s` with device `%s` and VLAN %d on the master of pool `%s`", net.Id, device, vlan, net.PoolId)
+	}
+	return match, nil
+}
+
+func resourcePrivateNetworkUpdateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	if d.HasChanges("name_label", "name_description") {
+		for _, networkId := range d.Get("network_ids").(map[string]interface{}) {
+			var success bool
+			params := map[string]interface{}{
+				"id":               networkId.(string),
+				"name_label":       d.Get("name_label").(string),
+				"name_description": d.Get("name_description").(string),
+			}
+			if err := callXoApi(c, "network.set", params, &success); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
+	return resourcePrivateNetworkReadContext(ctx, d, m)
+}
+
+func resourcePrivateNetworkDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	// The SDN Controller tears down the tunnels of a network once it is
+	// removed so deleting every member network removes the private network.
+	networks, err := getPrivateNetworkObjects(c, d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	for _, net := range networks {
+		err := c.DeleteNetwork(net.Id)
+		if _, ok := err.(client.NotFound); ok {
+			continue
+		}
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	d.SetId("")
+	return nil
+}
+
+// getPrivateNetworkObjects returns the networks that belong to the private
+// network with the given uuid, or to any private network if uuid is empty.
+func getPrivateNetworkObjects(c client.XOClient, uuid string) ([]privateNetworkObject, error) {
+	filter := map[string]interface{}{"type": "network"}
+	if uuid != "" {
+		filter["other_config"] = map[string]interface{}{
+			sdnPrivateNetworkUuidKey: uuid,
+		}
+	}
+
+	networkMap := map[string]privateNetworkObject{}
+	if err := getXoObjects(c, filter, &networkMap); err != nil {
+		if _, ok := err.(client.NotFound); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	networks := make([]privateNetworkObject, 0, len(networkMap))
+	for _, net := range networkMap {
+		netUuid, ok := net.OtherConfig[sdnPrivateNetworkUuidKey]
+		if !ok || (uuid != "" && netUuid != uuid) {
+			continue
+		}
+		networks = append(networks, net)
+	}
+	sort.Slice(networks, func(i, j int) bool {
+		return networks[i].PoolId < networks[j].PoolId
+	})
+	return networks, nil
+}
+
+// privateNetworkToMap returns the resource attributes of a private network
+// from its member networks. The member networks are renamed together, so a
+// member whose name differs from the configured one is reported as drift.
+func privateNetworkToMap(networks []privateNetworkObject, nameLabel, nameDescription string) (map[string]interface{}, error) {
+	if len(networks) == 0 {
+		return nil, errors.New("a private network must have at least one network")
+	}
+
+	first := networks[0]
+	encrypted, err := strconv.ParseBool(first.OtherConfig[sdnEncryptedKey])
+	if err != nil {
+		encrypted = false
+	}
+
+	currentName, currentDescription := first.NameLabel, first.NameDescription
+	poolIds := make([]string, 0, len(networks))
+	networkIds := make(map[string]string, len(networks))
+	for _, net := range networks {
+		if net.NameLabel != nameLabel {
+			currentName = net.NameLabel
+		}
+		if net.NameDescription != nameDescription {
+			currentDescription = net.NameDescription
+		}
+		poolIds = append(poolIds, net.PoolId)
+		networkIds[net.PoolId] = net.Id
+	}
+
+	return map[string]interface{}{
+		"name_label":          currentName,
+		"name_description":    currentDescription,
+		"encapsulation":       first.OtherConfig[sdnEncapsulationKey],
+		"encrypted":           encrypted,
+		"mtu":                 first.MTU,
+		"preferred_center_id": first.OtherConfig[sdnPreferredCenterKey],
+		"pool_ids":            poolIds,
+		"network_ids":         networkIds,
+	}, nil
+}
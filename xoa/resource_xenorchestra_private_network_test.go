@@ -0,0 +1,182 @@
+package xoa
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func TestAccXenorchestraPrivateNetwork_createAndUpdate(t *testing.T) {
+	if accTestPIF.Id == "" {
+		t.Skip()
+	}
+	resourceName := "xenorchestra_private_network.network"
+	nameLabel := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	updatedNameLabel := nameLabel + " updated"
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckXenorchestraPrivateNetworkDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccXenorchestraPrivateNetworkConfig(nameLabel, "gre"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, "id"),
+					resource.TestCheckResourceAttr(resourceName, "name_label", nameLabel),
+					resource.TestCheckResourceAttr(resourceName, "encapsulation", "gre"),
+					resource.TestCheckResourceAttr(resourceName, "encrypted", "false"),
+					resource.TestCheckResourceAttr(resourceName, "pool_ids.#", "1"),
+					resource.TestCheckResourceAttrSet(resourceName, "mtu"),
+					resource.TestCheckResourceAttrSet(resourceName, fmt.Sprintf("network_ids.%s", accTestPIF.PoolId)),
+				),
+			},
+			{
+				Config: testAccXenorchestraPrivateNetworkConfig(updatedNameLabel, "gre"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name_label", updatedNameLabel),
+					resource.TestCheckResourceAttr(resourceName, "pif_ids.#", "1"),
+					resource.TestCheckTypeSetElemAttr(resourceName, "pif_ids.*", accTestPIF.Id),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+				// The imported pif_ids are the pool master's PIFs, which
+				// accTestPIF may not be.
+				ImportStateVerifyIgnore: []string{"pif_ids"},
+			},
+			{
+				Config: testAccXenorchestraPrivateNetworkConfig(updatedNameLabel, "vxlan"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "encapsulation", "vxlan"),
+				),
+			},
+		},
+	},
+	)
+}
+
+func testAccCheckXenorchestraPrivateNetworkDestroy(s *terraform.State) error {
+	c, err := client.NewClient(client.GetConfigFromEnv())
+	if err != nil {
+		return err
+	}
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "xenorchestra_private_network" {
+			continue
+		}
+
+		networks, err := getPrivateNetworkObjects(c, rs.Primary.ID)
+		if err != nil {
+			return err
+		}
+		if len(networks) != 0 {
+			return fmt.Errorf("private network (%s) still has %d networks", rs.Primary.ID, len(networks))
+		}
+	}
+	return nil
+}
+
+func testAccXenorchestraPrivateNetworkConfig(name, encapsulation string) string {
+	return fmt.Sprintf(`
+resource "xenorchestra_private_network" "network" {
+    name_label = "%s"
+    encapsulation = "%s"
+    pool_ids = ["%s"]
+    pif_ids = ["%s"]
+}
+`, name, encapsulation, accTestPIF.PoolId, accTestPIF.Id)
+}
+
+func Test_privateNetworkToMap(t *testing.T) {
+	otherConfig := map[string]string{
+		sdnPrivateNetworkUuidKey: "private network uuid",
+		sdnEncapsulationKey:      "vxlan",
+		sdnEncryptedKey:          "true",
+		sdnPreferredCenterKey:    "host id",
+	}
+	networks := []privateNetworkObject{
+		{
+			Network:     client.Network{Id: "network 1", NameLabel: "name", NameDescription: "description", PoolId: "pool 1", MTU: 1546},
+			OtherConfig: otherConfig,
+		},
+		{
+			Network:     client.Network{Id: "network 2", NameLabel: "renamed", NameDescription: "description", PoolId: "pool 2", MTU: 1546},
+			OtherConfig: otherConfig,
+		},
+	}
+
+	data, err := privateNetworkToMap(networks, "name", "description")
+	if err != nil {
+		t.Fatalf("expected private network to convert, received error: %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"name_label":          "renamed",
+		"name_description":    "description",
+		"encapsulation":       "vxlan",
+		"encrypted":           true,
+		"mtu":                 1546,
+		"preferred_center_id": "host id",
+		"pool_ids":            []string{"pool 1", "pool 2"},
+		"network_ids": map[string]string{
+			"pool 1": "network 1",
+			"pool 2": "network 2",
+		},
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected %+v, received %+v", expected, data)
+	}
+
+	if _, err := privateNetworkToMap(nil, "name", "description"); err == nil {
+		t.Errorf("expected an error for a private network without networks")
+	}
+}
+
+func Test_matchPrivateNetworkPIF(t *testing.T) {
+	net := privateNetworkObject{
+		Network: client.Network{Id: "network", PoolId: "pool"},
+		OtherConfig: map[string]string{
+			sdnPifDeviceKey: "eth1",
+			sdnVlanKey:      "-1",
+		},
+	}
+	pifs := map[string]client.PIF{
+		"master eth1": {Id: "master eth1", Host: "master", PoolId: "pool", Device: "eth1", Vlan: -1},
+		"master eth0": {Id: "master eth0", Host: "master", PoolId: "pool", Device: "eth0", Vlan: -1},
+		"master vlan": {Id: "master vlan", Host: "master", PoolId: "pool", Device: "eth1", Vlan: 100},
+		"slave eth1":  {Id: "slave eth1", Host: "slave", PoolId: "pool", Device: "eth1", Vlan: -1},
+		"slave eth0":  {Id: "slave eth0", Host: "slave", PoolId: "pool", Device: "eth0", Vlan: -1},
+	}
+
+	tests := []struct {
+		current []string
+		pifId   string
+	}{
+		{current: nil, pifId: "master eth1"},
+		{current: []string{"slave eth1"}, pifId: "slave eth1"},
+		{current: []string{"slave eth0"}, pifId: "master eth1"},
+	}
+	for _, test := range tests {
+		current := map[string]bool{}
+		for _, id := range test.current {
+			current[id] = true
+		}
+		pifId, err := matchPrivateNetworkPIF(net, pifs, current, "master")
+		if err != nil {
+			t.Fatalf("expected a PIF to match, received error: %v", err)
+		}
+		if pifId != test.pifId {
+			t.Errorf("expected %q to be matched with current PIFs %v, received %q", test.pifId, test.current, pifId)
+		}
+	}
+
+	if _, err := matchPrivateNetworkPIF(net, pifs, nil, "other host"); err == nil {
+		t.Errorf("expected an error when the pool master has no matching PIF")
+	}
+}
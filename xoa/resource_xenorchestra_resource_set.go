@@ -2,6 +2,8 @@ package xoa
 
 import (
 	"context"
+	"fmt"
+	"sort"
 
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -12,6 +14,18 @@ import (
 
 var validLimitType []string = []string{"cpus", "disk", "memory"}
 
+// resourceSetObject is a resource set as returned by the XO api. Unlike
+// client.ResourceSet, it keeps the limits on IP pools, which are keyed by the
+// IP pool id.
+type resourceSetObject struct {
+	Id       string                             `json:"id"`
+	Name     string                             `json:"name"`
+	Subjects []string                           `json:"subjects"`
+	Objects  []string                           `json:"objects"`
+	IpPools  []string                           `json:"ipPools"`
+	Limits   map[string]client.ResourceSetLimit `json:"limits"`
+}
+
 func resourceResourceSet() *schema.Resource {
 	return &schema.Resource{
 		Description:   "Creates a Xen Orchestra resource set.",
@@ -19,8 +33,9 @@ func resourceResourceSet() *schema.Resource {
 		ReadContext:   resourceSetReadContext,
 		UpdateContext: resourceSetUpdateContext,
 		DeleteContext: resourceSetDeleteContext,
+		CustomizeDiff: resourceSetCustomizeDiff,
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			StateContext: resourceSetImportContext,
 		},
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
@@ -44,6 +59,20 @@ func resourceResourceSet() *schema.Resource {
 				Optional:    true,
 				Description: "The uuids of the objects that are within scope of the resource set. A minimum of a storage repository, network and VM template are required for users to launch VMs.",
 			},
+			"ignore_unmanaged_members": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether the objects and subjects that are not listed in `objects` and `subjects` are left in the resource set, e.g. to add them with the `xenorchestra_resource_set_object` and `xenorchestra_resource_set_subject` resources. When `false`, they are removed.",
+			},
+			"ip_pools": &schema.Schema{
+				Type: schema.TypeSet,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Optional:    true,
+				Description: "The ids of the IP pools the resource set's VMs can allocate addresses from.",
+			},
 			"limit": &schema.Schema{
 				Type:        schema.TypeSet,
 				Required:    true,
@@ -52,9 +81,9 @@ func resourceResourceSet() *schema.Resource {
 					Schema: map[string]*schema.Schema{
 						"type": &schema.Schema{
 							Type:         schema.TypeString,
-							Description:  "The type of resource set limit. Must be cpus, memory or disk.",
+							Description:  "The type of resource set limit. Must be cpus, memory, disk or the id of an IP pool listed in `ip_pools` to limit the number of addresses allocated from it.",
 							Required:     true,
-							ValidateFunc: validation.StringInSlice(validLimitType, false),
+							ValidateFunc: validation.StringIsNotEmpty,
 						},
 						"quantity": &schema.Schema{
 							Type:        schema.TypeInt,
@@ -64,10 +93,72 @@ func resourceResourceSet() *schema.Resource {
 					},
 				},
 			},
+			"usage": resourceSetUsageSchema(),
 		},
 	}
 }
 
+func resourceSetUsageSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Computed:    true,
+		Description: "The consumption of each limit of the resource set.",
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"type": &schema.Schema{
+					Type:        schema.TypeString,
+					Computed:    true,
+					Description: "The type of the limit.",
+				},
+				"total": &schema.Schema{
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "The quantity allowed by the limit.",
+				},
+				"available": &schema.Schema{
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "The quantity that is still available.",
+				},
+				"used": &schema.Schema{
+					Type:        schema.TypeInt,
+					Computed:    true,
+					Description: "The quantity consumed by the resource set's VMs.",
+				},
+			},
+		},
+	}
+}
+
+func resourceSetCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, v interface{}) error {
+	if diff.HasChange("limit") {
+		if err := diff.SetNewComputed("usage"); err != nil {
+			return err
+		}
+	}
+	if !diff.NewValueKnown("limit") || !diff.NewValueKnown("ip_pools") {
+		return nil
+	}
+
+	ipPools := diff.Get("ip_pools").(*schema.Set)
+	for _, limit := range diff.Get("limit").(*schema.Set).List() {
+		t := limit.(map[string]interface{})["type"].(string)
+		if !isResourceSetLimitType(t) && !ipPools.Contains(t) {
+			return fmt.Errorf("limit type `%s` must be one of cpus, disk, memory or the id of an IP pool listed in ip_pools", t)
+		}
+	}
+	return nil
+}
+
+func isResourceSetLimitType(limitType string) bool {
+	for _, t := range validLimitType {
+		if t == limitType {
+			return true
+		}
+	}
+	return false
+}
+
 func resourceSetCreateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(client.XOClient)
 
@@ -103,15 +194,40 @@ func resourceSetCreateContext(ctx context.Context, d *schema.ResourceData, m int
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	d.SetId(rs.Id)
+
+	ipPools := d.Get("ip_pools").(*schema.Set)
+	if ipPools.Len() > 0 {
+		if err := setResourceSetIpPools(c, rs.Id, ipPools); err != nil {
+			return diag.FromErr(err)
+		}
+	}
 
-	return diag.FromErr(resourceSetToData(*rs, d))
+	// The SDK's client.ResourceSet only carries the cpus, disk and memory
+	// limits so the IP pool limits are added once the set exists
+	for _, limit := range limits.List() {
+		l := limit.(map[string]interface{})
+		t := l["type"].(string)
+		if isResourceSetLimitType(t) {
+			continue
+		}
+		if err := c.AddResourceSetLimit(*rs, t, l["quantity"].(int)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	rsObj, err := getResourceSetObject(c, rs.Id)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	return diag.FromErr(resourceSetToData(filterUnmanagedMembers(*rsObj, d), d))
 }
 
 func resourceSetReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(client.XOClient)
 
 	id := d.Id()
-	rs, err := c.GetResourceSetById(id)
+	rs, err := getResourceSetObject(c, id)
 	tflog.Debug(ctx, "Found resource set", map[string]interface{}{
 		"resource_set": rs,
 		"error":        err,
@@ -126,7 +242,7 @@ func resourceSetReadContext(ctx context.Context, d *schema.ResourceData, m inter
 		return diag.FromErr(err)
 	}
 
-	return diag.FromErr(resourceSetToData(*rs, d))
+	return diag.FromErr(resourceSetToData(filterUnmanagedMembers(*rs, d), d))
 }
 
 func resourceSetUpdateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -134,6 +250,12 @@ func resourceSetUpdateContext(ctx context.Context, d *schema.ResourceData, m int
 
 	id := d.Id()
 	rs, _ := c.GetResourceSetById(id)
+	if d.HasChange("ip_pools") {
+		if err := setResourceSetIpPools(c, id, d.Get("ip_pools").(*schema.Set)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	if d.HasChange("limit") {
 		old, new := d.GetChange("limit")
 
@@ -218,13 +340,13 @@ func resourceSetUpdateContext(ctx context.Context, d *schema.ResourceData, m int
 			}
 		}
 	}
-	rs, err := c.GetResourceSetById(id)
+	rsObj, err := getResourceSetObject(c, id)
 
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	return diag.FromErr(resourceSetToData(*rs, d))
+	return diag.FromErr(resourceSetToData(filterUnmanagedMembers(*rsObj, d), d))
 }
 
 func resourceSetDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
@@ -239,39 +361,138 @@ func resourceSetDeleteContext(ctx context.Context, d *schema.ResourceData, m int
 	return nil
 }
 
-func resourceSetToData(rs client.ResourceSet, d *schema.ResourceData) error {
+func resourceSetImportContext(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	d.Set("ignore_unmanaged_members", false)
+	return []*schema.ResourceData{d}, nil
+}
+
+// filterUnmanagedMembers drops the objects and subjects that the resource
+// does not manage when ignore_unmanaged_members is set, so that members added
+// by other resources do not show up as drift.
+func filterUnmanagedMembers(rs resourceSetObject, d *schema.ResourceData) resourceSetObject {
+	if !d.Get("ignore_unmanaged_members").(bool) {
+		return rs
+	}
+	rs.Objects = intersectMembers(rs.Objects, d.Get("objects").(*schema.Set))
+	rs.Subjects = intersectMembers(rs.Subjects, d.Get("subjects").(*schema.Set))
+	return rs
+}
+
+func intersectMembers(members []string, managed *schema.Set) []string {
+	result := []string{}
+	for _, member := range members {
+		if managed.Contains(member) {
+			result = append(result, member)
+		}
+	}
+	return result
+}
+
+func resourceSetToData(rs resourceSetObject, d *schema.ResourceData) error {
 	d.SetId(rs.Id)
-	d.Set("name", rs.Name)
-	d.Set("subjects", rs.Subjects)
-	d.Set("objects", rs.Objects)
-	d.Set("limit", limitToMapList(rs.Limits))
+	for key, value := range resourceSetObjectToMap(rs) {
+		if err := d.Set(key, value); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func limitToMapList(rsLimits client.ResourceSetLimits) []map[string]interface{} {
-	result := make([]map[string]interface{}, 0, 3)
-	if rsLimits.Cpus.Total != 0 {
-		result = append(result, map[string]interface{}{
-			"type":     "cpus",
-			"quantity": rsLimits.Cpus.Total,
-		})
+func resourceSetObjectToMap(rs resourceSetObject) map[string]interface{} {
+	subjects := rs.Subjects
+	if subjects == nil {
+		subjects = []string{}
+	}
+	objects := rs.Objects
+	if objects == nil {
+		objects = []string{}
+	}
+	ipPools := rs.IpPools
+	if ipPools == nil {
+		ipPools = []string{}
 	}
-	if rsLimits.Disk.Total != 0 {
+	return map[string]interface{}{
+		"name":     rs.Name,
+		"subjects": subjects,
+		"objects":  objects,
+		"ip_pools": ipPools,
+		"limit":    limitToMapList(rs.Limits),
+		"usage":    limitUsageToMapList(rs.Limits),
+	}
+}
+
+// sortedLimitTypes returns the types of the limits that are set, ignoring
+// the ones XO reports with a zero total.
+func sortedLimitTypes(rsLimits map[string]client.ResourceSetLimit) []string {
+	types := make([]string, 0, len(rsLimits))
+	for t, limit := range rsLimits {
+		if limit.Total != 0 {
+			types = append(types, t)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+func limitToMapList(rsLimits map[string]client.ResourceSetLimit) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(rsLimits))
+	for _, t := range sortedLimitTypes(rsLimits) {
 		result = append(result, map[string]interface{}{
-			"type":     "disk",
-			"quantity": rsLimits.Disk.Total,
+			"type":     t,
+			"quantity": rsLimits[t].Total,
 		})
 	}
-	if rsLimits.Memory.Total != 0 {
+
+	return result
+}
+
+func limitUsageToMapList(rsLimits map[string]client.ResourceSetLimit) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(rsLimits))
+	for _, t := range sortedLimitTypes(rsLimits) {
+		limit := rsLimits[t]
 		result = append(result, map[string]interface{}{
-			"type":     "memory",
-			"quantity": rsLimits.Memory.Total,
+			"type":      t,
+			"total":     limit.Total,
+			"available": limit.Available,
+			"used":      limit.Total - limit.Available,
 		})
 	}
 
 	return result
 }
 
+func getResourceSetObject(c client.XOClient, id string) (*resourceSetObject, error) {
+	var resourceSets []resourceSetObject
+	if err := callXoApi(c, "resourceSet.getAll", map[string]interface{}{}, &resourceSets); err != nil {
+		return nil, err
+	}
+
+	for _, rs := range resourceSets {
+		if rs.Id == id {
+			return &rs, nil
+		}
+	}
+	return nil, client.NotFound{Query: client.ResourceSet{Id: id}}
+}
+
+func setResourceSetIpPools(c client.XOClient, id string, ipPools *schema.Set) error {
+	pools := []string{}
+	for _, pool := range ipPools.List() {
+		pools = append(pools, pool.(string))
+	}
+	sort.Strings(pools)
+
+	params := map[string]interface{}{
+		"id":      id,
+		"ipPools": pools,
+	}
+	var success bool
+	if err := callXoApi(c, "resourceSet.set", params, &success); err != nil {
+		return fmt.Errorf("failed to set the IP pools of resource set %s: %w", id, err)
+	}
+	return nil
+}
+
 func setLimitByType(rs *client.ResourceSet, limitType string, limitValue int) {
 	rsLimit := client.ResourceSetLimit{
 		Available: limitValue,
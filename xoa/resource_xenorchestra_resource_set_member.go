@@ -0,0 +1,182 @@
+package xoa
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+// resourceSetMemberKind describes a kind of resource set member: the
+// attribute holding the member's id and the SDK calls that add and remove
+// it.
+type resourceSetMemberKind struct {
+	attr        string
+	description string
+	members     func(rs *resourceSetObject) []string
+	add         func(c client.XOClient, rs client.ResourceSet, id string) error
+	remove      func(c client.XOClient, rs client.ResourceSet, id string) error
+}
+
+var resourceSetObjectMember = resourceSetMemberKind{
+	attr:        "object_id",
+	description: "The id of the object (e.g. a storage repository, network or VM template) to add to the resource set.",
+	members:     func(rs *resourceSetObject) []string { return rs.Objects },
+	add: func(c client.XOClient, rs client.ResourceSet, id string) error {
+		return c.AddResourceSetObject(rs, id)
+	},
+	remove: func(c client.XOClient, rs client.ResourceSet, id string) error {
+		return c.RemoveResourceSetObject(rs, id)
+	},
+}
+
+var resourceSetSubjectMember = resourceSetMemberKind{
+	attr:        "subject_id",
+	description: "The uuid of the user or group to give access to the resource set.",
+	members:     func(rs *resourceSetObject) []string { return rs.Subjects },
+	add: func(c client.XOClient, rs client.ResourceSet, id string) error {
+		return c.AddResourceSetSubject(rs, id)
+	},
+	remove: func(c client.XOClient, rs client.ResourceSet, id string) error {
+		return c.RemoveResourceSetSubject(rs, id)
+	},
+}
+
+func resourceResourceSetObject() *schema.Resource {
+	return resourceSetMemberResource(resourceSetObjectMember, "Adds an object to a resource set without managing the set's other objects.")
+}
+
+func resourceResourceSetSubject() *schema.Resource {
+	return resourceSetMemberResource(resourceSetSubjectMember, "Gives a user or group access to a resource set without managing the set's other subjects.")
+}
+
+func resourceSetMemberResource(kind resourceSetMemberKind, description string) *schema.Resource {
+	return &schema.Resource{
+		Description: description + `
+
+**Note:** When the resource set is also managed with ` + "`xenorchestra_resource_set`" + `, set its ` + "`ignore_unmanaged_members`" + ` to ` + "`true`" + ` so that it does not remove the members added by this resource.`,
+		CreateContext: func(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+			return resourceSetMemberCreateContext(ctx, d, m, kind)
+		},
+		ReadContext: func(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+			return resourceSetMemberReadContext(ctx, d, m, kind)
+		},
+		DeleteContext: func(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+			return resourceSetMemberDeleteContext(ctx, d, m, kind)
+		},
+		Importer: &schema.ResourceImporter{
+			StateContext: func(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+				return resourceSetMemberImportContext(ctx, d, m, kind)
+			},
+		},
+
+		Schema: map[string]*schema.Schema{
+			"resource_set_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The id of the resource set.",
+			},
+			kind.attr: &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: kind.description,
+			},
+		},
+	}
+}
+
+func resourceSetMemberCreateContext(ctx context.Context, d *schema.ResourceData, m interface{}, kind resourceSetMemberKind) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	rsId := d.Get("resource_set_id").(string)
+	memberId := d.Get(kind.attr).(string)
+	if err := kind.add(c, client.ResourceSet{Id: rsId}, memberId); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId(resourceSetMemberId(rsId, memberId))
+	return resourceSetMemberReadContext(ctx, d, m, kind)
+}
+
+func resourceSetMemberReadContext(ctx context.Context, d *schema.ResourceData, m interface{}, kind resourceSetMemberKind) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	rsId, memberId, err := parseResourceSetMemberId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	rs, err := getResourceSetObject(c, rsId)
+	if _, ok := err.(client.NotFound); ok {
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	found := false
+	for _, id := range kind.members(rs) {
+		if id == memberId {
+			found = true
+			break
+		}
+	}
+	if !found {
+		d.SetId("")
+		return nil
+	}
+
+	if err := d.Set("resource_set_id", rsId); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set(kind.attr, memberId); err != nil {
+		return diag.FromErr(err)
+	}
+	return nil
+}
+
+func resourceSetMemberDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}, kind resourceSetMemberKind) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	rsId, memberId, err := parseResourceSetMemberId(d.Id())
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = kind.remove(c, client.ResourceSet{Id: rsId}, memberId)
+	if _, ok := err.(client.NotFound); err != nil && !ok {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+	return nil
+}
+
+func resourceSetMemberImportContext(ctx context.Context, d *schema.ResourceData, m interface{}, kind resourceSetMemberKind) ([]*schema.ResourceData, error) {
+	rsId, memberId, err := parseResourceSetMemberId(d.Id())
+	if err != nil {
+		return nil, err
+	}
+	d.Set("resource_set_id", rsId)
+	d.Set(kind.attr, memberId)
+	return []*schema.ResourceData{d}, nil
+}
+
+// resourceSetMemberId returns the id of a membership resource, which is
+// made of the resource set id and the member id separated by a colon.
+func resourceSetMemberId(rsId, memberId string) string {
+	return fmt.Sprintf("%s:%s", rsId, memberId)
+}
+
+func parseResourceSetMemberId(id string) (string, string, error) {
+	parts := strings.SplitN(id, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid resource set member id `%s`, expected `<resource set id>:<member id>`", id)
+	}
+	return parts[0], parts[1], nil
+}
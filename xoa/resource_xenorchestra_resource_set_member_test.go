@@ -0,0 +1,116 @@
+package xoa
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestAccResourceSetMember_addToSharedResourceSet(t *testing.T) {
+	rsResourceName := "xenorchestra_resource_set.shared"
+	objectResourceName := "xenorchestra_resource_set_object.sr"
+	subjectResourceName := "xenorchestra_resource_set_subject.team"
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceSetDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceSetMemberConfig(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(rsResourceName, "objects.#", "1"),
+					resource.TestCheckResourceAttr(rsResourceName, "subjects.#", "1"),
+					resource.TestCheckResourceAttrPair(objectResourceName, "resource_set_id", rsResourceName, "id"),
+					resource.TestCheckResourceAttr(objectResourceName, "object_id", accDefaultSr.Id),
+					resource.TestCheckResourceAttrPair(subjectResourceName, "resource_set_id", rsResourceName, "id"),
+					resource.TestCheckResourceAttr(subjectResourceName, "subject_id", "team subject"),
+				),
+			},
+			{
+				// The members added by the membership resources must not
+				// show up as drift on the resource set
+				Config:   testAccResourceSetMemberConfig(),
+				PlanOnly: true,
+			},
+			{
+				ResourceName:      objectResourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+			{
+				ResourceName:      subjectResourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccResourceSetMemberConfig() string {
+	return fmt.Sprintf(`
+resource "xenorchestra_resource_set" "shared" {
+    name = "%s-shared"
+    ignore_unmanaged_members = true
+    limit {
+	type = "cpus"
+	quantity = 4
+    }
+
+    subjects = ["owner subject"]
+    objects = ["%s"]
+}
+
+resource "xenorchestra_resource_set_object" "sr" {
+    resource_set_id = xenorchestra_resource_set.shared.id
+    object_id = "%s"
+}
+
+resource "xenorchestra_resource_set_subject" "team" {
+    resource_set_id = xenorchestra_resource_set.shared.id
+    subject_id = "team subject"
+}
+`, rsName, accTestPool.Id, accDefaultSr.Id)
+}
+
+func Test_parseResourceSetMemberId(t *testing.T) {
+	tests := []struct {
+		id       string
+		rsId     string
+		memberId string
+		err      bool
+	}{
+		{id: resourceSetMemberId("rs", "member"), rsId: "rs", memberId: "member"},
+		{id: "rs:member:with:colons", rsId: "rs", memberId: "member:with:colons"},
+		{id: "rs", err: true},
+		{id: ":member", err: true},
+		{id: "rs:", err: true},
+	}
+
+	for _, test := range tests {
+		rsId, memberId, err := parseResourceSetMemberId(test.id)
+		if test.err {
+			if err == nil {
+				t.Errorf("expected parsing `%s` to fail", test.id)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("failed to parse `%s`: %v", test.id, err)
+		}
+		if rsId != test.rsId || memberId != test.memberId {
+			t.Errorf("expected `%s` to be parsed into (%s, %s), received (%s, %s)", test.id, test.rsId, test.memberId, rsId, memberId)
+		}
+	}
+}
+
+func Test_intersectMembers(t *testing.T) {
+	managed := schema.NewSet(schema.HashString, []interface{}{"managed", "removed outside of terraform"})
+
+	expected := []string{"managed"}
+	if members := intersectMembers([]string{"unmanaged", "managed"}, managed); !reflect.DeepEqual(members, expected) {
+		t.Errorf("expected %v, received %v", expected, members)
+	}
+}
@@ -2,6 +2,8 @@ package xoa
 
 import (
 	"fmt"
+	"reflect"
+	"regexp"
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -197,6 +199,87 @@ func TestAccResourceSet_removeSubjectsAndObjects(t *testing.T) {
 	})
 }
 
+func TestAccResourceSet_usage(t *testing.T) {
+	resourceName := "xenorchestra_resource_set.bar"
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckResourceSetDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccResourceSetConfig(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resourceSetCompositeChecks(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "ip_pools.#", "0"),
+					resource.TestCheckResourceAttr(resourceName, "usage.#", "2"),
+					resource.TestCheckResourceAttr(resourceName, "usage.0.type", "cpus"),
+					resource.TestCheckResourceAttr(resourceName, "usage.0.total", "4"),
+					resource.TestCheckResourceAttr(resourceName, "usage.0.available", "4"),
+					resource.TestCheckResourceAttr(resourceName, "usage.0.used", "0"),
+					resource.TestCheckResourceAttr(resourceName, "usage.1.type", "disk"),
+				),
+			},
+		},
+	})
+}
+
+func TestAccResourceSet_limitOnUnknownIpPool(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+resource "xenorchestra_resource_set" "bar" {
+    name = "%s"
+    limit {
+	type = "not-an-ip-pool"
+	quantity = 4
+    }
+}
+`, rsName),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("limit type `not-an-ip-pool` must be one of cpus, disk, memory or the id of an IP pool listed in ip_pools"),
+			},
+		},
+	})
+}
+
+func Test_resourceSetObjectToMap(t *testing.T) {
+	rs := resourceSetObject{
+		Id:       "rs id",
+		Name:     "rs",
+		Subjects: []string{"subject"},
+		IpPools:  []string{"ip pool"},
+		Limits: map[string]client.ResourceSetLimit{
+			"memory":  {Total: 1024, Available: 256},
+			"cpus":    {Total: 4, Available: 4},
+			"disk":    {Total: 0, Available: 0},
+			"ip pool": {Total: 10, Available: 7},
+		},
+	}
+
+	expected := map[string]interface{}{
+		"name":     "rs",
+		"subjects": []string{"subject"},
+		"objects":  []string{},
+		"ip_pools": []string{"ip pool"},
+		"limit": []map[string]interface{}{
+			{"type": "cpus", "quantity": 4},
+			{"type": "ip pool", "quantity": 10},
+			{"type": "memory", "quantity": 1024},
+		},
+		"usage": []map[string]interface{}{
+			{"type": "cpus", "total": 4, "available": 4, "used": 0},
+			{"type": "ip pool", "total": 10, "available": 7, "used": 3},
+			{"type": "memory", "total": 1024, "available": 256, "used": 768},
+		},
+	}
+	if data := resourceSetObjectToMap(rs); !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected %+v, received %+v", expected, data)
+	}
+}
+
 func testAccResourceSetExists(resourceName string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[resourceName]
@@ -0,0 +1,309 @@
+package xoa
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+var validTemplateModes = []string{"clone", "convert"}
+
+func resourceTemplateRecord() *schema.Resource {
+	return &schema.Resource{
+		Description:   "Creates a VM template from an existing VM. The resulting template's id can be used as the `template` of a `xenorchestra_vm`.\n\n**Note:** The source VM must be halted before it can be cloned or converted. Set `halt_source_vm` to have the provider halt it.",
+		CreateContext: resourceTemplateCreateContext,
+		ReadContext:   resourceTemplateReadContext,
+		UpdateContext: resourceTemplateUpdateContext,
+		DeleteContext: resourceTemplateDeleteContext,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceTemplateImportContext,
+		},
+		Schema: map[string]*schema.Schema{
+			"source_vm_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The id of the VM the template is created from.",
+			},
+			"mode": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "clone",
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(validTemplateModes, false),
+				Description:  "Whether the source VM is cloned into a template (`clone`) or converted in place (`convert`). When converting, the source VM no longer exists as a VM and destroying the template deletes it.",
+			},
+			"full_copy": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				ForceNew:    true,
+				Description: "Whether the source VM's disks are fully copied instead of fast cloned. Only used when `mode` is `clone`.",
+			},
+			"halt_source_vm": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether the source VM should be halted if it is running. When false, creating the template fails if the source VM is not halted.",
+			},
+			"name_label": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The name of the template.",
+			},
+			"name_description": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The description of the template.",
+			},
+			"tags": resourceTags(),
+			"other_config": &schema.Schema{
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "The other_config key value pairs to set on the template, such as `install-methods` or the `disks` provisioning XML. Only the keys specified here are managed; removing a key removes it from the template.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"pool_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The id of the pool the template belongs to.",
+			},
+			"uuid": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The uuid of the template.",
+			},
+		},
+	}
+}
+
+func resourceTemplateCreateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+	sourceId := d.Get("source_vm_id").(string)
+
+	vm, err := c.GetVm(client.Vm{Id: sourceId})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if vm.PowerState != client.HaltedPowerState {
+		if !d.Get("halt_source_vm").(bool) {
+			return diag.FromErr(fmt.Errorf("source vm `%s` must be halted to create a template from it but is %s. Halt it or set `halt_source_vm`", sourceId, vm.PowerState))
+		}
+		tflog.Debug(ctx, "Halting source vm before creating template", map[string]interface{}{
+			"vm_id": sourceId,
+		})
+		if err := c.HaltVm(sourceId); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	templateId := sourceId
+	if d.Get("mode").(string) == "clone" {
+		params := map[string]interface{}{
+			"id":        sourceId,
+			"name":      d.Get("name_label").(string),
+			"full_copy": d.Get("full_copy").(bool),
+		}
+		if err := callXoApi(c, "vm.clone", params, &templateId); err != nil {
+			return diag.FromErr(fmt.Errorf("failed to clone vm `%s`: %w", sourceId, err))
+		}
+		// Track the clone right away so that it is deleted along with the
+		// tainted resource if the following steps fail.
+		d.SetId(templateId)
+	}
+
+	var success bool
+	if err := callXoApi(c, "vm.convertToTemplate", map[string]interface{}{"id": templateId}, &success); err != nil {
+		return diag.FromErr(fmt.Errorf("failed to convert vm `%s` to a template: %w", templateId, err))
+	}
+	d.SetId(templateId)
+
+	params := map[string]interface{}{
+		"id":               templateId,
+		"name_label":       d.Get("name_label").(string),
+		"name_description": d.Get("name_description").(string),
+	}
+	if err := callXoApi(c, "vm.set", params, &success); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if otherConfig := d.Get("other_config").(map[string]interface{}); len(otherConfig) > 0 {
+		if err := setVmOtherConfig(c, templateId, otherConfig); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	for _, tag := range d.Get("tags").(*schema.Set).List() {
+		if err := c.AddTag(templateId, tag.(string)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceTemplateReadContext(ctx, d, m)
+}
+
+func resourceTemplateReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	tmpl, err := getTemplateById(c, d.Id())
+	if _, ok := err.(client.NotFound); ok {
+		// A clone that failed to be converted is still a VM, keep it in the
+		// state so that the tainted resource deletes it.
+		if d.Get("mode").(string) == "clone" {
+			if _, err := c.GetVm(client.Vm{Id: d.Id()}); err == nil {
+				return nil
+			}
+		}
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diag.FromErr(templateToData(tmpl, d))
+}
+
+func resourceTemplateUpdateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+	id := d.Id()
+
+	if d.HasChanges("name_label", "name_description") {
+		params := map[string]interface{}{
+			"id":               id,
+			"name_label":       d.Get("name_label").(string),
+			"name_description": d.Get("name_description").(string),
+		}
+		var success bool
+		if err := callXoApi(c, "vm.set", params, &success); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if d.HasChange("other_config") {
+		o, n := d.GetChange("other_config")
+		otherConfig := n.(map[string]interface{})
+		for k := range o.(map[string]interface{}) {
+			if _, ok := otherConfig[k]; !ok {
+				// A null value removes the key
+				otherConfig[k] = nil
+			}
+		}
+		if err := setVmOtherConfig(c, id, otherConfig); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if d.HasChange("tags") {
+		o, n := d.GetChange("tags")
+		oTags := o.(*schema.Set)
+		nTags := n.(*schema.Set)
+
+		for _, removal := range oTags.Difference(nTags).List() {
+			if err := c.RemoveTag(id, removal.(string)); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+
+		for _, addition := range nTags.Difference(oTags).List() {
+			if err := c.AddTag(id, addition.(string)); err != nil {
+				return diag.FromErr(err)
+			}
+		}
+	}
+
+	return resourceTemplateReadContext(ctx, d, m)
+}
+
+func resourceTemplateDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	if err := c.DeleteVm(d.Id()); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId("")
+	return nil
+}
+
+func resourceTemplateImportContext(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	c := m.(client.XOClient)
+
+	tmpl, err := getTemplateById(c, d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	// An imported template is treated as if it was converted in place so
+	// that the source_vm_id does not force a replacement.
+	d.Set("source_vm_id", tmpl.Id)
+	d.Set("mode", "convert")
+	d.Set("full_copy", false)
+	d.Set("halt_source_vm", false)
+	if err := templateToData(tmpl, d); err != nil {
+		return nil, err
+	}
+	return []*schema.ResourceData{d}, nil
+}
+
+func getTemplateById(c client.XOClient, id string) (*templateObject, error) {
+	templates := map[string]templateObject{}
+	if err := getXoObjects(c, map[string]interface{}{"type": "VM-template", "id": id}, &templates); err != nil {
+		return nil, err
+	}
+
+	tmpl, ok := templates[id]
+	if !ok {
+		return nil, client.NotFound{Query: client.Template{Id: id}}
+	}
+	return &tmpl, nil
+}
+
+func templateToData(tmpl *templateObject, d *schema.ResourceData) error {
+	d.SetId(tmpl.Id)
+
+	// Only track the other_config keys that are managed since XAPI and XO
+	// populate many of them on their own.
+	otherConfig := map[string]interface{}{}
+	for k := range d.Get("other_config").(map[string]interface{}) {
+		if v, ok := tmpl.Other[k]; ok {
+			otherConfig[k] = v
+		}
+	}
+
+	keys := map[string]interface{}{
+		"name_label":       tmpl.NameLabel,
+		"name_description": tmpl.NameDescription,
+		"pool_id":          tmpl.PoolId,
+		"uuid":             tmpl.Uuid,
+		"tags":             tmpl.Tags,
+		"other_config":     otherConfig,
+	}
+	for k, v := range keys {
+		if err := d.Set(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setVmOtherConfig merges otherConfig into the other_config of the VM or
+// template. Keys with a nil value are removed.
+func setVmOtherConfig(c client.XOClient, id string, otherConfig map[string]interface{}) error {
+	params := map[string]interface{}{
+		"id":    id,
+		"other": otherConfig,
+	}
+	var success bool
+	if err := callXoApi(c, "vm.set", params, &success); err != nil {
+		return fmt.Errorf("failed to set other_config of `%s`: %w", id, err)
+	}
+	return nil
+}
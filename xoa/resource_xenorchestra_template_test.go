@@ -0,0 +1,139 @@
+package xoa
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func TestAccXenorchestraTemplate_cloneAndUpdate(t *testing.T) {
+	resourceName := "xenorchestra_template.bar"
+	vmName := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	templateName := fmt.Sprintf("%s - template", vmName)
+	updatedName := fmt.Sprintf("%s - updated", templateName)
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckXenorchestraTemplateDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccTemplateResourceConfig(vmName, templateName, "tag1", "minimal"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccTemplateExists(resourceName),
+					resource.TestCheckResourceAttrSet(resourceName, "id"),
+					resource.TestCheckResourceAttrSet(resourceName, "uuid"),
+					resource.TestCheckResourceAttr(resourceName, "pool_id", accTestPool.Id),
+					resource.TestCheckResourceAttr(resourceName, "name_label", templateName),
+					resource.TestCheckResourceAttr(resourceName, "tags.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "other_config.install-methods", "minimal"),
+					resource.TestCheckResourceAttrPair(resourceName, "source_vm_id", "xenorchestra_vm.bar", "id"),
+					resource.TestCheckResourceAttrPair("data.xenorchestra_template.created", "id", resourceName, "id"),
+				),
+			},
+			{
+				Config: testAccTemplateResourceConfig(vmName, updatedName, "tag2", "cdrom,nfs"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccTemplateExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "name_label", updatedName),
+					resource.TestCheckResourceAttr(resourceName, "tags.#", "1"),
+					resource.TestCheckResourceAttr(resourceName, "other_config.install-methods", "cdrom,nfs"),
+				),
+			},
+			{
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"source_vm_id", "mode", "other_config"},
+			},
+		},
+	})
+}
+
+func testAccTemplateExists(resourceName string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", resourceName)
+		}
+
+		if rs.Primary.ID == "" {
+			return fmt.Errorf("No template Id is set")
+		}
+
+		c, err := client.NewClient(client.GetConfigFromEnv())
+		if err != nil {
+			return err
+		}
+
+		_, err = getTemplateById(c, rs.Primary.ID)
+		return err
+	}
+}
+
+func testAccCheckXenorchestraTemplateDestroy(s *terraform.State) error {
+	c, err := client.NewClient(client.GetConfigFromEnv())
+	if err != nil {
+		return err
+	}
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "xenorchestra_template" {
+			continue
+		}
+
+		_, err := getTemplateById(c, rs.Primary.ID)
+		if _, ok := err.(client.NotFound); ok {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("template (%s) still exists", rs.Primary.ID)
+	}
+	return nil
+}
+
+func testAccTemplateResourceConfig(vmName, templateName, tag, installMethods string) string {
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
+data "xenorchestra_network" "network" {
+    name_label = "%s"
+    pool_id = "%s"
+}
+
+resource "xenorchestra_vm" "bar" {
+    memory_max = 4295000000
+    cpus  = 1
+    cloud_config = xenorchestra_cloud_config.bar.template
+    name_label = "%s"
+    template = data.xenorchestra_template.template.id
+    power_state = "Halted"
+    network {
+	network_id = data.xenorchestra_network.network.id
+    }
+
+    disk {
+      sr_id = "%s"
+      name_label = "disk 1"
+      size = 10001317888
+    }
+}
+
+resource "xenorchestra_template" "bar" {
+    source_vm_id = xenorchestra_vm.bar.id
+    name_label = "%s"
+    name_description = "created from a vm"
+    tags = ["%s"]
+    other_config = {
+      "install-methods" = "%s"
+    }
+}
+
+data "xenorchestra_template" "created" {
+    name_label = xenorchestra_template.bar.name_label
+    pool_id = "%s"
+}
+`, accDefaultNetwork.NameLabel, accTestPool.Id, vmName, accDefaultSr.Id, templateName, tag, installMethods, accTestPool.Id)
+}
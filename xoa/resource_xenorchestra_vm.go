@@ -60,6 +60,15 @@ func resourceVm() *schema.Resource {
 	delete(vmSchema, "cdrom")
 	delete(vmSchema, "installation_method")
 	delete(vmSchema, "destroy_cloud_config_vdi_after_boot")
+	delete(vmSchema, "cloud_config_id")
+	delete(vmSchema, "cloud_config_index")
+	delete(vmSchema, "validate_cloud_init")
+	delete(vmSchema, "imported")
+	delete(vmSchema, "recreate_config_drive_on_change")
+	delete(vmSchema, "cloud_vendor_data")
+	delete(vmSchema, "cloud_meta_data")
+	delete(vmSchema, "cloud_init_datasource")
+	vmSchema["cloud_config"].ConflictsWith = nil
 	vmSchema["id"] = &schema.Schema{
 		Type:     schema.TypeString,
 		Required: true,
@@ -79,17 +88,48 @@ func vmCustomizeDiff(ctx context.Context, diff *schema.ResourceDiff, v interface
 	// Check power state and cloudConfig constraints
 	destroyCloudConfig := diff.Get("destroy_cloud_config_vdi_after_boot").(bool)
 	cloudConfig := diff.Get("cloud_config").(string)
+	cloudConfigId := diff.Get("cloud_config_id").(string)
 	powerState := diff.Get("power_state").(string)
 	powerStateChanged := diff.HasChange("power_state")
 
-	if destroyCloudConfig && cloudConfig == "" {
+	if destroyCloudConfig && cloudConfig == "" && cloudConfigId == "" {
 		return fmt.Errorf("cloud_config must be specified when destroy_cloud_config_vdi_after_boot is set to `true`")
 	}
 
+	if destroyCloudConfig && vmUsesProviderConfigDrive(diff) {
+		return fmt.Errorf("destroy_cloud_config_vdi_after_boot is only supported with Xen Orchestra's config drive, it cannot be used with cloud_vendor_data, cloud_meta_data or the `%s` cloud_init_datasource", configDriveDatasource)
+	}
+
 	if powerStateChanged && destroyCloudConfig && powerState != client.RunningPowerState {
 		return fmt.Errorf("power_state must be `%s` when destroy_cloud_config_vdi_after_boot set to `true`", client.RunningPowerState)
 	}
 
+	// Check the cloud-init documents, values only known after apply are
+	// skipped.
+	if diff.Get("validate_cloud_init").(bool) {
+		if diff.HasChange("cloud_config") && diff.NewValueKnown("cloud_config") && cloudConfig != "" {
+			if err := validateCloudInitUserData(cloudConfig); err != nil {
+				return fmt.Errorf("invalid cloud_config: %w. Set validate_cloud_init to `false` to skip this check", err)
+			}
+		}
+		vendorData := diff.Get("cloud_vendor_data").(string)
+		if diff.HasChange("cloud_vendor_data") && diff.NewValueKnown("cloud_vendor_data") && vendorData != "" {
+			if err := validateCloudInitUserData(vendorData); err != nil {
+				return fmt.Errorf("invalid cloud_vendor_data: %w. Set validate_cloud_init to `false` to skip this check", err)
+			}
+		}
+		cloudNetworkConfig := diff.Get("cloud_network_config").(string)
+		if diff.HasChanges("cloud_network_config", "cloud_init_datasource") && diff.NewValueKnown("cloud_network_config") && cloudNetworkConfig != "" {
+			validate := validateCloudInitNetworkConfig
+			if diff.Get("cloud_init_datasource").(string) == configDriveDatasource {
+				validate = validateOpenStackNetworkData
+			}
+			if err := validate(cloudNetworkConfig); err != nil {
+				return fmt.Errorf("invalid cloud_network_config: %w. Set validate_cloud_init to `false` to skip this check", err)
+			}
+		}
+	}
+
 	// Check memory constraints
 	memoryMin := diff.Get("memory_min").(int)
 	memoryMax := diff.Get("memory_max").(int)
@@ -144,16 +184,18 @@ func resourceVmSchema() map[string]*schema.Schema {
 			Optional:    true,
 		},
 		"cloud_network_config": &schema.Schema{
-			Description: "The content of the cloud-init network configuration for the VM (uses [version 1](https://cloudinit.readthedocs.io/en/latest/topics/network-config-format-v1.html))",
-			Type:        schema.TypeString,
-			Optional:    true,
+			Description:      "The content of the cloud-init network configuration for the VM (uses [version 1](https://cloudinit.readthedocs.io/en/latest/topics/network-config-format-v1.html)). The `{network.<index>.ip_pool_address}` placeholders are replaced with the address allocated to the given network block from its `ip_pool_id`. With the `ConfigDrive` `cloud_init_datasource`, it is an OpenStack `network_data.json` document instead.",
+			Type:             schema.TypeString,
+			Optional:         true,
+			DiffSuppressFunc: suppressUnknownCloudInitDiff,
 		},
 		"clone_type": &schema.Schema{
-			Description:  "The type of clone to perform for the VM. Possible values include `fast` or `full` and defaults to `fast`. In order to perform a `full` clone, the VM template must not be a disk template.",
-			Type:         schema.TypeString,
-			Optional:     true,
-			Default:      client.CloneTypeFastClone,
-			ValidateFunc: validation.StringInSlice(validCloneType, false),
+			Description:      "The type of clone to perform for the VM. Possible values include `fast` or `full` and defaults to `fast`. In order to perform a `full` clone, the VM template must not be a disk template.",
+			Type:             schema.TypeString,
+			Optional:         true,
+			Default:          client.CloneTypeFastClone,
+			DiffSuppressFunc: suppressUnknownCreateOnlyDiff,
+			ValidateFunc:     validation.StringInSlice(validCloneType, false),
 		},
 		"auto_poweron": &schema.Schema{
 			Type:        schema.TypeBool,
@@ -182,11 +224,12 @@ func resourceVmSchema() map[string]*schema.Schema {
 			Default:      client.RunningPowerState,
 		},
 		"installation_method": &schema.Schema{
-			Type:          schema.TypeString,
-			Description:   "This cannot be used with `cdrom`. Possible values are `network` which allows a VM to boot via PXE.",
-			Optional:      true,
-			ValidateFunc:  validation.StringInSlice(validInstallationMethods, false),
-			ConflictsWith: []string{"cdrom"},
+			Type:             schema.TypeString,
+			Description:      "This cannot be used with `cdrom`. Possible values are `network` which allows a VM to boot via PXE.",
+			Optional:         true,
+			DiffSuppressFunc: suppressUnknownCreateOnlyDiff,
+			ValidateFunc:     validation.StringInSlice(validInstallationMethods, false),
+			ConflictsWith:    []string{"cdrom"},
 		},
 		"high_availability": &schema.Schema{
 			Type:         schema.TypeString,
@@ -196,22 +239,84 @@ func resourceVmSchema() map[string]*schema.Schema {
 			ValidateFunc: validation.StringInSlice(validHaOptions, false),
 		},
 		"template": &schema.Schema{
-			Type:        schema.TypeString,
-			Description: "The ID of the VM template to create the new VM from.",
-			Required:    true,
-			ForceNew:    true,
+			Type:             schema.TypeString,
+			Description:      "The ID of the VM template to create the new VM from.",
+			Required:         true,
+			ForceNew:         true,
+			DiffSuppressFunc: suppressUnknownCreateOnlyDiff,
 		},
 		"cloud_config": &schema.Schema{
-			Description: "The content of the cloud-init config to use. See the cloud init docs for more [information](https://cloudinit.readthedocs.io/en/latest/topics/examples.html).",
-			Type:        schema.TypeString,
+			Description:      "The content of the cloud-init config to use. See the cloud init docs for more [information](https://cloudinit.readthedocs.io/en/latest/topics/examples.html).",
+			Type:             schema.TypeString,
+			Optional:         true,
+			DiffSuppressFunc: suppressUnknownCloudInitDiff,
+			ConflictsWith:    []string{"cloud_config_id"},
+		},
+		"cloud_config_id": &schema.Schema{
+			Description:      "The id of the stored cloud config (see `xenorchestra_cloud_config`) to use. As in the Xen Orchestra UI, `{name}` is replaced with the VM's `name_label` and `%` with `cloud_config_index` in its template.",
+			Type:             schema.TypeString,
+			Optional:         true,
+			DiffSuppressFunc: suppressUnknownCloudInitDiff,
+			ConflictsWith:    []string{"cloud_config"},
+		},
+		"cloud_vendor_data": &schema.Schema{
+			Description:      "The content of the cloud-init vendor-data to use, in the same formats as `cloud_config`. Setting it makes the provider generate the VM's config drive, see `cloud_init_datasource`.",
+			Type:             schema.TypeString,
+			Optional:         true,
+			DiffSuppressFunc: suppressUnknownCloudInitDiff,
+		},
+		"cloud_meta_data": &schema.Schema{
+			Description: "The cloud-init meta-data of the VM. Setting it makes the provider generate the VM's config drive, see `cloud_init_datasource`.",
+			Type:        schema.TypeList,
 			Optional:    true,
+			MaxItems:    1,
+			Elem: &schema.Resource{
+				Schema: map[string]*schema.Schema{
+					"instance_id": &schema.Schema{
+						Description: "The instance-id of the VM. Defaults to the VM's UUID followed by a hash of its cloud-init documents.",
+						Type:        schema.TypeString,
+						Optional:    true,
+					},
+					"local_hostname": &schema.Schema{
+						Description: "The local-hostname of the VM.",
+						Type:        schema.TypeString,
+						Optional:    true,
+					},
+				},
+			},
 		},
-		"destroy_cloud_config_vdi_after_boot": &schema.Schema{
+		"cloud_init_datasource": &schema.Schema{
+			Description:  "The cloud-init datasource the config drive is built for. Possible values are `NoCloud` (a drive labelled `cidata`) and `ConfigDrive` (an OpenStack config drive labelled `config-2`, whose `cloud_network_config` is an OpenStack `network_data.json` document). Xen Orchestra only creates `NoCloud` drives holding `cloud_config` and `cloud_network_config`; with `ConfigDrive`, `cloud_vendor_data` or `cloud_meta_data` the provider creates the VM halted, attaches its own config drive and then starts it. Defaults to `NoCloud`.",
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      noCloudDatasource,
+			ValidateFunc: validation.StringInSlice(validCloudInitDatasources, false),
+		},
+		"cloud_config_index": &schema.Schema{
+			Description: "The index of the VM that replaces `%` in the `cloud_config_id` template. Xen Orchestra numbers the VMs it creates in batch from 1, use e.g. `count.index + 1` to get the same result.",
+			Type:        schema.TypeInt,
+			Optional:    true,
+			Default:     1,
+		},
+		"validate_cloud_init": &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     true,
+			Description: "Whether `cloud_config`, `cloud_vendor_data` and `cloud_network_config` are validated during plan. The user-data and vendor-data must start with one of the headers cloud-init recognizes, e.g. `#cloud-config` (and be a YAML mapping), `#cloud-config-archive` (and be a YAML list), `#include`, `## template: jinja` or `#!`, be gzip compressed or be a MIME multipart document. The network config must be a valid version 1 or 2 document, or an OpenStack `network_data.json` document with the `ConfigDrive` datasource. Set to `false` to skip these checks, e.g. for user-data formats the provider does not know. Defaults to `true`.",
+		},
+		"recreate_config_drive_on_change": &schema.Schema{
 			Type:        schema.TypeBool,
 			Optional:    true,
 			Default:     false,
-			ForceNew:    true,
-			Description: "Determines whether the cloud config VDI should be deleted once the VM has booted. Defaults to `false`. If set to `true`, power_state must be set to `Running`.",
+			Description: "Whether changes to `cloud_config`, `cloud_config_id`, `cloud_config_index`, `cloud_network_config`, `cloud_vendor_data`, `cloud_meta_data` or `cloud_init_datasource` replace the config drive of an existing VM. Unless `cloud_meta_data` sets it, the new drive has a new instance-id, so cloud-init applies the updated documents, as on a new instance, on the VM's next boot. The VM is not rebooted and the new drive is kept even if `destroy_cloud_config_vdi_after_boot` is set. Defaults to `false`, which only stores the change in the state.",
+		},
+		"destroy_cloud_config_vdi_after_boot": &schema.Schema{
+			Type:             schema.TypeBool,
+			Optional:         true,
+			Default:          false,
+			ForceNew:         true,
+			DiffSuppressFunc: suppressUnknownCreateOnlyDiff,
+			Description:      "Determines whether the cloud config VDI should be deleted once the VM has booted. Defaults to `false`. If set to `true`, power_state must be set to `Running`.",
 		},
 		"core_os": &schema.Schema{
 			Type:     schema.TypeBool,
@@ -293,6 +398,47 @@ $ xo-cli xo.getAllObjects filter='json:{"id": "cf7b5d7d-3cd5-6b7c-5025-5c935c8cd
 				Type: schema.TypeString,
 			},
 		},
+		"imported": &schema.Schema{
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: "Whether the VM was imported and not updated since. The template, clone_type, installation_method, destroy_cloud_config_vdi_after_boot and cloud-init attributes of an imported VM are only stored in the state by its next update.",
+		},
+		"guest_os": &schema.Schema{
+			Type:        schema.TypeMap,
+			Computed:    true,
+			Description: "The operating system reported by the guest. The 'name', 'distro', 'version' and 'kernel' keys are populated when available. This is only accessible if guest-tools is installed in the VM.",
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+		},
+		"guest_hostname": &schema.Schema{
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The hostname reported by the guest. This is only accessible if guest-tools is installed in the VM and the guest agent reports it.",
+		},
+		"guest_memory_usage": &schema.Schema{
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "The memory in bytes the guest reports as used. This is only accessible if guest-tools is installed in the VM.",
+		},
+		"pv_drivers_version": &schema.Schema{
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The version of the PV drivers installed in the guest.",
+		},
+		"pv_drivers_up_to_date": &schema.Schema{
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: "Whether the PV drivers installed in the guest are up to date.",
+		},
+		"disk_usage": &schema.Schema{
+			Type:        schema.TypeMap,
+			Computed:    true,
+			Description: "The space in bytes used by each of the VM's disks, keyed by the disk's position (see `disk.*.position`).",
+			Elem: &schema.Schema{
+				Type: schema.TypeInt,
+			},
+		},
 		"vga": &schema.Schema{
 			Type:         schema.TypeString,
 			Default:      "std",
@@ -392,6 +538,17 @@ $ xo-cli xo.getAllObjects filter='json:{"id": "cf7b5d7d-3cd5-6b7c-5025-5c935c8cd
 							Type: schema.TypeString,
 						},
 					},
+					"ip_pool_id": &schema.Schema{
+						Type:        schema.TypeString,
+						Optional:    true,
+						ForceNew:    true,
+						Description: "The id of the IP pool to allocate the network interface's address from. The address is allocated when the VM is created and released when the VM is destroyed, so adding or changing it, including adding a network block with an `ip_pool_id` to an existing VM, replaces the VM. The pool must be available on the `network_id` network. Since XO restricts the network interface's traffic to its allocated address, the guest must be configured with it, e.g. by using the `{network.<index>.ip_pool_address}` placeholder in `cloud_network_config`.",
+					},
+					"ip_pool_address": &schema.Schema{
+						Type:        schema.TypeString,
+						Computed:    true,
+						Description: "The address allocated from the `ip_pool_id` IP pool.",
+					},
 					"expected_ip_cidr": &schema.Schema{
 						Type:        schema.TypeString,
 						Default:     "",
@@ -476,7 +633,7 @@ Xen Orchestra allows templating cloudinit config through its own custom mechanis
 * "{name}" is replaced with the VM's name
 * "%" is replaced with the VM's index
 
-This does not work in terraform since that is applied on Xen Orchestra's client side (Javascript). Terraform provides a "templatefile" function that allows for a similar substitution. Please see the example below for more details.
+Xen Orchestra applies this substitution on its client side (Javascript), so it does not apply to the ` + "`cloud_config`" + ` content. When a VM references a stored ` + "`xenorchestra_cloud_config`" + ` with ` + "`cloud_config_id`" + `, the provider performs the same substitution, using ` + "`cloud_config_index`" + ` as the VM's index. Otherwise, Terraform provides a "templatefile" function that allows for a similar substitution. Please see the example below for more details.
 `,
 		CustomizeDiff: vmCustomizeDiff,
 		CreateContext: resourceVmCreateContext,
@@ -581,6 +738,17 @@ func resourceVmCreateContext(ctx context.Context, d *schema.ResourceData, m inte
 		}
 	}
 
+	ipPoolAddresses, err := pickIpPoolAddresses(c, networks)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer releaseIpPoolReservations(networks, ipPoolAddresses)
+
+	cloudConfig, err := vmCloudConfig(c, d)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
 	var rs *client.FlatResourceSet
 	if rsId, ok := d.GetOk("resource_set"); ok {
 		rs = &client.FlatResourceSet{
@@ -596,7 +764,7 @@ func resourceVmCreateContext(ctx context.Context, d *schema.ResourceData, m inte
 		NameLabel:                      d.Get("name_label").(string),
 		NameDescription:                d.Get("name_description").(string),
 		Template:                       d.Get("template").(string),
-		CloudConfig:                    d.Get("cloud_config").(string),
+		CloudConfig:                    cloudConfig,
 		CloneType:                      d.Get("clone_type").(string),
 		ResourceSet:                    rs,
 		HA:                             d.Get("high_availability").(string),
@@ -605,7 +773,7 @@ func resourceVmCreateContext(ctx context.Context, d *schema.ResourceData, m inte
 		CPUs: client.CPUs{
 			Number: d.Get("cpus").(int),
 		},
-		CloudNetworkConfig: d.Get("cloud_network_config").(string),
+		CloudNetworkConfig: substituteIpPoolAddresses(d.Get("cloud_network_config").(string), ipPoolAddresses),
 		Memory: client.MemoryObject{
 			Static: []int{
 				0, d.Get("memory_max").(int),
@@ -639,6 +807,17 @@ func resourceVmCreateContext(ctx context.Context, d *schema.ResourceData, m inte
 		createVmParams.CoresPerSocket = &cps
 	}
 
+	// XO can only create NoCloud config drives holding the user-data and
+	// network-config. Otherwise the VM is created halted, without config
+	// drive, and started once the provider's config drive is attached.
+	providerConfigDrive := vmUsesProviderConfigDrive(d)
+	if providerConfigDrive {
+		createVmParams.CloudConfig = ""
+		createVmParams.CloudNetworkConfig = ""
+		createVmParams.PowerState = client.HaltedPowerState
+		createVmParams.WaitForIps = map[string]string{}
+	}
+
 	vm, err := c.CreateVm(createVmParams, d.Timeout(schema.TimeoutCreate))
 	if err != nil {
 		return diag.FromErr(err)
@@ -659,7 +838,7 @@ func resourceVmCreateContext(ctx context.Context, d *schema.ResourceData, m inte
 		}
 
 		var success bool
-		err := c.(*client.Client).Call("vm.set", params, &success)
+		err := callXoApi(c, "vm.set", params, &success)
 		if err != nil {
 			return diag.FromErr(fmt.Errorf("failed to update vm blocked operations: %w", err))
 		}
@@ -672,6 +851,27 @@ func resourceVmCreateContext(ctx context.Context, d *schema.ResourceData, m inte
 		return diag.FromErr(err)
 	}
 
+	if len(ipPoolAddresses) > 0 {
+		// The addresses are stored before they are allocated so that the
+		// state of a VM tainted by a failed allocation records them.
+		for index, address := range ipPoolAddresses {
+			networks[index].(map[string]interface{})["ip_pool_address"] = address
+		}
+		if err := d.Set("network", networks); err != nil {
+			return diag.FromErr(err)
+		}
+		if err := allocateIpPoolAddresses(c, vifs, networks, ipPoolAddresses); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if providerConfigDrive {
+		vm, err = startVmWithConfigDrive(ctx, c, d, vm, ipPoolAddresses, waitForIpsMap)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	vmDisks, err := c.GetDisks(vm)
 	if err != nil {
 		return diag.FromErr(err)
@@ -682,7 +882,12 @@ func resourceVmCreateContext(ctx context.Context, d *schema.ResourceData, m inte
 		return diag.FromErr(err)
 	}
 
-	err = recordToData(ctx, *vm, vifs, vmDisks, cdroms, d)
+	guestMetrics, err := getVmGuestMetrics(c, vm.Id, vmDisks)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = recordToData(ctx, *vm, vifs, vmDisks, cdroms, guestMetrics, d)
 	return diag.FromErr(err)
 }
 
@@ -758,9 +963,8 @@ func cdromsToMapList(disks []client.Disk) []map[string]interface{} {
 	return result
 }
 
-func vifsToMapList(ctx context.Context, vifs []client.VIF, guestNets []guestNetwork, d *schema.ResourceData) []map[string]interface{} {
-	result := make([]map[string]interface{}, 0, len(vifs))
-
+// Returns the configured expected_ip_cidr of each network block keyed by its index
+func vifExpectedCidrs(d *schema.ResourceData) map[string]string {
 	expectedCidrs := map[string]string{}
 
 	networks := d.Get("network").([]interface{})
@@ -772,6 +976,12 @@ func vifsToMapList(ctx context.Context, vifs []client.VIF, guestNets []guestNetw
 		}
 		expectedCidrs[strconv.Itoa(index)] = expectedCidr
 	}
+	return expectedCidrs
+}
+
+func vifsToMapList(ctx context.Context, vifs []client.VIF, guestNets []guestNetwork, expectedCidrs map[string]string) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(vifs))
+
 	for index, vif := range vifs {
 		ipv6Addrs := []string{}
 		ipv4Addrs := []string{}
@@ -833,7 +1043,12 @@ func resourceVmReadContext(ctx context.Context, d *schema.ResourceData, m interf
 		return diag.FromErr(err)
 	}
 
-	err = recordToData(ctx, *vm, vifs, disks, cdroms, d)
+	guestMetrics, err := getVmGuestMetrics(c, vm.Id, disks)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	err = recordToData(ctx, *vm, vifs, disks, cdroms, guestMetrics, d)
 	return diag.FromErr(err)
 }
 
@@ -1116,6 +1331,16 @@ func resourceVmUpdateContext(ctx context.Context, d *schema.ResourceData, m inte
 		"vm": vm,
 	})
 
+	if d.Get("recreate_config_drive_on_change").(bool) && d.HasChanges("cloud_config", "cloud_config_id", "cloud_config_index", "cloud_network_config", "cloud_vendor_data", "cloud_meta_data", "cloud_init_datasource") {
+		drive, err := vmConfigDrive(c, d, vm.Id, vmIpPoolAddresses(d))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if err := replaceConfigDrive(ctx, c, vm, drive); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	powerStateChanged := d.HasChange("power_state")
 	_, newPowerState := d.GetChange("power_state")
 	tflog.Debug(ctx, "Power state status", map[string]interface{}{
@@ -1174,12 +1399,18 @@ func resourceVmUpdateContext(ctx context.Context, d *schema.ResourceData, m inte
 		}
 	}
 
+	if err := storeUnknownCreateOnlyAttributes(d); err != nil {
+		return diag.FromErr(err)
+	}
+
 	return resourceVmReadContext(ctx, d, m)
 }
 
 func resourceVmDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	c := m.(client.XOClient)
 
+	// XO releases the ip pool addresses allocated to the VM's VIFs when
+	// they are deleted along with the VM.
 	err := c.DeleteVm(d.Id())
 
 	if err != nil {
@@ -1235,7 +1466,12 @@ func expandNetworks(networks []interface{}) []*client.VIF {
 func RecordImportContext(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
 	c := m.(client.XOClient)
 
-	vm, err := c.GetVm(client.Vm{Id: d.Id()})
+	id, err := resolveVmImportId(c, d.Id())
+	if err != nil {
+		return nil, err
+	}
+
+	vm, err := c.GetVm(client.Vm{Id: id})
 	if err != nil {
 		return nil, err
 	}
@@ -1258,154 +1494,171 @@ func RecordImportContext(ctx context.Context, d *schema.ResourceData, m interfac
 		return rd, err
 	}
 
-	err = recordToData(ctx, *vm, vifs, disks, cdroms, d)
-
-	if err := d.Set("destroy_cloud_config_vdi_after_boot", false); err != nil {
+	guestMetrics, err := getVmGuestMetrics(c, vm.Id, disks)
+	if err != nil {
 		return rd, err
 	}
 
-	return rd, err
-}
-
-func recordToData(ctx context.Context, resource client.Vm, vifs []client.VIF, disks []client.Disk, cdroms []client.Disk, d *schema.ResourceData) error {
-	d.SetId(resource.Id)
-	// d.Set("cloud_config", resource.CloudConfig)
-	if len(resource.Memory.Dynamic) == 2 {
-		if err := d.Set("memory_max", resource.Memory.Dynamic[1]); err != nil {
-			return err
-		}
-		if err := d.Set("memory_min", resource.Memory.Dynamic[0]); err != nil {
-			return err
-		}
-	} else {
-		tflog.Warn(ctx, "VM's static memory limits", map[string]interface{}{
-			"expected": 2,
-			"found":    len(resource.Memory.Dynamic),
-			"limits":   resource.Memory.Dynamic,
-		})
+	// The xenstore keys set on the VM are tracked from the start so that
+	// the config managing them has no diff.
+	if err := d.Set("xenstore", filterXenstoreDataToVmData(vm.XenstoreData)); err != nil {
+		return rd, err
 	}
 
-	d.Set("cpus", resource.CPUs.Number)
-
-	// Handle CPU topology (cores_per_socket and sockets)
-	if resource.CoresPerSocket != nil && *resource.CoresPerSocket > 0 {
-		if err := d.Set("cores_per_socket", *resource.CoresPerSocket); err != nil {
-			return err
-		}
-		// Compute sockets = cpus / cores_per_socket
-		sockets := resource.CPUs.Number / (*resource.CoresPerSocket)
-		d.Set("sockets", sockets)
-	} else {
-		// Default: 1 core per socket means sockets = cpus
-		d.Set("sockets", resource.CPUs.Number)
+	err = recordToData(ctx, *vm, vifs, disks, cdroms, guestMetrics, d)
+	if err != nil {
+		return rd, err
 	}
 
-	d.Set("name_label", resource.NameLabel)
-	d.Set("affinity_host", resource.AffinityHost)
-	d.Set("name_description", resource.NameDescription)
-	d.Set("high_availability", resource.HA)
-	d.Set("auto_poweron", resource.AutoPoweron)
-	if resource.Template != "" {
-		d.Set("template", resource.Template)
-	}
-	if resource.ResourceSet != nil {
-		d.Set("resource_set", resource.ResourceSet.Id)
-	} else {
-		d.Set("resource_set", "")
+	// clone_type, installation_method, destroy_cloud_config_vdi_after_boot
+	// and the cloud-init attributes, like the template when XO does not
+	// report it, are left unset. Their diff is suppressed while the VM is
+	// marked as imported, until the next update sets them in the state.
+	if err := d.Set("imported", true); err != nil {
+		return rd, err
 	}
-	d.Set("power_state", resource.PowerState)
 
-	if err := d.Set("secure_boot", resource.SecureBoot); err != nil {
-		return err
+	if err := d.Set("cloud_config_index", 1); err != nil {
+		return rd, err
 	}
 
-	if err := d.Set("hvm_boot_firmware", resource.Boot.Firmware); err != nil {
-		return err
+	if err := d.Set("validate_cloud_init", true); err != nil {
+		return rd, err
 	}
 
-	if err := d.Set("exp_nested_hvm", resource.ExpNestedHvm); err != nil {
-		return err
+	if err := d.Set("recreate_config_drive_on_change", false); err != nil {
+		return rd, err
 	}
 
-	if err := d.Set("vga", resource.Vga); err != nil {
-		return err
+	if err := d.Set("cloud_init_datasource", noCloudDatasource); err != nil {
+		return rd, err
 	}
 
-	if err := d.Set("videoram", resource.Videoram.Value); err != nil {
+	return rd, nil
+}
+
+func recordToData(ctx context.Context, resource client.Vm, vifs []client.VIF, disks []client.Disk, cdroms []client.Disk, guestMetrics *vmGuestMetrics, d *schema.ResourceData) error {
+	d.SetId(resource.Id)
+	// d.Set("cloud_config", resource.CloudConfig)
+	vmMap, err := vmToMap(ctx, resource, vifs, disks, guestMetrics, vifExpectedCidrs(d))
+	if err != nil {
 		return err
 	}
 
-	if err := d.Set("start_delay", resource.StartDelay); err != nil {
-		return err
+	// XO does not record which pool an address was allocated from, so the
+	// allocations are carried over from the state
+	if networks, ok := vmMap["network"].([]map[string]interface{}); ok {
+		allocations := vifIpPoolAllocations(d)
+		for index, network := range networks {
+			if allocation, ok := allocations[index]; ok {
+				network["ip_pool_id"] = allocation["ip_pool_id"]
+				network["ip_pool_address"] = allocation["ip_pool_address"]
+			}
+		}
 	}
 
-	if err := d.Set("tags", resource.Tags); err != nil {
-		return err
+	// The xenstore is only tracked when it is managed by the config since
+	// XO and the guest tools populate many keys on their own.
+	if xenstore := d.Get("xenstore").(map[string]interface{}); len(xenstore) == 0 {
+		delete(vmMap, "xenstore")
 	}
-	if err := d.Set("blocked_operations", vmBlockedOperationsToList(resource)); err != nil {
-		return err
+
+	for k, v := range vmMap {
+		if err := d.Set(k, v); err != nil {
+			return err
+		}
 	}
 
+	return d.Set("cdrom", cdromsToMapList(isoCdroms(cdroms)))
+}
+
+// vmToMap returns the attributes that the xenorchestra_vm resource and the
+// xenorchestra_vms data source both populate from XO, so that a vm looks
+// the same regardless of how it was read.
+func vmToMap(ctx context.Context, vm client.Vm, vifs []client.VIF, disks []client.Disk, guestMetrics *vmGuestMetrics, expectedCidrs map[string]string) (map[string]interface{}, error) {
 	tflog.Debug(ctx, "Found IP addresses", map[string]interface{}{
-		"addresses": resource.Addresses,
+		"addresses": vm.Addresses,
 	})
-	networkIps, err := extractIpsFromNetworks(resource.Addresses)
-
+	networkIps, err := extractIpsFromNetworks(vm.Addresses)
+	if err != nil {
+		return nil, err
+	}
 	tflog.Debug(ctx, "Extracted network interface IPs", map[string]interface{}{
 		"devices": networkIps,
 	})
 
-	if err != nil {
-		return err
-	}
-
-	nets := vifsToMapList(ctx, vifs, networkIps, d)
+	nets := vifsToMapList(ctx, vifs, networkIps, expectedCidrs)
 	tflog.Debug(ctx, "Setting VIFs", map[string]interface{}{
 		"vifs": nets,
 	})
-	if err := d.Set("network", nets); err != nil {
-		return err
-	}
 
-	disksMapList := disksToMapList(disks)
-	err = d.Set("disk", disksMapList)
-	if err != nil {
-		return err
+	ipv4 := []string{}
+	ipv6 := []string{}
+	for i := range networkIps {
+		ipv4 = append(ipv4, networkIps[i]["ipv4"]...)
+		ipv6 = append(ipv6, networkIps[i]["ipv6"]...)
+	}
+
+	affinityHost := ""
+	if vm.AffinityHost != nil {
+		affinityHost = *vm.AffinityHost
+	}
+	resourceSet := ""
+	if vm.ResourceSet != nil {
+		resourceSet = vm.ResourceSet.Id
+	}
+
+	// sockets defaults to cpus (1 core per socket) unless the topology is set
+	vmMap := map[string]interface{}{
+		"name_label":         vm.NameLabel,
+		"name_description":   vm.NameDescription,
+		"cpus":               vm.CPUs.Number,
+		"sockets":            vm.CPUs.Number,
+		"affinity_host":      affinityHost,
+		"high_availability":  vm.HA,
+		"auto_poweron":       vm.AutoPoweron,
+		"resource_set":       resourceSet,
+		"power_state":        vm.PowerState,
+		"secure_boot":        vm.SecureBoot,
+		"hvm_boot_firmware":  vm.Boot.Firmware,
+		"exp_nested_hvm":     vm.ExpNestedHvm,
+		"vga":                vm.Vga,
+		"videoram":           vm.Videoram.Value,
+		"start_delay":        vm.StartDelay,
+		"tags":               vm.Tags,
+		"blocked_operations": vmBlockedOperationsToList(vm),
+		"network":            nets,
+		"disk":               disksToMapList(disks),
+		"ipv4_addresses":     ipv4,
+		"ipv6_addresses":     ipv6,
+		"xenstore":           filterXenstoreDataToVmData(vm.XenstoreData),
+	}
+
+	if len(vm.Memory.Dynamic) == 2 {
+		vmMap["memory_max"] = vm.Memory.Dynamic[1]
+		vmMap["memory_min"] = vm.Memory.Dynamic[0]
+	} else {
+		tflog.Warn(ctx, "VM's static memory limits", map[string]interface{}{
+			"expected": 2,
+			"found":    len(vm.Memory.Dynamic),
+			"limits":   vm.Memory.Dynamic,
+		})
 	}
 
-	cdsMapList := cdromsToMapList(cdroms)
-	err = d.Set("cdrom", cdsMapList)
-	if err != nil {
-		return err
+	// Handle CPU topology (cores_per_socket and sockets)
+	if vm.CoresPerSocket != nil && *vm.CoresPerSocket > 0 {
+		vmMap["cores_per_socket"] = *vm.CoresPerSocket
+		vmMap["sockets"] = vm.CPUs.Number / (*vm.CoresPerSocket)
 	}
 
-	if len(networkIps) > 0 {
-		for _, proto := range []string{"ipv4", "ipv6"} {
-			ips := []string{}
-			for i := range networkIps {
-				ips = append(ips, networkIps[i][proto]...)
-				if err := d.Set(fmt.Sprintf("%s_addresses", proto), ips); err != nil {
-					return err
-				}
-			}
-
-		}
-	} else {
-		if err := d.Set("ipv4_addresses", []string{}); err != nil {
-			return err
-		}
-		if err := d.Set("ipv6_addresses", []string{}); err != nil {
-			return err
-		}
-	}
-	if xenstore := d.Get("xenstore").(map[string]interface{}); len(xenstore) > 0 {
-		filtered := filterXenstoreDataToVmData(resource.XenstoreData)
-		if err := d.Set("xenstore", filtered); err != nil {
-			return err
-		}
+	if vm.Template != "" {
+		vmMap["template"] = vm.Template
 	}
 
-	return nil
+	for k, v := range vmGuestMetricsToMap(guestMetrics) {
+		vmMap[k] = v
+	}
+	return vmMap, nil
 }
 
 func filterXenstoreDataToVmData(xenstore map[string]interface{}) map[string]interface{} {
@@ -1715,3 +1968,100 @@ func suppressAttachedDiffWhenHalted(k, old, new string, d *schema.ResourceData)
 	}
 	return
 }
+
+// vmGuestMetrics contains the guest reported information that XO exposes on
+// its VM objects but that client.Vm does not contain.
+type vmGuestMetrics struct {
+	Id                string            `json:"id"`
+	OsVersion         map[string]string `json:"os_version"`
+	PVDriversVersion  string            `json:"pvDriversVersion"`
+	PVDriversUpToDate bool              `json:"pvDriversUpToDate"`
+	Memory            struct {
+		Usage int `json:"usage"`
+	} `json:"memory"`
+
+	// The space used by each disk keyed by the disk's position
+	DiskUsage map[string]int `json:"-"`
+}
+
+// vmGuestMetricsObject is either the VM or one of its VDIs, which are
+// fetched together by getVmGuestMetrics.
+type vmGuestMetricsObject struct {
+	vmGuestMetrics
+	Type  string `json:"type"`
+	Usage int    `json:"usage"`
+}
+
+// getVmGuestMetrics returns the guest metrics of the VM along with the space
+// used by its disks, which are the ones returned by GetDisks. The VM and the
+// disks' VDIs are fetched with a single call.
+func getVmGuestMetrics(c client.XOClient, vmId string, disks []client.Disk) (*vmGuestMetrics, error) {
+	ids := []string{vmId}
+	for _, disk := range disks {
+		ids = append(ids, disk.VDIId)
+	}
+	filter := map[string]interface{}{
+		"id": map[string]interface{}{
+			"__or": ids,
+		},
+	}
+
+	objects := map[string]vmGuestMetricsObject{}
+	if err := getXoObjects(c, filter, &objects); err != nil {
+		return nil, err
+	}
+
+	vm, ok := objects[vmId]
+	if !ok || vm.Type != "VM" {
+		return nil, client.NotFound{Query: client.Vm{Id: vmId}}
+	}
+	metrics := vm.vmGuestMetrics
+	for _, disk := range disks {
+		vdi, ok := objects[disk.VDIId]
+		if !ok || disk.NameLabel == defaultCloudConfigDiskName {
+			continue
+		}
+		if metrics.DiskUsage == nil {
+			metrics.DiskUsage = map[string]int{}
+		}
+		metrics.DiskUsage[disk.Position] = vdi.Usage
+	}
+	return &metrics, nil
+}
+
+func vmGuestMetricsToMap(metrics *vmGuestMetrics) map[string]interface{} {
+	if metrics == nil {
+		metrics = &vmGuestMetrics{}
+	}
+
+	osVersion := metrics.OsVersion
+	version := osVersion["major"]
+	if minor := osVersion["minor"]; version != "" && minor != "" {
+		version = fmt.Sprintf("%s.%s", version, minor)
+	}
+
+	guestOs := map[string]string{}
+	for k, v := range map[string]string{
+		"name":    osVersion["name"],
+		"distro":  osVersion["distro"],
+		"version": version,
+		"kernel":  osVersion["uname"],
+	} {
+		if v != "" {
+			guestOs[k] = v
+		}
+	}
+
+	diskUsage := metrics.DiskUsage
+	if diskUsage == nil {
+		diskUsage = map[string]int{}
+	}
+	return map[string]interface{}{
+		"guest_os":              guestOs,
+		"guest_hostname":        osVersion["hostname"],
+		"guest_memory_usage":    metrics.Memory.Usage,
+		"pv_drivers_version":    metrics.PVDriversVersion,
+		"pv_drivers_up_to_date": metrics.PVDriversUpToDate,
+		"disk_usage":            diskUsage,
+	}
+}
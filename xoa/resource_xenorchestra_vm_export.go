@@ -0,0 +1,142 @@
+package xoa
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func resourceVmExportRecord() *schema.Resource {
+	return &schema.Resource{
+		Description:   "Exports a VM as an XVA file on the machine running terraform. The export happens on creation; change `triggers` to export the VM again. Destroying the resource does not remove the file.",
+		CreateContext: resourceVmExportCreateContext,
+		ReadContext:   resourceVmExportReadContext,
+		DeleteContext: resourceVmExportDeleteContext,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"vm_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The id of the VM to export.",
+			},
+			"filepath": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The local path the XVA file is written to.",
+			},
+			"compress": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				ForceNew:    true,
+				Description: "Whether XO should compress the XVA with gzip.",
+			},
+			"triggers": &schema.Schema{
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Arbitrary values that cause the VM to be exported again when they change.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"size": &schema.Schema{
+				Type:        schema.TypeInt,
+				Computed:    true,
+				Description: "The size in bytes of the exported file.",
+			},
+			"sha256": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The SHA256 checksum of the exported file.",
+			},
+		},
+	}
+}
+
+func resourceVmExportCreateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	vmId := d.Get("vm_id").(string)
+	path := d.Get("filepath").(string)
+
+	var result struct {
+		GetFrom string `json:"$getFrom"`
+	}
+	params := map[string]interface{}{
+		"vm":       vmId,
+		"compress": d.Get("compress").(bool),
+	}
+	if err := callXoApi(c, "vm.export", params, &result); err != nil {
+		return diag.FromErr(err)
+	}
+	if result.GetFrom == "" {
+		return diag.Errorf("vm.export did not return a download url for vm %s", vmId)
+	}
+
+	// Download next to the destination so a failed export never leaves a
+	// truncated file behind.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	tflog.Debug(ctx, "Exporting VM", map[string]interface{}{
+		"vm_id":    vmId,
+		"filepath": path,
+	})
+	size, err := xoHttpDownload(ctx, c, result.GetFrom, io.MultiWriter(tmp, hash))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(vmId + ":" + path)
+	d.Set("size", size)
+	d.Set("sha256", hex.EncodeToString(hash.Sum(nil)))
+	return resourceVmExportReadContext(ctx, d, m)
+}
+
+func resourceVmExportReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	fi, err := os.Stat(d.Get("filepath").(string))
+	if os.IsNotExist(err) {
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Export again when the file was modified outside of terraform
+	if fi.Size() != int64(d.Get("size").(int)) {
+		d.SetId("")
+	}
+	return nil
+}
+
+func resourceVmExportDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	d.SetId("")
+	return nil
+}
@@ -0,0 +1,73 @@
+package xoa
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+)
+
+func TestAccXenorchestraVmExport_exportToFile(t *testing.T) {
+	resourceName := "xenorchestra_vm_export.bar"
+	vmName := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	exportPath := filepath.Join(t.TempDir(), "export.xva")
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVmExportConfig(vmName, exportPath, "1"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair(resourceName, "vm_id", "xenorchestra_vm.bar", "id"),
+					resource.TestCheckResourceAttr(resourceName, "filepath", exportPath),
+					resource.TestCheckResourceAttrSet(resourceName, "size"),
+					resource.TestCheckResourceAttrSet(resourceName, "sha256"),
+				),
+			},
+			{
+				Config: testAccVmExportConfig(vmName, exportPath, "2"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "triggers.export", "2"),
+					resource.TestCheckResourceAttrSet(resourceName, "sha256"),
+				),
+			},
+		},
+	})
+}
+
+func testAccVmExportConfig(vmName, exportPath, trigger string) string {
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
+data "xenorchestra_network" "network" {
+    name_label = "%s"
+    pool_id = "%s"
+}
+
+resource "xenorchestra_vm" "bar" {
+    memory_max = 4295000000
+    cpus  = 1
+    cloud_config = xenorchestra_cloud_config.bar.template
+    name_label = "%s"
+    template = data.xenorchestra_template.template.id
+    power_state = "Halted"
+    network {
+	network_id = data.xenorchestra_network.network.id
+    }
+
+    disk {
+      sr_id = "%s"
+      name_label = "disk 1"
+      size = 10001317888
+    }
+}
+
+resource "xenorchestra_vm_export" "bar" {
+    vm_id = xenorchestra_vm.bar.id
+    filepath = "%s"
+    compress = true
+    triggers = {
+      export = "%s"
+    }
+}
+`, accDefaultNetwork.NameLabel, accTestPool.Id, vmName, accDefaultSr.Id, exportPath, trigger)
+}
@@ -0,0 +1,466 @@
+package xoa
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+var validVmImportTypes = []string{"xva", "ova"}
+
+func resourceVmImportRecord() *schema.Resource {
+	return &schema.Resource{
+		Description:   "Imports a VM from an XVA or OVA appliance into a storage repository. The imported VM is deleted when the resource is destroyed.",
+		CreateContext: resourceVmImportCreateContext,
+		ReadContext:   resourceVmImportReadContext,
+		UpdateContext: resourceVmImportUpdateContext,
+		DeleteContext: resourceVmImportDeleteContext,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"type": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(validVmImportTypes, false),
+				Description:  "The format of the appliance, either `xva` or `ova`.",
+			},
+			"filepath": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ExactlyOneOf: []string{"filepath", "source_url"},
+				Description:  "The local path of the appliance to upload.",
+			},
+			"source_url": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IsURLWithHTTPorHTTPS,
+				Description:  "The url XO downloads the appliance from. Only supported for `xva` appliances.",
+			},
+			"sr_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The id of the storage repository the VM's disks are imported into.",
+			},
+			"network_map": &schema.Schema{
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "A map of the appliance's network interface device number (`0`, `1`, etc) to the id of the network it should be connected to. Every network interface of an `ova` appliance must be mapped.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"name_label": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The name of the imported VM. Defaults to the name stored in the appliance.",
+			},
+			"vm_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The id of the imported VM.",
+			},
+			"pool_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The id of the pool the VM was imported into.",
+			},
+		},
+	}
+}
+
+func resourceVmImportCreateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+	ctx, cancel := context.WithTimeout(ctx, d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	importType := d.Get("type").(string)
+	filepath := d.Get("filepath").(string)
+	sourceUrl := d.Get("source_url").(string)
+	networkMap := d.Get("network_map").(map[string]interface{})
+
+	params := map[string]interface{}{
+		"sr":   d.Get("sr_id").(string),
+		"type": importType,
+	}
+
+	if sourceUrl != "" {
+		if importType != "xva" {
+			return diag.Errorf("importing from `source_url` is only supported for xva appliances")
+		}
+		params["url"] = sourceUrl
+	}
+
+	if importType == "ova" {
+		ova, err := parseOvaFile(filepath)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		if nameLabel := d.Get("name_label").(string); nameLabel != "" {
+			ova.NameLabel = nameLabel
+		}
+		for i := range ova.Networks {
+			networkId, ok := networkMap[strconv.Itoa(i)]
+			if !ok {
+				return diag.Errorf("network_map must provide a network for the ova's network interface %d", i)
+			}
+			ova.Networks[i] = networkId.(string)
+		}
+		params["data"] = ova
+	}
+
+	var result json.RawMessage
+	if err := callXoApi(c, "vm.import", params, &result); err != nil {
+		return diag.FromErr(err)
+	}
+
+	vmId, err := vmImportResultId(result)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if vmId == "" {
+		var sendTo struct {
+			SendTo string `json:"$sendTo"`
+		}
+		if err := json.Unmarshal(result, &sendTo); err != nil || sendTo.SendTo == "" {
+			return diag.Errorf("unexpected vm.import response: %s", result)
+		}
+
+		file, err := os.Open(filepath)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		defer file.Close()
+
+		tflog.Debug(ctx, "Uploading appliance", map[string]interface{}{
+			"filepath": filepath,
+			"type":     importType,
+		})
+		body, err := xoHttpUpload(ctx, c, sendTo.SendTo, file)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		vmId, err = vmImportResultId(body)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	if vmId == "" {
+		return diag.Errorf("XO did not return the id of the imported vm")
+	}
+	d.SetId(vmId)
+
+	if nameLabel := d.Get("name_label").(string); nameLabel != "" && importType == "xva" {
+		var success bool
+		if err := callXoApi(c, "vm.set", map[string]interface{}{"id": vmId, "name_label": nameLabel}, &success); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	// The OVA networks are mapped as part of the import
+	if importType == "xva" && len(networkMap) > 0 {
+		if err := remapVmImportVifs(c, vmId, networkMap); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceVmImportReadContext(ctx, d, m)
+}
+
+func resourceVmImportReadContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	vm, err := c.GetVm(client.Vm{Id: d.Id()})
+	if _, ok := err.(client.NotFound); ok {
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("vm_id", vm.Id)
+	d.Set("name_label", vm.NameLabel)
+	d.Set("pool_id", vm.PoolId)
+	return nil
+}
+
+func resourceVmImportUpdateContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	if d.HasChange("name_label") {
+		var success bool
+		params := map[string]interface{}{
+			"id":         d.Id(),
+			"name_label": d.Get("name_label").(string),
+		}
+		if err := callXoApi(c, "vm.set", params, &success); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+	return resourceVmImportReadContext(ctx, d, m)
+}
+
+func resourceVmImportDeleteContext(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(client.XOClient)
+
+	if err := c.DeleteVm(d.Id()); err != nil {
+		return diag.FromErr(err)
+	}
+	d.SetId("")
+	return nil
+}
+
+// vmImportResultId extracts the id of the imported vm from either a
+// vm.import result or the JSON-RPC response returned by the upload.
+func vmImportResultId(result []byte) (string, error) {
+	var id string
+	if err := json.Unmarshal(result, &id); err == nil {
+		return id, nil
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(result, &response); err != nil {
+		return strings.TrimSpace(string(result)), nil
+	}
+	if response.Error != nil {
+		return "", fmt.Errorf("vm import failed: %s", response.Error.Message)
+	}
+	if len(response.Result) == 0 {
+		return "", nil
+	}
+	return vmImportResultId(response.Result)
+}
+
+// remapVmImportVifs moves the VIFs of the imported vm to the networks given
+// by networkMap.
+func remapVmImportVifs(c client.XOClient, vmId string, networkMap map[string]interface{}) error {
+	vifs, err := c.GetVIFs(&client.Vm{Id: vmId})
+	if err != nil {
+		return err
+	}
+
+	for _, vif := range vifs {
+		networkId, ok := networkMap[vif.Device]
+		if !ok || networkId.(string) == vif.Network {
+			continue
+		}
+
+		var success bool
+		params := map[string]interface{}{
+			"id":      vif.Id,
+			"network": networkId.(string),
+		}
+		if err := callXoApi(c, "vif.set", params, &success); err != nil {
+			return fmt.Errorf("failed to move VIF %s to network %s: %w", vif.Device, networkId, err)
+		}
+	}
+	return nil
+}
+
+// ovaImportData is the description of an OVA appliance that XO expects as
+// the `data` of vm.import.
+type ovaImportData struct {
+	NameLabel        string                   `json:"nameLabel"`
+	DescriptionLabel string                   `json:"descriptionLabel"`
+	NCpus            int                      `json:"nCpus"`
+	Memory           int64                    `json:"memory"`
+	Disks            map[string]ovaImportDisk `json:"disks"`
+	Networks         []string                 `json:"networks"`
+}
+
+type ovaImportDisk struct {
+	Capacity         int64  `json:"capacity"`
+	NameLabel        string `json:"nameLabel"`
+	DescriptionLabel string `json:"descriptionLabel"`
+	Path             string `json:"path"`
+	Position         int    `json:"position"`
+}
+
+type ovfEnvelope struct {
+	References []struct {
+		Id   string `xml:"id,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"References>File"`
+	Disks []struct {
+		DiskId                  string `xml:"diskId,attr"`
+		FileRef                 string `xml:"fileRef,attr"`
+		Capacity                string `xml:"capacity,attr"`
+		CapacityAllocationUnits string `xml:"capacityAllocationUnits,attr"`
+	} `xml:"DiskSection>Disk"`
+	VirtualSystem struct {
+		Name       string `xml:"Name"`
+		Annotation string `xml:"AnnotationSection>Annotation"`
+		Items      []struct {
+			ResourceType    int    `xml:"ResourceType"`
+			VirtualQuantity int64  `xml:"VirtualQuantity"`
+			AllocationUnits string `xml:"AllocationUnits"`
+			HostResource    string `xml:"HostResource"`
+			ElementName     string `xml:"ElementName"`
+			Connection      string `xml:"Connection"`
+		} `xml:"VirtualHardwareSection>Item"`
+	} `xml:"VirtualSystem"`
+}
+
+// The CIM resource types used by the OVF virtual hardware items
+const (
+	ovfResourceProcessor = 3
+	ovfResourceMemory    = 4
+	ovfResourceEthernet  = 10
+	ovfResourceDisk      = 17
+)
+
+// parseOvaFile reads the OVF descriptor of an OVA archive.
+func parseOvaFile(path string) (*ovaImportData, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	tr := tar.NewReader(file)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("ova `%s` does not contain an ovf descriptor", path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ova `%s`: %w", path, err)
+		}
+		if strings.HasSuffix(strings.ToLower(hdr.Name), ".ovf") {
+			return parseOvf(tr)
+		}
+	}
+}
+
+func parseOvf(r io.Reader) (*ovaImportData, error) {
+	var envelope ovfEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse ovf descriptor: %w", err)
+	}
+
+	files := map[string]string{}
+	for _, f := range envelope.References {
+		files[f.Id] = f.Href
+	}
+
+	type ovfDisk struct {
+		capacity int64
+		path     string
+	}
+	disks := map[string]ovfDisk{}
+	for _, disk := range envelope.Disks {
+		capacity, err := strconv.ParseInt(disk.Capacity, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid capacity `%s` for disk %s", disk.Capacity, disk.DiskId)
+		}
+		multiplier, err := ovfAllocationUnits(disk.CapacityAllocationUnits, 1)
+		if err != nil {
+			return nil, err
+		}
+		disks[disk.DiskId] = ovfDisk{
+			capacity: capacity * multiplier,
+			path:     files[disk.FileRef],
+		}
+	}
+
+	vs := envelope.VirtualSystem
+	data := &ovaImportData{
+		NameLabel:        vs.Name,
+		DescriptionLabel: vs.Annotation,
+		Disks:            map[string]ovaImportDisk{},
+		Networks:         []string{},
+	}
+	position := 0
+	for _, item := range vs.Items {
+		switch item.ResourceType {
+		case ovfResourceProcessor:
+			data.NCpus = int(item.VirtualQuantity)
+		case ovfResourceMemory:
+			// Memory is expressed in MB unless stated otherwise
+			multiplier, err := ovfAllocationUnits(item.AllocationUnits, 1<<20)
+			if err != nil {
+				return nil, err
+			}
+			data.Memory = item.VirtualQuantity * multiplier
+		case ovfResourceEthernet:
+			data.Networks = append(data.Networks, item.Connection)
+		case ovfResourceDisk:
+			diskId := item.HostResource[strings.LastIndex(item.HostResource, "/")+1:]
+			disk, ok := disks[diskId]
+			if !ok {
+				return nil, fmt.Errorf("ovf disk item references unknown disk `%s`", item.HostResource)
+			}
+			data.Disks[strconv.Itoa(position)] = ovaImportDisk{
+				Capacity:         disk.capacity,
+				NameLabel:        item.ElementName,
+				DescriptionLabel: item.ElementName,
+				Path:             disk.path,
+				Position:         position,
+			}
+			position++
+		}
+	}
+
+	if len(data.Disks) == 0 {
+		return nil, errors.New("ovf descriptor does not contain any disk")
+	}
+	return data, nil
+}
+
+var ovfAllocationUnitsRegex = regexp.MustCompile(`^byte\s*\*\s*2\^(\d+)$`)
+
+// ovfAllocationUnits returns the number of bytes of the OVF allocation unit
+// (e.g. `byte * 2^20` or `MegaBytes`).
+func ovfAllocationUnits(units string, defaultUnits int64) (int64, error) {
+	units = strings.TrimSpace(units)
+	switch strings.ToLower(units) {
+	case "":
+		return defaultUnits, nil
+	case "byte", "bytes":
+		return 1, nil
+	case "kilobytes", "kb":
+		return 1 << 10, nil
+	case "megabytes", "mb":
+		return 1 << 20, nil
+	case "gigabytes", "gb":
+		return 1 << 30, nil
+	}
+
+	matches := ovfAllocationUnitsRegex.FindStringSubmatch(units)
+	if matches == nil {
+		return 0, fmt.Errorf("unsupported ovf allocation units `%s`", units)
+	}
+	exp, err := strconv.Atoi(matches[1])
+	if err != nil || exp > 62 {
+		return 0, fmt.Errorf("unsupported ovf allocation units `%s`", units)
+	}
+	return 1 << exp, nil
+}
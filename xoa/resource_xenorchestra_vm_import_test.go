@@ -0,0 +1,239 @@
+package xoa
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func TestAccXenorchestraVmImport_xvaRoundTrip(t *testing.T) {
+	resourceName := "xenorchestra_vm_import.bar"
+	vmName := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	importName := fmt.Sprintf("%s - imported", vmName)
+	exportPath := filepath.Join(t.TempDir(), "export.xva")
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckXenorchestraVmImportDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVmImportConfig(vmName, exportPath, importName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, "id"),
+					resource.TestCheckResourceAttrPair(resourceName, "vm_id", resourceName, "id"),
+					resource.TestCheckResourceAttr(resourceName, "pool_id", accTestPool.Id),
+					resource.TestCheckResourceAttr(resourceName, "name_label", importName),
+					testAccVmImportVifNetwork(resourceName, accDefaultNetwork.Id),
+				),
+			},
+			{
+				Config: testAccVmImportConfig(vmName, exportPath, importName+" - updated"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "name_label", importName+" - updated"),
+				),
+			},
+		},
+	})
+}
+
+func testAccVmImportVifNetwork(resourceName, networkId string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", resourceName)
+		}
+
+		c, err := client.NewClient(client.GetConfigFromEnv())
+		if err != nil {
+			return err
+		}
+
+		vifs, err := c.GetVIFs(&client.Vm{Id: rs.Primary.ID})
+		if err != nil {
+			return err
+		}
+		for _, vif := range vifs {
+			if vif.Device == "0" && vif.Network != networkId {
+				return fmt.Errorf("expected VIF 0 to be on network %s, found %s", networkId, vif.Network)
+			}
+		}
+		return nil
+	}
+}
+
+func testAccCheckXenorchestraVmImportDestroy(s *terraform.State) error {
+	c, err := client.NewClient(client.GetConfigFromEnv())
+	if err != nil {
+		return err
+	}
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "xenorchestra_vm_import" {
+			continue
+		}
+
+		_, err := c.GetVm(client.Vm{Id: rs.Primary.ID})
+		if _, ok := err.(client.NotFound); ok {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("imported vm (%s) still exists", rs.Primary.ID)
+	}
+	return nil
+}
+
+func testAccVmImportConfig(vmName, exportPath, importName string) string {
+	return testAccVmExportConfig(vmName, exportPath, "1") + fmt.Sprintf(`
+resource "xenorchestra_vm_import" "bar" {
+    type = "xva"
+    filepath = xenorchestra_vm_export.bar.filepath
+    sr_id = "%s"
+    name_label = "%s"
+    network_map = {
+      "0" = data.xenorchestra_network.network.id
+    }
+}
+`, accDefaultSr.Id, importName)
+}
+
+const testOvf = `<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1" xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData">
+  <References>
+    <File ovf:id="file1" ovf:href="disk1.vmdk"/>
+    <File ovf:id="file2" ovf:href="disk2.vmdk"/>
+  </References>
+  <DiskSection>
+    <Disk ovf:diskId="vmdisk1" ovf:fileRef="file1" ovf:capacity="10" ovf:capacityAllocationUnits="byte * 2^30"/>
+    <Disk ovf:diskId="vmdisk2" ovf:fileRef="file2" ovf:capacity="1048576"/>
+  </DiskSection>
+  <VirtualSystem ovf:id="appliance">
+    <Name>appliance</Name>
+    <AnnotationSection>
+      <Annotation>an appliance</Annotation>
+    </AnnotationSection>
+    <VirtualHardwareSection>
+      <Item>
+        <rasd:ElementName>2 virtual CPU(s)</rasd:ElementName>
+        <rasd:ResourceType>3</rasd:ResourceType>
+        <rasd:VirtualQuantity>2</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:AllocationUnits>byte * 2^20</rasd:AllocationUnits>
+        <rasd:ElementName>2048MB of memory</rasd:ElementName>
+        <rasd:ResourceType>4</rasd:ResourceType>
+        <rasd:VirtualQuantity>2048</rasd:VirtualQuantity>
+      </Item>
+      <Item>
+        <rasd:Connection>VM Network</rasd:Connection>
+        <rasd:ElementName>Ethernet 1</rasd:ElementName>
+        <rasd:ResourceType>10</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:ElementName>Hard disk 1</rasd:ElementName>
+        <rasd:HostResource>ovf:/disk/vmdisk1</rasd:HostResource>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+      <Item>
+        <rasd:ElementName>Hard disk 2</rasd:ElementName>
+        <rasd:HostResource>ovf:/disk/vmdisk2</rasd:HostResource>
+        <rasd:ResourceType>17</rasd:ResourceType>
+      </Item>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>`
+
+func Test_parseOvf(t *testing.T) {
+	data, err := parseOvf(strings.NewReader(testOvf))
+	if err != nil {
+		t.Fatalf("expected ovf to parse, received error: %v", err)
+	}
+
+	expected := &ovaImportData{
+		NameLabel:        "appliance",
+		DescriptionLabel: "an appliance",
+		NCpus:            2,
+		Memory:           2048 << 20,
+		Networks:         []string{"VM Network"},
+		Disks: map[string]ovaImportDisk{
+			"0": {
+				Capacity:         10 << 30,
+				NameLabel:        "Hard disk 1",
+				DescriptionLabel: "Hard disk 1",
+				Path:             "disk1.vmdk",
+				Position:         0,
+			},
+			"1": {
+				Capacity:         1048576,
+				NameLabel:        "Hard disk 2",
+				DescriptionLabel: "Hard disk 2",
+				Path:             "disk2.vmdk",
+				Position:         1,
+			},
+		},
+	}
+	if !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected %+v, received %+v", expected, data)
+	}
+}
+
+func Test_ovfAllocationUnits(t *testing.T) {
+	tests := []struct {
+		units    string
+		expected int64
+		err      bool
+	}{
+		{units: "", expected: 7},
+		{units: "byte", expected: 1},
+		{units: "byte * 2^20", expected: 1 << 20},
+		{units: "byte*2^30", expected: 1 << 30},
+		{units: "MegaBytes", expected: 1 << 20},
+		{units: "hertz * 10^6", err: true},
+	}
+
+	for _, test := range tests {
+		units, err := ovfAllocationUnits(test.units, 7)
+		if test.err {
+			if err == nil {
+				t.Errorf("expected `%s` to fail to parse", test.units)
+			}
+			continue
+		}
+		if err != nil || units != test.expected {
+			t.Errorf("expected `%s` to be %d, received %d (err: %v)", test.units, test.expected, units, err)
+		}
+	}
+}
+
+func Test_vmImportResultId(t *testing.T) {
+	tests := []struct {
+		result   string
+		expected string
+		err      bool
+	}{
+		{result: `"vm-id"`, expected: "vm-id"},
+		{result: `{"jsonrpc":"2.0","id":0,"result":"vm-id"}`, expected: "vm-id"},
+		{result: `{"$sendTo":"/api/abc"}`, expected: ""},
+		{result: `{"jsonrpc":"2.0","id":0,"error":{"message":"import failed"}}`, err: true},
+	}
+
+	for _, test := range tests {
+		id, err := vmImportResultId([]byte(test.result))
+		if test.err {
+			if err == nil {
+				t.Errorf("expected `%s` to return an error", test.result)
+			}
+			continue
+		}
+		if err != nil || id != test.expected {
+			t.Errorf("expected `%s` to return id %q, received %q (err: %v)", test.result, test.expected, id, err)
+		}
+	}
+}
@@ -87,6 +87,67 @@ func Test_extractIpsFromNetworks(t *testing.T) {
 	}
 }
 
+func Test_vmGuestMetricsToMap(t *testing.T) {
+	metrics := &vmGuestMetrics{
+		OsVersion: map[string]string{
+			"name":     "Debian GNU/Linux 12 (bookworm)",
+			"distro":   "debian",
+			"major":    "12",
+			"minor":    "5",
+			"uname":    "6.1.0-18-amd64",
+			"hostname": "web-1",
+		},
+		PVDriversVersion:  "8.2.1",
+		PVDriversUpToDate: true,
+		DiskUsage: map[string]int{
+			"0": 1024,
+		},
+	}
+	metrics.Memory.Usage = 2048
+
+	tests := []struct {
+		metrics  *vmGuestMetrics
+		expected map[string]interface{}
+	}{
+		{
+			metrics: nil,
+			expected: map[string]interface{}{
+				"guest_os":              map[string]string{},
+				"guest_hostname":        "",
+				"guest_memory_usage":    0,
+				"pv_drivers_version":    "",
+				"pv_drivers_up_to_date": false,
+				"disk_usage":            map[string]int{},
+			},
+		},
+		{
+			metrics: metrics,
+			expected: map[string]interface{}{
+				"guest_os": map[string]string{
+					"name":    "Debian GNU/Linux 12 (bookworm)",
+					"distro":  "debian",
+					"version": "12.5",
+					"kernel":  "6.1.0-18-amd64",
+				},
+				"guest_hostname":        "web-1",
+				"guest_memory_usage":    2048,
+				"pv_drivers_version":    "8.2.1",
+				"pv_drivers_up_to_date": true,
+				"disk_usage": map[string]int{
+					"0": 1024,
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		actual := vmGuestMetricsToMap(test.metrics)
+		if !reflect.DeepEqual(test.expected, actual) {
+			t.Errorf("expected '%+v' to be equal to: %+v", test.expected, actual)
+		}
+	}
+}
+
 func Test_diskHash(t *testing.T) {
 	nameLabel := "name label"
 	nameDescription := "name description"
@@ -562,6 +623,108 @@ func TestAccXenorchestraVm_createAndPlanWithNonExistantVm(t *testing.T) {
 	})
 }
 
+func TestAccXenorchestraVm_createWithCloudConfigId(t *testing.T) {
+	resourceName := "xenorchestra_vm.bar"
+	vmName := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckXenorchestraVmDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVmConfigWithCloudConfigId(vmName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccVmExists(resourceName),
+					resource.TestCheckResourceAttrPair(resourceName, "cloud_config_id", "xenorchestra_cloud_config.bar", "id"),
+					resource.TestCheckResourceAttr(resourceName, "cloud_config_index", "3"),
+					resource.TestCheckNoResourceAttr(resourceName, "cloud_config")),
+			},
+		},
+	})
+}
+
+func TestAccXenorchestraVm_invalidCloudInitFailsPlan(t *testing.T) {
+	resourceName := "xenorchestra_vm.bar"
+	vmName := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckXenorchestraVmDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccVmConfigWithCloudInit(vmName, "hostname: web", "version: 1\\nconfig: []", true),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("invalid cloud_config: user-data must start with"),
+			},
+			{
+				Config:      testAccVmConfigWithCloudInit(vmName, "#cloud-config\\nhostname: web", "version: 3", true),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("invalid cloud_network_config: network config version must be 1 or 2"),
+			},
+			{
+				Config: testAccVmConfigWithCloudInit(vmName, "hostname: web", "version: 3", false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccVmExists(resourceName),
+					resource.TestCheckResourceAttr(resourceName, "validate_cloud_init", "false")),
+			},
+		},
+	})
+}
+
+func TestAccXenorchestraVm_recreateConfigDriveOnChange(t *testing.T) {
+	resourceName := "xenorchestra_vm.bar"
+	vmName := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	var vmId, configDriveId string
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckXenorchestraVmDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVmConfigRecreateConfigDrive(vmName, "first"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccVmExists(resourceName),
+					testAccVmId(resourceName, &vmId, false),
+					testAccVmConfigDriveId(resourceName, &configDriveId, false)),
+			},
+			{
+				Config: testAccVmConfigRecreateConfigDrive(vmName, "second"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					testAccVmExists(resourceName),
+					testAccVmId(resourceName, &vmId, true),
+					testAccVmConfigDriveId(resourceName, &configDriveId, true),
+					resource.TestCheckResourceAttr(resourceName, "cloud_config", "#cloud-config\nhostname: second\n")),
+			},
+		},
+	})
+}
+
+func TestAccXenorchestraVm_createWithProviderConfigDrive(t *testing.T) {
+	resourceName := "xenorchestra_vm.bar"
+	vmName := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	var configDriveId string
+	for _, datasource := range []string{"NoCloud", "ConfigDrive"} {
+		t.Run(datasource, func(t *testing.T) {
+			resource.Test(t, resource.TestCase{
+				PreCheck:     func() { testAccPreCheck(t) },
+				Providers:    testAccProviders,
+				CheckDestroy: testAccCheckXenorchestraVmDestroy,
+				Steps: []resource.TestStep{
+					{
+						Config: testAccVmConfigWithProviderConfigDrive(vmName, datasource),
+						Check: resource.ComposeAggregateTestCheckFunc(
+							testAccVmExists(resourceName),
+							testAccVmConfigDriveId(resourceName, &configDriveId, false),
+							resource.TestCheckResourceAttr(resourceName, "power_state", "Running"),
+							resource.TestCheckResourceAttr(resourceName, "cloud_init_datasource", datasource),
+							resource.TestCheckResourceAttr(resourceName, "cloud_meta_data.0.local_hostname", "web")),
+					},
+				},
+			})
+		})
+	}
+}
+
 func TestAccXenorchestraVm_createWithFullClone(t *testing.T) {
 	resourceName := "xenorchestra_vm.bar"
 	vdiDataSourceName := "data.xenorchestra_vdi.disk"
@@ -662,6 +825,9 @@ func TestAccXenorchestraVm_createWhenWaitingForIp(t *testing.T) {
 					resource.TestCheckResourceAttrSet(resourceName, "ipv6_addresses.0"),
 					resource.TestMatchResourceAttr(resourceName, "network.0.ipv6_addresses.#", regex),
 					resource.TestCheckResourceAttrSet(resourceName, "network.0.ipv6_addresses.0"),
+					resource.TestCheckResourceAttrSet(resourceName, "guest_os.kernel"),
+					resource.TestCheckResourceAttrSet(resourceName, "guest_memory_usage"),
+					resource.TestCheckResourceAttrSet(resourceName, "disk_usage.0"),
 				),
 			},
 		},
@@ -745,7 +911,7 @@ func TestAccXenorchestraVm_ensureVmsInResourceSetsCanBeUpdatedByNonAdminUsers(t
 		Steps: []resource.TestStep{
 			// Create a resource set and cloud config template with an admin user
 			{
-				Config: testAccVmResourceSet(vmName) + testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template"),
+				Config: testAccVmResourceSet(vmName) + testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig),
 			},
 			// Create a VM using the resource set from the previous step
 			{
@@ -1416,6 +1582,51 @@ func TestAccXenorchestraVm_import(t *testing.T) {
 	})
 }
 
+func TestAccXenorchestraVm_importByNameIsPlanClean(t *testing.T) {
+	resourceName := "xenorchestra_vm.bar"
+	vmName := fmt.Sprintf("%s - %s", accTestPrefix, t.Name())
+	resource.ParallelTest(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckXenorchestraVmDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccVmConfigWithCloudInit(vmName, "#cloud-config\nhostname: web\n", "", true),
+			},
+			{
+				ResourceName:  resourceName,
+				ImportState:   true,
+				ImportStateId: vmName,
+				ImportStateCheck: func(s []*terraform.InstanceState) error {
+					if len(s) != 1 || s[0].Attributes["name_label"] != vmName {
+						return fmt.Errorf("expected the VM named %q to be imported", vmName)
+					}
+					if s[0].Attributes["imported"] != "true" {
+						return fmt.Errorf("expected the imported VM to be marked as imported")
+					}
+					return nil
+				},
+			},
+			{
+				ResourceName:       resourceName,
+				ImportState:        true,
+				ImportStateId:      fmt.Sprintf("%s/%s", accTestPool.NameLabel, vmName),
+				ImportStatePersist: true,
+			},
+			{
+				Config:   testAccVmConfigWithCloudInit(vmName, "#cloud-config\nhostname: web\n", "", true),
+				PlanOnly: true,
+			},
+			{
+				ResourceName:  resourceName,
+				ImportState:   true,
+				ImportStateId: fmt.Sprintf("%s - missing", vmName),
+				ExpectError:   regexp.MustCompile("could not find a VM"),
+			},
+		},
+	})
+}
+
 // TODO: Add unit tests
 func testAccCheckXenorchestraVmDestroy(s *terraform.State) error {
 	c, err := client.NewClient(client.GetConfigFromEnv())
@@ -2054,8 +2265,151 @@ resource "xenorchestra_vm" "bar" {
 `, accDefaultNetwork.NameLabel, accTestPool.Id, vmName, accDefaultSr.Id)
 }
 
+func testAccVmConfigWithCloudInit(vmName, cloudConfig, cloudNetworkConfig string, validate bool) string {
+	return testAccTemplateConfig() + fmt.Sprintf(`
+data "xenorchestra_network" "network" {
+    name_label = "%s"
+    pool_id = "%s"
+}
+
+resource "xenorchestra_vm" "bar" {
+    memory_max = 4295000000
+    cpus  = 1
+    cloud_config = "%s"
+    cloud_network_config = "%s"
+    validate_cloud_init = %t
+    name_label = "%s"
+    name_description = "description"
+    template = data.xenorchestra_template.template.id
+    network {
+	network_id = data.xenorchestra_network.network.id
+    }
+
+    disk {
+      sr_id = "%s"
+      name_label = "disk 1"
+      size = 10001317888
+    }
+}
+`, accDefaultNetwork.NameLabel, accTestPool.Id, cloudConfig, cloudNetworkConfig, validate, vmName, accDefaultSr.Id)
+}
+
+func testAccVmConfigRecreateConfigDrive(vmName, hostname string) string {
+	return testAccTemplateConfig() + fmt.Sprintf(`
+data "xenorchestra_network" "network" {
+    name_label = "%s"
+    pool_id = "%s"
+}
+
+resource "xenorchestra_vm" "bar" {
+    memory_max = 4295000000
+    cpus  = 1
+    cloud_config = "#cloud-config\\nhostname: %s\\n"
+    recreate_config_drive_on_change = true
+    name_label = "%s"
+    name_description = "description"
+    template = data.xenorchestra_template.template.id
+    network {
+	network_id = data.xenorchestra_network.network.id
+    }
+
+    disk {
+      sr_id = "%s"
+      name_label = "disk 1"
+      size = 10001317888
+    }
+}
+`, accDefaultNetwork.NameLabel, accTestPool.Id, hostname, vmName, accDefaultSr.Id)
+}
+
+// testAccVmId records the VM's id or, when unchanged is set, verifies that
+// the VM was not recreated.
+func testAccVmId(resourceName string, id *string, unchanged bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", resourceName)
+		}
+
+		if unchanged && rs.Primary.ID != *id {
+			return fmt.Errorf("expected VM %s to be updated in place, it was recreated as %s", *id, rs.Primary.ID)
+		}
+		*id = rs.Primary.ID
+		return nil
+	}
+}
+
+// testAccVmConfigDriveId records the id of the VM's config drive VDI or,
+// when changed is set, verifies that it was replaced.
+func testAccVmConfigDriveId(resourceName string, id *string, changed bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		rs, ok := s.RootModule().Resources[resourceName]
+		if !ok {
+			return fmt.Errorf("Not found: %s", resourceName)
+		}
+
+		c, err := client.NewClient(client.GetConfigFromEnv())
+		if err != nil {
+			return err
+		}
+
+		disks, err := c.GetDisks(&client.Vm{Id: rs.Primary.ID})
+		if err != nil {
+			return err
+		}
+
+		var configDriveIds []string
+		for _, disk := range disks {
+			if disk.NameLabel == defaultCloudConfigDiskName {
+				configDriveIds = append(configDriveIds, disk.VDIId)
+			}
+		}
+		if len(configDriveIds) != 1 {
+			return fmt.Errorf("expected VM %s to have a single config drive, found %v", rs.Primary.ID, configDriveIds)
+		}
+
+		if changed && configDriveIds[0] == *id {
+			return fmt.Errorf("expected the config drive %s of VM %s to be replaced", *id, rs.Primary.ID)
+		}
+		*id = configDriveIds[0]
+		return nil
+	}
+}
+
+func testAccVmConfigWithProviderConfigDrive(vmName, datasource string) string {
+	return testAccTemplateConfig() + fmt.Sprintf(`
+data "xenorchestra_network" "network" {
+    name_label = "%s"
+    pool_id = "%s"
+}
+
+resource "xenorchestra_vm" "bar" {
+    memory_max = 4295000000
+    cpus  = 1
+    cloud_config = "#cloud-config\\nhostname: web\\n"
+    cloud_vendor_data = "#cloud-config\\npackages: []\\n"
+    cloud_init_datasource = "%s"
+    cloud_meta_data {
+      local_hostname = "web"
+    }
+    name_label = "%s"
+    name_description = "description"
+    template = data.xenorchestra_template.template.id
+    network {
+	network_id = data.xenorchestra_network.network.id
+    }
+
+    disk {
+      sr_id = "%s"
+      name_label = "disk 1"
+      size = 10001317888
+    }
+}
+`, accDefaultNetwork.NameLabel, accTestPool.Id, datasource, vmName, accDefaultSr.Id)
+}
+
 func testAccVmConfigWithTag(vmName, tag string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2086,7 +2440,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithISO(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccNonDefaultTemplateConfig(disklessTestTemplate.NameLabel) + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccNonDefaultTemplateConfig(disklessTestTemplate.NameLabel) + fmt.Sprintf(`
 data "xenorchestra_vdi" "iso" {
     name_label = "%s"
     pool_id = "%s"
@@ -2122,7 +2476,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithoutISO(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccNonDefaultTemplateConfig(disklessTestTemplate.NameLabel) + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccNonDefaultTemplateConfig(disklessTestTemplate.NameLabel) + fmt.Sprintf(`
 
 data "xenorchestra_vdi" "iso" {
     name_label = "%s"
@@ -2155,7 +2509,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithTags(vmName, tag, secondTag string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2187,7 +2541,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithAffinityHost(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_pool" "pool" {
     name_label = "%s"
 }
@@ -2223,7 +2577,7 @@ func testAccVmConfig(vmName string) string {
 }
 
 func testAccVmConfigWithWaitForIp(vmName, expectedIpCidr string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2251,7 +2605,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigFullClone(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2279,8 +2633,36 @@ resource "xenorchestra_vm" "bar" {
 `, accDefaultNetwork.NameLabel, accTestPool.Id, vmName, accDefaultSr.Id)
 }
 
+func testAccVmConfigWithCloudConfigId(vmName string) string {
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), `#cloud-config\nhostname: {name}-%`) + testAccTemplateConfig() + fmt.Sprintf(`
+data "xenorchestra_network" "network" {
+    name_label = "%s"
+    pool_id = "%s"
+}
+
+resource "xenorchestra_vm" "bar" {
+    memory_max = 4295000000
+    cpus  = 1
+    cloud_config_id = xenorchestra_cloud_config.bar.id
+    cloud_config_index = 3
+    name_label = "%s"
+    name_description = "description"
+    template = data.xenorchestra_template.template.id
+    network {
+	network_id = data.xenorchestra_network.network.id
+    }
+
+    disk {
+      sr_id = "%s"
+      name_label = "disk 1"
+      size = 10001317888
+    }
+}
+`, accDefaultNetwork.NameLabel, accTestPool.Id, vmName, accDefaultSr.Id)
+}
+
 func testAccVmConfigWithPowerState(vmName, powerState string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2313,7 +2695,7 @@ resource "xenorchestra_vm" "bar" {
 // disk was actually deleted. The XO api uses the guest metrics to determine when it can remove
 // the disk, so an IP address allocation happens at the same time.
 func testAccVmConfigWithDestroyCloudConfigAfterBoot(vmName string, powerState string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2343,7 +2725,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithDestroyCloudConfigAfterBootIgnoringChanges(vmName string, powerState string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2379,7 +2761,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigPXEBoot(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccNonDefaultTemplateConfig(disklessTestTemplate.NameLabel) + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccNonDefaultTemplateConfig(disklessTestTemplate.NameLabel) + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "Lab Network (VLAN 10)"
     pool_id = "%s"
@@ -2416,7 +2798,7 @@ data "xenorchestra_vdi" "disk" {
 }
 
 func testAccVmConfigConflictingCdromAndInstallMethod(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_vdi" "iso" {
     name_label = "%s"
     pool_id = "%s"
@@ -2452,7 +2834,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithShortTimeout(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2484,7 +2866,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithCd(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_vdi" "iso" {
     name_label = "%s"
     pool_id = "%s"
@@ -2520,7 +2902,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWaitForIp(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2548,7 +2930,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithSingleXenstoreData(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2586,7 +2968,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithMultipleXenstoreData(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2625,7 +3007,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithDiskNameLabelAndNameDescription(vmName, nameLabel, description string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2653,7 +3035,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithNetworkConfig(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2689,7 +3071,7 @@ EOF
 }
 
 func testAccVmConfigDisconnectedDisk(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2717,7 +3099,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithAdditionalDisk(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2750,7 +3132,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmVifAttachedConfig(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2778,7 +3160,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmVifDetachedConfig(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2806,7 +3188,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithMacAddress(vmName, macAddress string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2834,7 +3216,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithMacAddressSentinelInput(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 resource "macaddress" "mac" {}
 
 data "xenorchestra_network" "network" {
@@ -2864,7 +3246,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithTwoMacAddresses(vmName, firstMac, secondMac string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2897,7 +3279,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithSecondVIF(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2932,7 +3314,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigWithThreeVIFs(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -2970,7 +3352,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigUpdateAttr(nameLabel, attr string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", nameLabel), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", nameLabel), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -3000,7 +3382,7 @@ resource "xenorchestra_vm" "bar" {
 // Terraform config that tests changes to a VM that do not require halting
 // the VM prior to applying
 func testAccVmConfigUpdateAttrsHaltIrrelevant(nameLabel, nameDescription, ha string, powerOn bool) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", nameLabel), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", nameLabel), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_network" "network" {
     name_label = "%s"
     pool_id = "%s"
@@ -3029,7 +3411,7 @@ resource "xenorchestra_vm" "bar" {
 }
 
 func testAccVmConfigUpdateAttrsHaltIrrelevantWithAffinityHost(nameLabel, nameDescription, ha string, powerOn bool) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", nameLabel), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", nameLabel), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_pool" "pool" {
     name_label = "%s"
 }
@@ -3069,7 +3451,7 @@ func testAccVmConfigUpdateAttrsVariableCPUAndMemory(cpus, memoryMax int, memoryM
 	} else {
 		memoryMinStr = fmt.Sprintf("%d", *memoryMin)
 	}
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", nameLabel), "template") + testAccTemplateConfig() + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", nameLabel), testAccVmCloudConfig) + testAccTemplateConfig() + fmt.Sprintf(`
 data "xenorchestra_pool" "pool" {
     name_label = "%s"
 }
@@ -3118,7 +3500,7 @@ func testAccVmManagedResourceSetConfig(vmName string) string {
 }
 
 func testAccVmManagedResourceSetWithDescriptionConfig(vmName, nameDescription string, providerAlias string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccVmResourceSet(vmName) + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccVmResourceSet(vmName) + fmt.Sprintf(`
 
 resource "xenorchestra_vm" "bar" {
     %s
@@ -3182,7 +3564,7 @@ resource "xenorchestra_resource_set" "rs" {
 }
 
 func testAccVmConfigWithoutResourceSet(vmName string) string {
-	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), "template") + testAccVmResourceSet(vmName) + fmt.Sprintf(`
+	return testAccCloudConfigConfig(fmt.Sprintf("vm-template-%s", vmName), testAccVmCloudConfig) + testAccVmResourceSet(vmName) + fmt.Sprintf(`
 
 resource "xenorchestra_vm" "bar" {
     memory_max = 4295000000
@@ -1,6 +1,11 @@
 package xoa
 
-import "github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
 
 func resourceTags() *schema.Schema {
 	return &schema.Schema{
@@ -12,3 +17,45 @@ func resourceTags() *schema.Schema {
 		Description: "The tags (labels) applied to the given entity. Not used for filtering if empty.",
 	}
 }
+
+// adoptedTagsSchema is used by the resources that manage an existing object
+// (e.g. a pool or host). Their tags are only managed when configured so
+// that adopting the object does not remove its existing tags.
+func adoptedTagsSchema(kind string) *schema.Schema {
+	tags := resourceTags()
+	tags.Computed = true
+	tags.Description = fmt.Sprintf("The tags (labels) applied to the %s. The %s's tags are left untouched when unset.", kind, kind)
+	return tags
+}
+
+// updateAdoptedTags reconciles the configured tags with the object's
+// current tags.
+func updateAdoptedTags(c client.XOClient, id string, current []string, d *schema.ResourceData) error {
+	if !isConfigured(d, "tags") {
+		return nil
+	}
+	oTags := schema.NewSet(schema.HashString, []interface{}{})
+	for _, tag := range current {
+		oTags.Add(tag)
+	}
+	nTags := d.Get("tags").(*schema.Set)
+
+	for _, removal := range oTags.Difference(nTags).List() {
+		if err := c.RemoveTag(id, removal.(string)); err != nil {
+			return err
+		}
+	}
+
+	for _, addition := range nTags.Difference(oTags).List() {
+		if err := c.AddTag(id, addition.(string)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isConfigured reports whether the attribute is part of the resource's
+// configuration as opposed to a computed value read from the object.
+func isConfigured(d *schema.ResourceData, key string) bool {
+	return !d.GetRawConfig().GetAttr(key).IsNull()
+}
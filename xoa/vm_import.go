@@ -0,0 +1,146 @@
+package xoa
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+var vmUuidRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// vmImportObject contains the fields of the VM and pool objects needed to
+// resolve the id given to `terraform import`.
+type vmImportObject struct {
+	Id        string `json:"id"`
+	Type      string `json:"type"`
+	NameLabel string `json:"name_label"`
+	PoolId    string `json:"$poolId"`
+}
+
+// resolveVmImportId returns the id of the VM referenced by importId, which
+// is either the VM's uuid, its name_label or `<pool>/<name_label>` where
+// the pool is given by its name_label or id.
+func resolveVmImportId(c client.XOClient, importId string) (string, error) {
+	if vmUuidRegex.MatchString(importId) {
+		return importId, nil
+	}
+
+	filter := map[string]interface{}{
+		"type": map[string]interface{}{
+			"__or": []string{"VM", "pool"},
+		},
+	}
+	objects := map[string]vmImportObject{}
+	if err := getXoObjects(c, filter, &objects); err != nil {
+		return "", err
+	}
+
+	ids := matchVmImportId(importId, objects)
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("could not find a VM with the uuid, name_label or `<pool>/<name_label>` %q", importId)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("found %d VMs matching %q, import one of them by uuid instead: %s", len(ids), importId, strings.Join(ids, ", "))
+	}
+}
+
+// matchVmImportId returns the sorted ids of the VMs whose name_label or
+// `<pool>/<name_label>` is importId. Both forms are tried since VM names
+// may contain slashes.
+func matchVmImportId(importId string, objects map[string]vmImportObject) []string {
+	pools := map[string]string{}
+	for id, obj := range objects {
+		if obj.Type == "pool" {
+			pools[id] = obj.NameLabel
+		}
+	}
+
+	ids := []string{}
+	for id, obj := range objects {
+		if obj.Type != "VM" {
+			continue
+		}
+		if obj.NameLabel == importId ||
+			importId == obj.PoolId+"/"+obj.NameLabel ||
+			(pools[obj.PoolId] != "" && importId == pools[obj.PoolId]+"/"+obj.NameLabel) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// isoCdroms returns the CD drives that have an ISO inserted. Empty drives
+// are left out since they cannot be described by a cdrom block.
+func isoCdroms(cdroms []client.Disk) []client.Disk {
+	result := make([]client.Disk, 0, len(cdroms))
+	for _, cdrom := range cdroms {
+		if cdrom.VDIId == "" {
+			continue
+		}
+		result = append(result, cdrom)
+	}
+	return result
+}
+
+// unknownCreateOnlyAttributes are the creation time attributes that XO does
+// not report once the VM exists, they are left unset by the import.
+var unknownCreateOnlyAttributes = []string{
+	"template",
+	"clone_type",
+	"installation_method",
+	"destroy_cloud_config_vdi_after_boot",
+	"cloud_config",
+	"cloud_config_id",
+	"cloud_network_config",
+	"cloud_vendor_data",
+}
+
+// suppressUnknownCreateOnlyDiff suppresses the diff of the
+// unknownCreateOnlyAttributes missing from the state of an imported VM, so
+// that it is not replaced or updated to match the config.
+func suppressUnknownCreateOnlyDiff(k, old, new string, d *schema.ResourceData) bool {
+	return d.Get("imported").(bool) && old == ""
+}
+
+// storeUnknownCreateOnlyAttributes stores the configured values of the
+// unknownCreateOnlyAttributes of an imported VM and clears its imported
+// marker, so that their later changes are planned as for any other VM.
+func storeUnknownCreateOnlyAttributes(d *schema.ResourceData) error {
+	if !d.Get("imported").(bool) {
+		return nil
+	}
+
+	vmSchema := resourceVmSchema()
+	for _, key := range unknownCreateOnlyAttributes {
+		value := d.GetRawConfig().GetAttr(key)
+		if value.IsNull() {
+			continue
+		}
+
+		var err error
+		if vmSchema[key].Type == schema.TypeBool {
+			err = d.Set(key, value.True())
+		} else {
+			err = d.Set(key, value.AsString())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.Set("imported", false)
+}
+
+// suppressUnknownCloudInitDiff behaves like suppressUnknownCreateOnlyDiff
+// for the cloud-init attributes, unless they are applied to the existing VM
+// by recreate_config_drive_on_change.
+func suppressUnknownCloudInitDiff(k, old, new string, d *schema.ResourceData) bool {
+	return suppressUnknownCreateOnlyDiff(k, old, new, d) && !d.Get("recreate_config_drive_on_change").(bool)
+}
@@ -0,0 +1,71 @@
+package xoa
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+func Test_matchVmImportId(t *testing.T) {
+	objects := map[string]vmImportObject{
+		"pool-1": {Id: "pool-1", Type: "pool", NameLabel: "Production", PoolId: "pool-1"},
+		"pool-2": {Id: "pool-2", Type: "pool", NameLabel: "Staging", PoolId: "pool-2"},
+		"vm-1":   {Id: "vm-1", Type: "VM", NameLabel: "web", PoolId: "pool-1"},
+		"vm-2":   {Id: "vm-2", Type: "VM", NameLabel: "web", PoolId: "pool-2"},
+		"vm-3":   {Id: "vm-3", Type: "VM", NameLabel: "db", PoolId: "pool-1"},
+		"vm-4":   {Id: "vm-4", Type: "VM", NameLabel: "Staging/db", PoolId: "pool-1"},
+	}
+
+	tests := []struct {
+		importId string
+		ids      []string
+	}{
+		{importId: "db", ids: []string{"vm-3"}},
+		{importId: "web", ids: []string{"vm-1", "vm-2"}},
+		{importId: "Production/web", ids: []string{"vm-1"}},
+		{importId: "pool-2/web", ids: []string{"vm-2"}},
+		{importId: "Staging/db", ids: []string{"vm-4"}},
+		{importId: "Production", ids: []string{}},
+		{importId: "Staging/web/", ids: []string{}},
+	}
+
+	for _, test := range tests {
+		ids := matchVmImportId(test.importId, objects)
+		if !reflect.DeepEqual(ids, test.ids) {
+			t.Errorf("expected %q to match %v, received %v", test.importId, test.ids, ids)
+		}
+	}
+}
+
+func Test_isoCdroms(t *testing.T) {
+	cdroms := []client.Disk{
+		{VBD: client.VBD{Id: "empty"}},
+		{VBD: client.VBD{Id: "iso"}, VDI: client.VDI{VDIId: "iso-vdi"}},
+	}
+
+	result := isoCdroms(cdroms)
+	if len(result) != 1 || result[0].VDIId != "iso-vdi" {
+		t.Errorf("expected only the cdrom with an ISO to be kept, received %v", result)
+	}
+}
+
+func Test_suppressUnknownCreateOnlyDiff(t *testing.T) {
+	d := resourceRecord().TestResourceData()
+	if suppressUnknownCreateOnlyDiff("clone_type", "", "full", d) {
+		t.Errorf("expected the diff of a VM being created not to be suppressed")
+	}
+
+	d.SetId("vm id")
+	if suppressUnknownCreateOnlyDiff("cloud_config", "", "#cloud-config", d) {
+		t.Errorf("expected the diff of a VM created without the attribute not to be suppressed")
+	}
+
+	d.Set("imported", true)
+	if !suppressUnknownCreateOnlyDiff("clone_type", "", "full", d) {
+		t.Errorf("expected the diff of an attribute missing from the state of an imported VM to be suppressed")
+	}
+	if suppressUnknownCreateOnlyDiff("clone_type", "fast", "full", d) {
+		t.Errorf("expected the diff of an attribute in the state not to be suppressed")
+	}
+}
@@ -0,0 +1,32 @@
+package xoa
+
+import (
+	"fmt"
+
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+// xoApiCaller is implemented by the SDK's client (and the test clients that
+// embed it). It gives access to the XO JSON-RPC methods the SDK does not
+// wrap yet.
+type xoApiCaller interface {
+	Call(method string, params, result interface{}) error
+}
+
+func callXoApi(c client.XOClient, method string, params, result interface{}) error {
+	caller, ok := c.(xoApiCaller)
+	if !ok {
+		return fmt.Errorf("client %T cannot call the `%s` XO api method", c, method)
+	}
+	return caller.Call(method, params, result)
+}
+
+// getXoObjects decodes the XO objects matching filter into result, which
+// must be a pointer to a map keyed by the object ids. This allows reading
+// object fields that the SDK's types do not expose.
+func getXoObjects(c client.XOClient, filter map[string]interface{}, result interface{}) error {
+	params := map[string]interface{}{
+		"filter": filter,
+	}
+	return callXoApi(c, "xo.getAllObjects", params, result)
+}
@@ -0,0 +1,105 @@
+package xoa
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/vatesfr/xenorchestra-go-sdk/client"
+)
+
+// xoaClient wraps the SDK's client with the provider configuration so that
+// resources can transfer files through XO's HTTP endpoints (the `$sendTo`
+// and `$getFrom` urls returned by the import and export methods).
+type xoaClient struct {
+	*client.Client
+	config client.Config
+}
+
+// xoHttpRequest performs a request against the path of a one time XO url.
+// These urls embed their own authorization so no credentials are sent.
+func xoHttpRequest(ctx context.Context, c client.XOClient, method, path string, body io.Reader, size int64) (*http.Response, error) {
+	xoc, ok := c.(*xoaClient)
+	if !ok {
+		return nil, fmt.Errorf("client %T does not support XO http transfers", c)
+	}
+
+	base, err := url.Parse(xoc.config.Url)
+	if err != nil {
+		return nil, err
+	}
+	switch base.Scheme {
+	case "ws":
+		base.Scheme = "http"
+	case "wss":
+		base.Scheme = "https"
+	}
+	u, err := base.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: xoc.config.InsecureSkipVerify,
+			},
+		},
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("XO http %s request failed with status %s: %s", method, resp.Status, msg)
+	}
+	return resp, nil
+}
+
+// xoHttpUpload streams the file to the XO `$sendTo` path and returns the
+// response body.
+func xoHttpUpload(ctx context.Context, c client.XOClient, sendTo string, file *os.File) ([]byte, error) {
+	fi, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	return xoHttpUploadReader(ctx, c, sendTo, file, fi.Size())
+}
+
+// xoHttpUploadReader streams size bytes of r to the XO `$sendTo` path and
+// returns the response body.
+func xoHttpUploadReader(ctx context.Context, c client.XOClient, sendTo string, r io.Reader, size int64) ([]byte, error) {
+	resp, err := xoHttpRequest(ctx, c, http.MethodPost, sendTo, r, size)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// xoHttpDownload streams the content of the XO `$getFrom` path into w and
+// returns the number of bytes written.
+func xoHttpDownload(ctx context.Context, c client.XOClient, getFrom string, w io.Writer) (int64, error) {
+	resp, err := xoHttpRequest(ctx, c, http.MethodGet, getFrom, nil, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	return io.Copy(w, resp.Body)
+}